@@ -51,7 +51,6 @@ require (
 	go.opentelemetry.io/otel/trace v1.38.0 // indirect
 	golang.org/x/crypto v0.43.0 // indirect
 	golang.org/x/net v0.46.1-0.20251013234738-63d1a5100f82 // indirect
-	golang.org/x/oauth2 v0.33.0 // indirect
 	golang.org/x/sync v0.18.0 // indirect
 	golang.org/x/text v0.30.0 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20251103181224-f26f9409b101 // indirect
@@ -69,5 +68,6 @@ require (
 	github.com/mattn/go-isatty v0.0.19 // indirect
 	github.com/mbobakov/grpc-consul-resolver v1.5.3
 	go.mongodb.org/mongo-driver/v2 v2.4.0
+	golang.org/x/oauth2 v0.33.0
 	golang.org/x/sys v0.37.0 // indirect
 )
@@ -40,6 +40,40 @@ load_env() {
   set +a
 }
 
+# Service-to-service authentication
+#
+# The API gateway signs the tokens it sends to the other services, and they verify them with its
+# public key. The key pair is only generated on the first run and read back from Vault afterwards,
+# so running this script again does not invalidate tokens that running pods have already issued.
+SERVICE_TOKEN_SIGNING_KEY="$(
+  vault kv get -field=SERVICE_TOKEN_SIGNING_KEY secret/api-gateway/service-auth 2>/dev/null || true
+)"
+if [ -z "$SERVICE_TOKEN_SIGNING_KEY" ]; then
+  echo "[api-gateway] Generating service token key pair..."
+  SERVICE_TOKEN_SIGNING_KEY="$(openssl genpkey -algorithm ed25519)"
+fi
+SERVICE_TOKEN_VERIFICATION_KEY="$(echo "$SERVICE_TOKEN_SIGNING_KEY" | openssl pkey -pubout)"
+
+# API Gateway
+echo "[api-gateway] Creating policy..."
+vault policy write api-gateway - <<EOF
+path "secret/data/api-gateway/*" {
+  capabilities = ["read"]
+}
+EOF
+
+echo "[api-gateway] Creating Kubernetes role..."
+vault write auth/kubernetes/role/api-gateway \
+  bound_service_account_names=money-tracker-api-api-gateway \
+  bound_service_account_namespaces=default \
+  policies=api-gateway \
+  audience=vault \
+  ttl=24h
+
+echo "[api-gateway] Creating secrets in Vault..."
+vault kv put secret/api-gateway/service-auth \
+  SERVICE_TOKEN_SIGNING_KEY="${SERVICE_TOKEN_SIGNING_KEY}"
+
 # Auth Service
 load_env "auth-service"
 
@@ -64,12 +98,27 @@ vault kv put secret/auth-service/mongodb \
   MONGO_DB="${MONGO_DB}"
 
 vault kv put secret/auth-service/jwt \
-  ACCESS_TOKEN_SECRET="${ACCESS_TOKEN_SECRET}" \
+  ACCESS_TOKEN_SIGNING_KEY="${ACCESS_TOKEN_SIGNING_KEY}" \
+  ACCESS_TOKEN_VERIFICATION_KEYS="${ACCESS_TOKEN_VERIFICATION_KEYS}" \
   REFRESH_TOKEN_SECRET="${REFRESH_TOKEN_SECRET}" \
+  PASSWORD_RESET_TOKEN_SECRET="${PASSWORD_RESET_TOKEN_SECRET}" \
+  MFA_TOKEN_SECRET="${MFA_TOKEN_SECRET}" \
+  MAGIC_LINK_TOKEN_SECRET="${MAGIC_LINK_TOKEN_SECRET}" \
   ACCESS_TOKEN_EXPIRES_IN="${ACCESS_TOKEN_EXPIRES_IN}" \
   REFRESH_TOKEN_EXPIRES_IN="${REFRESH_TOKEN_EXPIRES_IN}" \
+  PASSWORD_RESET_TOKEN_EXPIRES_IN="${PASSWORD_RESET_TOKEN_EXPIRES_IN}" \
   TOKEN_ISSUER="${TOKEN_ISSUER}"
 
+vault kv put secret/auth-service/mfa \
+  MFA_ENCRYPTION_KEY="${MFA_ENCRYPTION_KEY}"
+
+vault kv put secret/auth-service/password \
+  PASSWORD_PEPPER="${PASSWORD_PEPPER}"
+
+# Callers are "<service name>:<PEM public key>" pairs separated by ";"
+vault kv put secret/auth-service/service-auth \
+  SERVICE_CALLER_KEYS="api-gateway:${SERVICE_TOKEN_VERIFICATION_KEY}"
+
 vault kv put secret/auth-service/smtp \
   SMTP_HOST="${SMTP_HOST}" \
   SMTP_PORT="${SMTP_PORT}" \
@@ -8,11 +8,13 @@ import (
 	"time"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
 
 	"github.com/vasapolrittideah/money-tracker-api/services/api-gateway/internal/config"
 	"github.com/vasapolrittideah/money-tracker-api/services/api-gateway/internal/handler"
+	"github.com/vasapolrittideah/money-tracker-api/services/api-gateway/internal/middleware"
 	authclient "github.com/vasapolrittideah/money-tracker-api/services/auth-service/pkg/client"
+	"github.com/vasapolrittideah/money-tracker-api/shared/auth"
 	"github.com/vasapolrittideah/money-tracker-api/shared/discovery"
 	"github.com/vasapolrittideah/money-tracker-api/shared/logger"
 )
@@ -24,10 +26,11 @@ func main() {
 
 	r := chi.NewRouter()
 
-	r.Use(middleware.RequestID)
-	r.Use(middleware.RealIP)
-	r.Use(middleware.Logger)
-	r.Use(middleware.Recoverer)
+	r.Use(chimiddleware.RequestID)
+	r.Use(middleware.ClientIP(apiGatewayCfg.TrustedProxies))
+	r.Use(middleware.DeviceID())
+	r.Use(chimiddleware.Logger)
+	r.Use(chimiddleware.Recoverer)
 
 	server := &http.Server{
 		Addr:         apiGatewayCfg.Address,
@@ -42,16 +45,25 @@ func main() {
 		logger.Fatal().Err(err).Msg("failed to create Consul registry")
 	}
 
+	serviceTokenSigningKey, err := auth.ParseSigningKeyPEM([]byte(apiGatewayCfg.ServiceTokenSigningKey))
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to parse service token signing key")
+	}
+	serviceTokens := auth.NewServiceTokenIssuer(apiGatewayCfg.Name, serviceTokenSigningKey)
+
 	authServiceClient, err := authclient.NewAuthServiceClient(
 		"auth-service",
 		consulRegistry,
+		serviceTokens,
 	)
 	if err != nil {
 		logger.Fatal().Err(err).Msg("failed to create auth service client")
 	}
 
 	authHandler := handler.NewAuthHTTPHandler(logger, authServiceClient)
+	authHandler.RegisterWellKnownRoutes(r)
 	r.Route("/api/v1", func(r chi.Router) {
+		r.Use(middleware.PersonalAccessTokens(logger, authServiceClient))
 		authHandler.RegisterRoutes(r)
 	})
 
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"net/netip"
+
 	"github.com/caarlos0/env/v11"
 	"github.com/rs/zerolog"
 )
@@ -9,6 +11,14 @@ type APIGatewayConfig struct {
 	Environment string `env:"ENVIRONMENT"`
 	Name        string `env:"SERVICE_NAME"`
 	Address     string `env:"SERVICE_ADDRESS"`
+	// ServiceTokenSigningKey is the PEM encoded RSA or Ed25519 private key the gateway signs the tokens
+	// it authenticates to the services with. The services are configured with its public key.
+	ServiceTokenSigningKey string `env:"SERVICE_TOKEN_SIGNING_KEY"`
+	// TrustedProxies are the address ranges of the load balancers and proxies in front of the gateway,
+	// e.g. "10.0.0.0/8,192.168.1.10/32". Only requests coming from them have their client address
+	// taken from X-Forwarded-For; any other client could put whatever address it likes there to
+	// get around the login throttle.
+	TrustedProxies []netip.Prefix `env:"TRUSTED_PROXIES"`
 }
 
 func NewAPIGatewayConfig(logger *zerolog.Logger) *APIGatewayConfig {
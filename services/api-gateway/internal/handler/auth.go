@@ -1,16 +1,23 @@
 package handler
 
 import (
+	"encoding/base64"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/rs/zerolog"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
+	"github.com/vasapolrittideah/money-tracker-api/services/api-gateway/internal/middleware"
 	"github.com/vasapolrittideah/money-tracker-api/services/api-gateway/internal/payload"
 	authclient "github.com/vasapolrittideah/money-tracker-api/services/auth-service/pkg/client"
+	authtypes "github.com/vasapolrittideah/money-tracker-api/services/auth-service/pkg/types"
 	authpbv1 "github.com/vasapolrittideah/money-tracker-api/shared/protos/auth/v1"
 	"github.com/vasapolrittideah/money-tracker-api/shared/utilities"
 	"github.com/vasapolrittideah/money-tracker-api/shared/validator"
+	"github.com/vasapolrittideah/money-tracker-api/shared/webauthn"
 )
 
 type AuthHTTPHandler struct {
@@ -34,9 +41,71 @@ func (h *AuthHTTPHandler) RegisterRoutes(r chi.Router) {
 	r.Route("/auth", func(r chi.Router) {
 		r.Post("/login", h.login)
 		r.Post("/register", h.register)
+		r.Post("/google", h.loginWithGoogle)
+		r.Post("/magic-link", h.requestMagicLink)
+		r.Post("/magic-link/login", h.loginWithMagicLink)
+		r.Get("/oauth/{provider}/authorize", h.startOAuthLogin)
+		// Some providers, such as Apple, post the callback as a form instead of redirecting
+		r.Get("/oauth/{provider}/callback", h.completeOAuthLogin)
+		r.Post("/oauth/{provider}/callback", h.completeOAuthLogin)
+		r.Post("/verify-email", h.verifyEmail)
+		r.Post("/resend-verification", h.resendVerificationEmail)
+		r.Post("/refresh", h.refreshTokens)
+		r.Post("/mfa/verify", h.verifyMFA)
+		r.Post("/mfa/totp", h.enrollTOTP)
+		r.Post("/mfa/totp/confirm", h.confirmTOTP)
+		r.Post("/mfa/totp/disable", h.disableTOTP)
+		r.Post("/mfa/recovery-codes", h.regenerateRecoveryCodes)
+		r.Post("/passkeys/register/begin", h.beginPasskeyRegistration)
+		r.Post("/passkeys/register/finish", h.finishPasskeyRegistration)
+		r.Post("/passkeys/login/begin", h.beginPasskeyLogin)
+		r.Post("/passkeys/login/finish", h.finishPasskeyLogin)
+		r.Post("/change-password", h.changePassword)
+		r.Post("/email/change", h.requestEmailChange)
+		r.Post("/email/change/confirm", h.confirmEmailChange)
+		r.Post("/email/change/cancel", h.cancelEmailChange)
+		r.Post("/logout", h.logout)
+		r.Post("/logout-all", h.logoutAll)
+		r.Get("/sessions", h.listSessions)
+		r.Delete("/sessions/{sessionID}", h.revokeSession)
+		r.Get("/identities", h.listIdentities)
+		r.Post("/identities", h.linkIdentity)
+		r.Delete("/identities/{identityID}", h.unlinkIdentity)
+		r.Delete("/account", h.deleteAccount)
+		r.Post("/data-export", h.requestDataExport)
+		r.Post("/data-export/download", h.downloadDataExport)
+		r.Get("/security-events", h.listSecurityEvents)
+		r.Get("/login-alerts", h.getLoginAlerts)
+		r.Put("/login-alerts", h.updateLoginAlerts)
+		r.Post("/login-alerts/report", h.reportLogin)
+		r.Get("/tokens", h.listPersonalAccessTokens)
+		r.Post("/tokens", h.createPersonalAccessToken)
+		r.Delete("/tokens/{tokenID}", h.revokePersonalAccessToken)
+	})
+
+	r.Route("/admin", func(r chi.Router) {
+		r.With(middleware.RequirePermission(h.logger, h.authServiceClient, authtypes.PermissionUsersWrite)).
+			Put("/users/{userID}/roles", h.setUserRoles)
+	})
+
+	r.Route("/oauth", func(r chi.Router) {
+		r.Get("/authorize", h.getOAuthAuthorizationRequest)
+		r.Post("/authorize", h.authorizeOAuthClient)
+		r.Post("/token", h.exchangeOAuthToken)
+		r.Post("/revoke", h.revokeOAuthToken)
+		r.Get("/clients", h.listOAuthClients)
+		r.Post("/clients", h.createOAuthClient)
+		r.Delete("/clients/{clientID}", h.deleteOAuthClient)
+		r.Get("/consents", h.listOAuthConsents)
+		r.Delete("/consents/{clientID}", h.revokeOAuthConsent)
 	})
 }
 
+// RegisterWellKnownRoutes registers the routes served at fixed paths from the root of the API.
+func (h *AuthHTTPHandler) RegisterWellKnownRoutes(r chi.Router) {
+	r.Get("/.well-known/jwks.json", h.getJSONWebKeySet)
+}
+
 func (h *AuthHTTPHandler) login(w http.ResponseWriter, r *http.Request) {
 	var req payload.LoginRequest
 	if err := utilities.ReadJSON(w, r, &req); err != nil {
@@ -49,7 +118,9 @@ func (h *AuthHTTPHandler) login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	grpcResp, err := h.authServiceClient.Client.Login(r.Context(), &authpbv1.LoginRequest{
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	grpcResp, err := h.authServiceClient.Client.Login(ctx, &authpbv1.LoginRequest{
 		Email:    req.Email,
 		Password: req.Password,
 	})
@@ -61,6 +132,8 @@ func (h *AuthHTTPHandler) login(w http.ResponseWriter, r *http.Request) {
 	payload := &payload.LoginResponse{
 		AccessToken:  grpcResp.AccessToken,
 		RefreshToken: grpcResp.RefreshToken,
+		MFARequired:  grpcResp.MfaRequired,
+		MFAToken:     grpcResp.MfaToken,
 	}
 
 	utilities.WriteSuccessResponse(w, r, payload, h.logger)
@@ -78,7 +151,9 @@ func (h *AuthHTTPHandler) register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	grpcResp, err := h.authServiceClient.Client.Register(r.Context(), &authpbv1.RegisterRequest{
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	grpcResp, err := h.authServiceClient.Client.Register(ctx, &authpbv1.RegisterRequest{
 		Email:    req.Email,
 		Password: req.Password,
 	})
@@ -88,9 +163,1200 @@ func (h *AuthHTTPHandler) register(w http.ResponseWriter, r *http.Request) {
 	}
 
 	payload := &payload.RegisterResponse{
+		AccessToken:          grpcResp.AccessToken,
+		RefreshToken:         grpcResp.RefreshToken,
+		VerificationRequired: grpcResp.VerificationRequired,
+	}
+
+	utilities.WriteSuccessResponse(w, r, payload, h.logger)
+}
+
+func (h *AuthHTTPHandler) loginWithGoogle(w http.ResponseWriter, r *http.Request) {
+	var req payload.LoginWithGoogleRequest
+	if err := utilities.ReadJSON(w, r, &req); err != nil {
+		utilities.WriteRequestErrorResponse(w, r, err.Error(), h.logger)
+		return
+	}
+
+	if errs := validator.ValidateStruct(req); errs != nil {
+		utilities.WriteValidationErrorResponse(w, r, errs, h.logger)
+		return
+	}
+
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	grpcResp, err := h.authServiceClient.Client.LoginWithGoogle(ctx, &authpbv1.LoginWithGoogleRequest{
+		IdToken: req.IDToken,
+	})
+	if err != nil {
+		utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+		return
+	}
+
+	payload := &payload.LoginWithGoogleResponse{
+		AccessToken:  grpcResp.AccessToken,
+		RefreshToken: grpcResp.RefreshToken,
+		MFARequired:  grpcResp.MfaRequired,
+		MFAToken:     grpcResp.MfaToken,
+	}
+
+	utilities.WriteSuccessResponse(w, r, payload, h.logger)
+}
+
+func (h *AuthHTTPHandler) requestMagicLink(w http.ResponseWriter, r *http.Request) {
+	var req payload.RequestMagicLinkRequest
+	if err := utilities.ReadJSON(w, r, &req); err != nil {
+		utilities.WriteRequestErrorResponse(w, r, err.Error(), h.logger)
+		return
+	}
+
+	if errs := validator.ValidateStruct(req); errs != nil {
+		utilities.WriteValidationErrorResponse(w, r, errs, h.logger)
+		return
+	}
+
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	if _, err := h.authServiceClient.Client.RequestMagicLink(ctx, &authpbv1.RequestMagicLinkRequest{
+		Email: req.Email,
+	}); err != nil {
+		utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+		return
+	}
+
+	utilities.WriteSuccessResponse(w, r, nil, h.logger)
+}
+
+func (h *AuthHTTPHandler) loginWithMagicLink(w http.ResponseWriter, r *http.Request) {
+	var req payload.LoginWithMagicLinkRequest
+	if err := utilities.ReadJSON(w, r, &req); err != nil {
+		utilities.WriteRequestErrorResponse(w, r, err.Error(), h.logger)
+		return
+	}
+
+	if errs := validator.ValidateStruct(req); errs != nil {
+		utilities.WriteValidationErrorResponse(w, r, errs, h.logger)
+		return
+	}
+
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	grpcResp, err := h.authServiceClient.Client.LoginWithMagicLink(ctx, &authpbv1.LoginWithMagicLinkRequest{
+		Token: req.Token,
+	})
+	if err != nil {
+		utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+		return
+	}
+
+	payload := &payload.LoginWithMagicLinkResponse{
+		AccessToken:  grpcResp.AccessToken,
+		RefreshToken: grpcResp.RefreshToken,
+		MFARequired:  grpcResp.MfaRequired,
+		MFAToken:     grpcResp.MfaToken,
+	}
+
+	utilities.WriteSuccessResponse(w, r, payload, h.logger)
+}
+
+func (h *AuthHTTPHandler) startOAuthLogin(w http.ResponseWriter, r *http.Request) {
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	grpcResp, err := h.authServiceClient.Client.StartOAuthLogin(ctx, &authpbv1.StartOAuthLoginRequest{
+		Provider: chi.URLParam(r, "provider"),
+	})
+	if err != nil {
+		utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+		return
+	}
+
+	if err := setOAuthStateCookie(w, grpcResp.AuthorizationUrl); err != nil {
+		utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+		return
+	}
+
+	http.Redirect(w, r, grpcResp.AuthorizationUrl, http.StatusFound)
+}
+
+func (h *AuthHTTPHandler) completeOAuthLogin(w http.ResponseWriter, r *http.Request) {
+	if providerErr := r.FormValue("error"); providerErr != "" {
+		utilities.WriteRequestErrorResponse(w, r, "authorization failed: "+providerErr, h.logger)
+		return
+	}
+
+	req := payload.CompleteOAuthLoginRequest{
+		State: r.FormValue("state"),
+		Code:  r.FormValue("code"),
+	}
+	if errs := validator.ValidateStruct(req); errs != nil {
+		utilities.WriteValidationErrorResponse(w, r, errs, h.logger)
+		return
+	}
+
+	if !consumeOAuthStateCookie(w, r, req.State) {
+		utilities.WriteRequestErrorResponse(w, r, "the sign-in was not started in this browser", h.logger)
+		return
+	}
+
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	grpcResp, err := h.authServiceClient.Client.CompleteOAuthLogin(ctx, &authpbv1.CompleteOAuthLoginRequest{
+		Provider: chi.URLParam(r, "provider"),
+		State:    req.State,
+		Code:     req.Code,
+	})
+	if err != nil {
+		utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+		return
+	}
+
+	payload := &payload.CompleteOAuthLoginResponse{
+		AccessToken:  grpcResp.AccessToken,
+		RefreshToken: grpcResp.RefreshToken,
+		MFARequired:  grpcResp.MfaRequired,
+		MFAToken:     grpcResp.MfaToken,
+	}
+	if grpcResp.LinkedIdentity != nil {
+		linkedIdentity := identityFromProto(grpcResp.LinkedIdentity)
+		payload.LinkedIdentity = &linkedIdentity
+	}
+
+	utilities.WriteSuccessResponse(w, r, payload, h.logger)
+}
+
+func (h *AuthHTTPHandler) verifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req payload.VerifyEmailRequest
+	if err := utilities.ReadJSON(w, r, &req); err != nil {
+		utilities.WriteRequestErrorResponse(w, r, err.Error(), h.logger)
+		return
+	}
+
+	if errs := validator.ValidateStruct(req); errs != nil {
+		utilities.WriteValidationErrorResponse(w, r, errs, h.logger)
+		return
+	}
+
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	if _, err := h.authServiceClient.Client.VerifyEmail(ctx, &authpbv1.VerifyEmailRequest{
+		Email: req.Email,
+		Code:  req.Code,
+	}); err != nil {
+		utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+		return
+	}
+
+	utilities.WriteSuccessResponse(w, r, nil, h.logger)
+}
+
+func (h *AuthHTTPHandler) resendVerificationEmail(w http.ResponseWriter, r *http.Request) {
+	var req payload.ResendVerificationEmailRequest
+	if err := utilities.ReadJSON(w, r, &req); err != nil {
+		utilities.WriteRequestErrorResponse(w, r, err.Error(), h.logger)
+		return
+	}
+
+	if errs := validator.ValidateStruct(req); errs != nil {
+		utilities.WriteValidationErrorResponse(w, r, errs, h.logger)
+		return
+	}
+
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	if _, err := h.authServiceClient.Client.ResendVerificationEmail(ctx, &authpbv1.ResendVerificationEmailRequest{
+		Email: req.Email,
+	}); err != nil {
+		utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+		return
+	}
+
+	utilities.WriteSuccessResponse(w, r, nil, h.logger)
+}
+
+func (h *AuthHTTPHandler) refreshTokens(w http.ResponseWriter, r *http.Request) {
+	var req payload.RefreshTokensRequest
+	if err := utilities.ReadJSON(w, r, &req); err != nil {
+		utilities.WriteRequestErrorResponse(w, r, err.Error(), h.logger)
+		return
+	}
+
+	if errs := validator.ValidateStruct(req); errs != nil {
+		utilities.WriteValidationErrorResponse(w, r, errs, h.logger)
+		return
+	}
+
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	grpcResp, err := h.authServiceClient.Client.RefreshTokens(ctx, &authpbv1.RefreshTokensRequest{
+		RefreshToken: req.RefreshToken,
+	})
+	if err != nil {
+		utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+		return
+	}
+
+	payload := &payload.RefreshTokensResponse{
+		AccessToken:  grpcResp.AccessToken,
+		RefreshToken: grpcResp.RefreshToken,
+	}
+
+	utilities.WriteSuccessResponse(w, r, payload, h.logger)
+}
+
+func (h *AuthHTTPHandler) getJSONWebKeySet(w http.ResponseWriter, r *http.Request) {
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	grpcResp, err := h.authServiceClient.Client.GetJSONWebKeySet(ctx, &authpbv1.GetJSONWebKeySetRequest{})
+	if err != nil {
+		utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+		return
+	}
+
+	keys := make([]payload.JSONWebKey, 0, len(grpcResp.Keys))
+	for _, key := range grpcResp.Keys {
+		keys = append(keys, payload.JSONWebKey{
+			Kty: key.Kty,
+			Kid: key.Kid,
+			Use: key.Use,
+			Alg: key.Alg,
+			N:   key.N,
+			E:   key.E,
+			Crv: key.Crv,
+			X:   key.X,
+		})
+	}
+
+	payload := &payload.JSONWebKeySet{
+		Keys: keys,
+	}
+
+	// Verifiers fetch the keys again when they see an unknown key ID, so a short cache is enough
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	if err := utilities.WriteJSON(w, http.StatusOK, payload); err != nil {
+		h.logger.Error().Err(err).Msg("failed to write JSON web key set")
+	}
+}
+
+func (h *AuthHTTPHandler) verifyMFA(w http.ResponseWriter, r *http.Request) {
+	var req payload.VerifyMFARequest
+	if err := utilities.ReadJSON(w, r, &req); err != nil {
+		utilities.WriteRequestErrorResponse(w, r, err.Error(), h.logger)
+		return
+	}
+
+	if errs := validator.ValidateStruct(req); errs != nil {
+		utilities.WriteValidationErrorResponse(w, r, errs, h.logger)
+		return
+	}
+
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	grpcResp, err := h.authServiceClient.Client.VerifyMFA(ctx, &authpbv1.VerifyMFARequest{
+		MfaToken: req.MFAToken,
+		Code:     req.Code,
+	})
+	if err != nil {
+		utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+		return
+	}
+
+	payload := &payload.VerifyMFAResponse{
 		AccessToken:  grpcResp.AccessToken,
 		RefreshToken: grpcResp.RefreshToken,
 	}
 
 	utilities.WriteSuccessResponse(w, r, payload, h.logger)
 }
+
+func (h *AuthHTTPHandler) enrollTOTP(w http.ResponseWriter, r *http.Request) {
+	var req payload.EnrollTOTPRequest
+	if err := utilities.ReadJSON(w, r, &req); err != nil {
+		utilities.WriteRequestErrorResponse(w, r, err.Error(), h.logger)
+		return
+	}
+
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	grpcResp, err := h.authServiceClient.Client.EnrollTOTP(ctx, &authpbv1.EnrollTOTPRequest{
+		Password: req.Password,
+	})
+	if err != nil {
+		utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+		return
+	}
+
+	payload := &payload.EnrollTOTPResponse{
+		Secret:          grpcResp.Secret,
+		ProvisioningURI: grpcResp.ProvisioningUri,
+	}
+
+	utilities.WriteSuccessResponse(w, r, payload, h.logger)
+}
+
+func (h *AuthHTTPHandler) confirmTOTP(w http.ResponseWriter, r *http.Request) {
+	var req payload.ConfirmTOTPRequest
+	if err := utilities.ReadJSON(w, r, &req); err != nil {
+		utilities.WriteRequestErrorResponse(w, r, err.Error(), h.logger)
+		return
+	}
+
+	if errs := validator.ValidateStruct(req); errs != nil {
+		utilities.WriteValidationErrorResponse(w, r, errs, h.logger)
+		return
+	}
+
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	grpcResp, err := h.authServiceClient.Client.ConfirmTOTP(ctx, &authpbv1.ConfirmTOTPRequest{
+		Code: req.Code,
+	})
+	if err != nil {
+		utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+		return
+	}
+
+	payload := &payload.RecoveryCodesResponse{
+		RecoveryCodes: grpcResp.RecoveryCodes,
+	}
+
+	utilities.WriteSuccessResponse(w, r, payload, h.logger)
+}
+
+func (h *AuthHTTPHandler) disableTOTP(w http.ResponseWriter, r *http.Request) {
+	var req payload.DisableTOTPRequest
+	if err := utilities.ReadJSON(w, r, &req); err != nil {
+		utilities.WriteRequestErrorResponse(w, r, err.Error(), h.logger)
+		return
+	}
+
+	if errs := validator.ValidateStruct(req); errs != nil {
+		utilities.WriteValidationErrorResponse(w, r, errs, h.logger)
+		return
+	}
+
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	if _, err := h.authServiceClient.Client.DisableTOTP(ctx, &authpbv1.DisableTOTPRequest{
+		Password: req.Password,
+		Code:     req.Code,
+	}); err != nil {
+		utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+		return
+	}
+
+	utilities.WriteSuccessResponse(w, r, nil, h.logger)
+}
+
+func (h *AuthHTTPHandler) regenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	var req payload.RegenerateRecoveryCodesRequest
+	if err := utilities.ReadJSON(w, r, &req); err != nil {
+		utilities.WriteRequestErrorResponse(w, r, err.Error(), h.logger)
+		return
+	}
+
+	if errs := validator.ValidateStruct(req); errs != nil {
+		utilities.WriteValidationErrorResponse(w, r, errs, h.logger)
+		return
+	}
+
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	grpcResp, err := h.authServiceClient.Client.RegenerateRecoveryCodes(ctx, &authpbv1.RegenerateRecoveryCodesRequest{
+		Password: req.Password,
+		Code:     req.Code,
+	})
+	if err != nil {
+		utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+		return
+	}
+
+	payload := &payload.RecoveryCodesResponse{
+		RecoveryCodes: grpcResp.RecoveryCodes,
+	}
+
+	utilities.WriteSuccessResponse(w, r, payload, h.logger)
+}
+
+func (h *AuthHTTPHandler) beginPasskeyRegistration(w http.ResponseWriter, r *http.Request) {
+	var req payload.BeginPasskeyRegistrationRequest
+	if err := utilities.ReadJSON(w, r, &req); err != nil {
+		utilities.WriteRequestErrorResponse(w, r, err.Error(), h.logger)
+		return
+	}
+
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	grpcResp, err := h.authServiceClient.Client.BeginPasskeyRegistration(ctx, &authpbv1.BeginPasskeyRegistrationRequest{
+		Password: req.Password,
+	})
+	if err != nil {
+		utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+		return
+	}
+
+	pubKeyCredParams := make([]payload.PublicKeyCredentialParameters, 0, len(webauthn.SupportedAlgorithms))
+	for _, algorithm := range webauthn.SupportedAlgorithms {
+		pubKeyCredParams = append(pubKeyCredParams, payload.PublicKeyCredentialParameters{
+			Type: publicKeyCredentialType,
+			Alg:  algorithm,
+		})
+	}
+
+	excludeCredentials := make([]payload.PublicKeyCredentialDescriptor, 0, len(grpcResp.ExcludeCredentialIds))
+	for _, credentialID := range grpcResp.ExcludeCredentialIds {
+		excludeCredentials = append(excludeCredentials, payload.PublicKeyCredentialDescriptor{
+			Type: publicKeyCredentialType,
+			ID:   credentialID,
+		})
+	}
+
+	payload := &payload.BeginPasskeyRegistrationResponse{
+		PublicKey: payload.PublicKeyCredentialCreationOptions{
+			Challenge: grpcResp.Challenge,
+			RP: payload.PublicKeyCredentialRPEntity{
+				ID:   grpcResp.RpId,
+				Name: grpcResp.RpName,
+			},
+			User: payload.PublicKeyCredentialUserEntity{
+				ID:          base64.RawURLEncoding.EncodeToString(grpcResp.UserHandle),
+				Name:        grpcResp.UserName,
+				DisplayName: grpcResp.UserName,
+			},
+			PubKeyCredParams:   pubKeyCredParams,
+			Timeout:            grpcResp.TimeoutMs,
+			ExcludeCredentials: excludeCredentials,
+			// Passkeys have to be discoverable, since logins do not ask for an email address first
+			AuthenticatorSelection: payload.AuthenticatorSelectionCriteria{
+				ResidentKey:      "required",
+				UserVerification: "required",
+			},
+			Attestation: "none",
+		},
+	}
+
+	utilities.WriteSuccessResponse(w, r, payload, h.logger)
+}
+
+func (h *AuthHTTPHandler) finishPasskeyRegistration(w http.ResponseWriter, r *http.Request) {
+	var req payload.FinishPasskeyRegistrationRequest
+	if err := utilities.ReadJSON(w, r, &req); err != nil {
+		utilities.WriteRequestErrorResponse(w, r, err.Error(), h.logger)
+		return
+	}
+
+	if errs := validator.ValidateStruct(req); errs != nil {
+		utilities.WriteValidationErrorResponse(w, r, errs, h.logger)
+		return
+	}
+
+	clientDataJSON, err := decodeBase64URL(req.Response.ClientDataJSON)
+	if err != nil {
+		utilities.WriteRequestErrorResponse(w, r, "clientDataJSON is not base64url encoded", h.logger)
+		return
+	}
+
+	attestationObject, err := decodeBase64URL(req.Response.AttestationObject)
+	if err != nil {
+		utilities.WriteRequestErrorResponse(w, r, "attestationObject is not base64url encoded", h.logger)
+		return
+	}
+
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	grpcResp, err := h.authServiceClient.Client.FinishPasskeyRegistration(
+		ctx,
+		&authpbv1.FinishPasskeyRegistrationRequest{
+			ClientDataJson:    clientDataJSON,
+			AttestationObject: attestationObject,
+			Transports:        req.Response.Transports,
+			Name:              req.Name,
+		},
+	)
+	if err != nil {
+		utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+		return
+	}
+
+	payload := &payload.FinishPasskeyRegistrationResponse{
+		Passkey: passkeyFromProto(grpcResp.Passkey),
+	}
+
+	utilities.WriteSuccessResponse(w, r, payload, h.logger)
+}
+
+func (h *AuthHTTPHandler) beginPasskeyLogin(w http.ResponseWriter, r *http.Request) {
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	grpcResp, err := h.authServiceClient.Client.BeginPasskeyLogin(ctx, &authpbv1.BeginPasskeyLoginRequest{})
+	if err != nil {
+		utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+		return
+	}
+
+	payload := &payload.BeginPasskeyLoginResponse{
+		PublicKey: payload.PublicKeyCredentialRequestOptions{
+			Challenge:        grpcResp.Challenge,
+			RPID:             grpcResp.RpId,
+			Timeout:          grpcResp.TimeoutMs,
+			UserVerification: "required",
+		},
+	}
+
+	utilities.WriteSuccessResponse(w, r, payload, h.logger)
+}
+
+func (h *AuthHTTPHandler) finishPasskeyLogin(w http.ResponseWriter, r *http.Request) {
+	var req payload.FinishPasskeyLoginRequest
+	if err := utilities.ReadJSON(w, r, &req); err != nil {
+		utilities.WriteRequestErrorResponse(w, r, err.Error(), h.logger)
+		return
+	}
+
+	if errs := validator.ValidateStruct(req); errs != nil {
+		utilities.WriteValidationErrorResponse(w, r, errs, h.logger)
+		return
+	}
+
+	credentialID, err := decodeBase64URL(req.ID)
+	if err != nil {
+		utilities.WriteRequestErrorResponse(w, r, "id is not base64url encoded", h.logger)
+		return
+	}
+
+	clientDataJSON, err := decodeBase64URL(req.Response.ClientDataJSON)
+	if err != nil {
+		utilities.WriteRequestErrorResponse(w, r, "clientDataJSON is not base64url encoded", h.logger)
+		return
+	}
+
+	authenticatorData, err := decodeBase64URL(req.Response.AuthenticatorData)
+	if err != nil {
+		utilities.WriteRequestErrorResponse(w, r, "authenticatorData is not base64url encoded", h.logger)
+		return
+	}
+
+	signature, err := decodeBase64URL(req.Response.Signature)
+	if err != nil {
+		utilities.WriteRequestErrorResponse(w, r, "signature is not base64url encoded", h.logger)
+		return
+	}
+
+	userHandle, err := decodeBase64URL(req.Response.UserHandle)
+	if err != nil {
+		utilities.WriteRequestErrorResponse(w, r, "userHandle is not base64url encoded", h.logger)
+		return
+	}
+
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	grpcResp, err := h.authServiceClient.Client.FinishPasskeyLogin(ctx, &authpbv1.FinishPasskeyLoginRequest{
+		CredentialId:      credentialID,
+		ClientDataJson:    clientDataJSON,
+		AuthenticatorData: authenticatorData,
+		Signature:         signature,
+		UserHandle:        userHandle,
+	})
+	if err != nil {
+		utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+		return
+	}
+
+	payload := &payload.FinishPasskeyLoginResponse{
+		AccessToken:  grpcResp.AccessToken,
+		RefreshToken: grpcResp.RefreshToken,
+	}
+
+	utilities.WriteSuccessResponse(w, r, payload, h.logger)
+}
+
+func (h *AuthHTTPHandler) logout(w http.ResponseWriter, r *http.Request) {
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	if _, err := h.authServiceClient.Client.Logout(ctx, &authpbv1.LogoutRequest{}); err != nil {
+		utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+		return
+	}
+
+	utilities.WriteSuccessResponse(w, r, nil, h.logger)
+}
+
+func (h *AuthHTTPHandler) changePassword(w http.ResponseWriter, r *http.Request) {
+	var req payload.ChangePasswordRequest
+	if err := utilities.ReadJSON(w, r, &req); err != nil {
+		utilities.WriteRequestErrorResponse(w, r, err.Error(), h.logger)
+		return
+	}
+
+	if errs := validator.ValidateStruct(req); errs != nil {
+		utilities.WriteValidationErrorResponse(w, r, errs, h.logger)
+		return
+	}
+
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	if _, err := h.authServiceClient.Client.ChangePassword(ctx, &authpbv1.ChangePasswordRequest{
+		CurrentPassword: req.CurrentPassword,
+		NewPassword:     req.NewPassword,
+	}); err != nil {
+		utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+		return
+	}
+
+	utilities.WriteSuccessResponse(w, r, nil, h.logger)
+}
+
+func (h *AuthHTTPHandler) requestEmailChange(w http.ResponseWriter, r *http.Request) {
+	var req payload.RequestEmailChangeRequest
+	if err := utilities.ReadJSON(w, r, &req); err != nil {
+		utilities.WriteRequestErrorResponse(w, r, err.Error(), h.logger)
+		return
+	}
+
+	if errs := validator.ValidateStruct(req); errs != nil {
+		utilities.WriteValidationErrorResponse(w, r, errs, h.logger)
+		return
+	}
+
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	if _, err := h.authServiceClient.Client.RequestEmailChange(ctx, &authpbv1.RequestEmailChangeRequest{
+		NewEmail: req.NewEmail,
+		Password: req.Password,
+	}); err != nil {
+		utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+		return
+	}
+
+	utilities.WriteSuccessResponse(w, r, nil, h.logger)
+}
+
+func (h *AuthHTTPHandler) confirmEmailChange(w http.ResponseWriter, r *http.Request) {
+	var req payload.EmailChangeTokenRequest
+	if err := utilities.ReadJSON(w, r, &req); err != nil {
+		utilities.WriteRequestErrorResponse(w, r, err.Error(), h.logger)
+		return
+	}
+
+	if errs := validator.ValidateStruct(req); errs != nil {
+		utilities.WriteValidationErrorResponse(w, r, errs, h.logger)
+		return
+	}
+
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	if _, err := h.authServiceClient.Client.ConfirmEmailChange(ctx, &authpbv1.ConfirmEmailChangeRequest{
+		Token: req.Token,
+	}); err != nil {
+		utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+		return
+	}
+
+	utilities.WriteSuccessResponse(w, r, nil, h.logger)
+}
+
+func (h *AuthHTTPHandler) cancelEmailChange(w http.ResponseWriter, r *http.Request) {
+	var req payload.EmailChangeTokenRequest
+	if err := utilities.ReadJSON(w, r, &req); err != nil {
+		utilities.WriteRequestErrorResponse(w, r, err.Error(), h.logger)
+		return
+	}
+
+	if errs := validator.ValidateStruct(req); errs != nil {
+		utilities.WriteValidationErrorResponse(w, r, errs, h.logger)
+		return
+	}
+
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	if _, err := h.authServiceClient.Client.CancelEmailChange(ctx, &authpbv1.CancelEmailChangeRequest{
+		Token: req.Token,
+	}); err != nil {
+		utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+		return
+	}
+
+	utilities.WriteSuccessResponse(w, r, nil, h.logger)
+}
+
+func (h *AuthHTTPHandler) logoutAll(w http.ResponseWriter, r *http.Request) {
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	if _, err := h.authServiceClient.Client.LogoutAll(ctx, &authpbv1.LogoutAllRequest{}); err != nil {
+		utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+		return
+	}
+
+	utilities.WriteSuccessResponse(w, r, nil, h.logger)
+}
+
+func (h *AuthHTTPHandler) listSessions(w http.ResponseWriter, r *http.Request) {
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	grpcResp, err := h.authServiceClient.Client.ListSessions(ctx, &authpbv1.ListSessionsRequest{})
+	if err != nil {
+		utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+		return
+	}
+
+	sessions := make([]payload.Session, 0, len(grpcResp.Sessions))
+	for _, session := range grpcResp.Sessions {
+		sessions = append(sessions, payload.Session{
+			ID:           session.Id,
+			IPAddress:    session.IpAddress,
+			UserAgent:    session.UserAgent,
+			Current:      session.Current,
+			CreatedAt:    session.CreatedAt.AsTime(),
+			LastActiveAt: session.LastActiveAt.AsTime(),
+		})
+	}
+
+	payload := &payload.ListSessionsResponse{
+		Sessions: sessions,
+	}
+
+	utilities.WriteSuccessResponse(w, r, payload, h.logger)
+}
+
+func (h *AuthHTTPHandler) revokeSession(w http.ResponseWriter, r *http.Request) {
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	if _, err := h.authServiceClient.Client.RevokeSession(ctx, &authpbv1.RevokeSessionRequest{
+		SessionId: chi.URLParam(r, "sessionID"),
+	}); err != nil {
+		utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+		return
+	}
+
+	utilities.WriteSuccessResponse(w, r, nil, h.logger)
+}
+
+func (h *AuthHTTPHandler) listIdentities(w http.ResponseWriter, r *http.Request) {
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	grpcResp, err := h.authServiceClient.Client.ListIdentities(ctx, &authpbv1.ListIdentitiesRequest{})
+	if err != nil {
+		utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+		return
+	}
+
+	identities := make([]payload.Identity, 0, len(grpcResp.Identities))
+	for _, identity := range grpcResp.Identities {
+		identities = append(identities, identityFromProto(identity))
+	}
+
+	payload := &payload.ListIdentitiesResponse{
+		Identities: identities,
+	}
+
+	utilities.WriteSuccessResponse(w, r, payload, h.logger)
+}
+
+func (h *AuthHTTPHandler) linkIdentity(w http.ResponseWriter, r *http.Request) {
+	var req payload.LinkIdentityRequest
+	if err := utilities.ReadJSON(w, r, &req); err != nil {
+		utilities.WriteRequestErrorResponse(w, r, err.Error(), h.logger)
+		return
+	}
+
+	if errs := validator.ValidateStruct(req); errs != nil {
+		utilities.WriteValidationErrorResponse(w, r, errs, h.logger)
+		return
+	}
+
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	grpcResp, err := h.authServiceClient.Client.LinkIdentity(ctx, &authpbv1.LinkIdentityRequest{
+		Provider: req.Provider,
+		Password: req.Password,
+		IdToken:  req.IDToken,
+	})
+	if err != nil {
+		utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+		return
+	}
+
+	// The client is sent to the provider next, and the callback must come back to this browser
+	if grpcResp.AuthorizationUrl != "" {
+		if err := setOAuthStateCookie(w, grpcResp.AuthorizationUrl); err != nil {
+			utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+			return
+		}
+	}
+
+	payload := &payload.LinkIdentityResponse{
+		AuthorizationURL: grpcResp.AuthorizationUrl,
+	}
+	if grpcResp.Identity != nil {
+		identity := identityFromProto(grpcResp.Identity)
+		payload.Identity = &identity
+	}
+
+	utilities.WriteSuccessResponse(w, r, payload, h.logger)
+}
+
+func (h *AuthHTTPHandler) unlinkIdentity(w http.ResponseWriter, r *http.Request) {
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	if _, err := h.authServiceClient.Client.UnlinkIdentity(ctx, &authpbv1.UnlinkIdentityRequest{
+		IdentityId: chi.URLParam(r, "identityID"),
+	}); err != nil {
+		utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+		return
+	}
+
+	utilities.WriteSuccessResponse(w, r, nil, h.logger)
+}
+
+func (h *AuthHTTPHandler) deleteAccount(w http.ResponseWriter, r *http.Request) {
+	var req payload.DeleteAccountRequest
+	if err := utilities.ReadJSON(w, r, &req); err != nil {
+		utilities.WriteRequestErrorResponse(w, r, err.Error(), h.logger)
+		return
+	}
+
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	grpcResp, err := h.authServiceClient.Client.DeleteAccount(ctx, &authpbv1.DeleteAccountRequest{
+		Password: req.Password,
+	})
+	if err != nil {
+		utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+		return
+	}
+
+	payload := &payload.DeleteAccountResponse{
+		PurgeAt: grpcResp.PurgeAt.AsTime(),
+	}
+
+	utilities.WriteSuccessResponse(w, r, payload, h.logger)
+}
+
+func (h *AuthHTTPHandler) requestDataExport(w http.ResponseWriter, r *http.Request) {
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	grpcResp, err := h.authServiceClient.Client.RequestDataExport(ctx, &authpbv1.RequestDataExportRequest{})
+	if err != nil {
+		utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+		return
+	}
+
+	payload := &payload.RequestDataExportResponse{
+		ExpiresAt: grpcResp.ExpiresAt.AsTime(),
+	}
+
+	utilities.WriteSuccessResponse(w, r, payload, h.logger)
+}
+
+func (h *AuthHTTPHandler) downloadDataExport(w http.ResponseWriter, r *http.Request) {
+	var req payload.DownloadDataExportRequest
+	if err := utilities.ReadJSON(w, r, &req); err != nil {
+		utilities.WriteRequestErrorResponse(w, r, err.Error(), h.logger)
+		return
+	}
+
+	if errs := validator.ValidateStruct(req); errs != nil {
+		utilities.WriteValidationErrorResponse(w, r, errs, h.logger)
+		return
+	}
+
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	grpcResp, err := h.authServiceClient.Client.DownloadDataExport(ctx, &authpbv1.DownloadDataExportRequest{
+		Token: req.Token,
+	})
+	if err != nil {
+		utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+		return
+	}
+
+	// The archive is handed over as a file rather than wrapped in an API response
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", `attachment; filename="data-export.json"`)
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(grpcResp.Archive); err != nil {
+		h.logger.Error().Err(err).Msg("failed to write data export")
+	}
+}
+
+func (h *AuthHTTPHandler) listSecurityEvents(w http.ResponseWriter, r *http.Request) {
+	req := payload.ListSecurityEventsRequest{
+		PageToken: r.URL.Query().Get("page_token"),
+		UserID:    r.URL.Query().Get("user_id"),
+	}
+	if pageSize := r.URL.Query().Get("page_size"); pageSize != "" {
+		size, err := strconv.ParseInt(pageSize, 10, 32)
+		if err != nil {
+			utilities.WriteRequestErrorResponse(w, r, "page_size must be a number", h.logger)
+			return
+		}
+		req.PageSize = int32(size)
+	}
+
+	if errs := validator.ValidateStruct(req); errs != nil {
+		utilities.WriteValidationErrorResponse(w, r, errs, h.logger)
+		return
+	}
+
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	grpcResp, err := h.authServiceClient.Client.ListSecurityEvents(ctx, &authpbv1.ListSecurityEventsRequest{
+		PageSize:  req.PageSize,
+		PageToken: req.PageToken,
+		UserId:    req.UserID,
+	})
+	if err != nil {
+		utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+		return
+	}
+
+	events := make([]payload.SecurityEvent, 0, len(grpcResp.Events))
+	for _, event := range grpcResp.Events {
+		events = append(events, payload.SecurityEvent{
+			ID:        event.Id,
+			Type:      event.Type,
+			Outcome:   event.Outcome,
+			Reason:    event.Reason,
+			Method:    event.Method,
+			IPAddress: event.IpAddress,
+			UserAgent: event.UserAgent,
+			CreatedAt: event.CreatedAt.AsTime(),
+		})
+	}
+
+	payload := &payload.ListSecurityEventsResponse{
+		Events:        events,
+		NextPageToken: grpcResp.NextPageToken,
+	}
+
+	utilities.WriteSuccessResponse(w, r, payload, h.logger)
+}
+
+func (h *AuthHTTPHandler) getLoginAlerts(w http.ResponseWriter, r *http.Request) {
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	grpcResp, err := h.authServiceClient.Client.GetLoginAlerts(ctx, &authpbv1.GetLoginAlertsRequest{})
+	if err != nil {
+		utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+		return
+	}
+
+	payload := &payload.LoginAlertsResponse{
+		Rule: grpcResp.Rule,
+	}
+
+	utilities.WriteSuccessResponse(w, r, payload, h.logger)
+}
+
+func (h *AuthHTTPHandler) updateLoginAlerts(w http.ResponseWriter, r *http.Request) {
+	var req payload.UpdateLoginAlertsRequest
+	if err := utilities.ReadJSON(w, r, &req); err != nil {
+		utilities.WriteRequestErrorResponse(w, r, err.Error(), h.logger)
+		return
+	}
+
+	if errs := validator.ValidateStruct(req); errs != nil {
+		utilities.WriteValidationErrorResponse(w, r, errs, h.logger)
+		return
+	}
+
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	if _, err := h.authServiceClient.Client.UpdateLoginAlerts(ctx, &authpbv1.UpdateLoginAlertsRequest{
+		Rule: req.Rule,
+	}); err != nil {
+		utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+		return
+	}
+
+	payload := &payload.LoginAlertsResponse{
+		Rule: req.Rule,
+	}
+
+	utilities.WriteSuccessResponse(w, r, payload, h.logger)
+}
+
+func (h *AuthHTTPHandler) reportLogin(w http.ResponseWriter, r *http.Request) {
+	var req payload.ReportLoginRequest
+	if err := utilities.ReadJSON(w, r, &req); err != nil {
+		utilities.WriteRequestErrorResponse(w, r, err.Error(), h.logger)
+		return
+	}
+
+	if errs := validator.ValidateStruct(req); errs != nil {
+		utilities.WriteValidationErrorResponse(w, r, errs, h.logger)
+		return
+	}
+
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	if _, err := h.authServiceClient.Client.ReportLogin(ctx, &authpbv1.ReportLoginRequest{
+		Token: req.Token,
+	}); err != nil {
+		utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+		return
+	}
+
+	utilities.WriteSuccessResponse(w, r, nil, h.logger)
+}
+
+func (h *AuthHTTPHandler) createPersonalAccessToken(w http.ResponseWriter, r *http.Request) {
+	var req payload.CreatePersonalAccessTokenRequest
+	if err := utilities.ReadJSON(w, r, &req); err != nil {
+		utilities.WriteRequestErrorResponse(w, r, err.Error(), h.logger)
+		return
+	}
+
+	if errs := validator.ValidateStruct(req); errs != nil {
+		utilities.WriteValidationErrorResponse(w, r, errs, h.logger)
+		return
+	}
+
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	grpcReq := &authpbv1.CreatePersonalAccessTokenRequest{
+		Name:     req.Name,
+		Scopes:   req.Scopes,
+		Password: req.Password,
+		Code:     req.Code,
+	}
+	if req.ExpiresAt != nil {
+		grpcReq.ExpiresAt = timestamppb.New(*req.ExpiresAt)
+	}
+
+	grpcResp, err := h.authServiceClient.Client.CreatePersonalAccessToken(ctx, grpcReq)
+	if err != nil {
+		utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+		return
+	}
+
+	payload := &payload.CreatePersonalAccessTokenResponse{
+		PersonalAccessToken: personalAccessTokenFromProto(grpcResp.PersonalAccessToken),
+		Token:               grpcResp.Token,
+	}
+
+	utilities.WriteSuccessResponse(w, r, payload, h.logger)
+}
+
+func (h *AuthHTTPHandler) listPersonalAccessTokens(w http.ResponseWriter, r *http.Request) {
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	grpcResp, err := h.authServiceClient.Client.ListPersonalAccessTokens(
+		ctx,
+		&authpbv1.ListPersonalAccessTokensRequest{},
+	)
+	if err != nil {
+		utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+		return
+	}
+
+	tokens := make([]payload.PersonalAccessToken, 0, len(grpcResp.Tokens))
+	for _, token := range grpcResp.Tokens {
+		tokens = append(tokens, personalAccessTokenFromProto(token))
+	}
+
+	payload := &payload.ListPersonalAccessTokensResponse{
+		Tokens: tokens,
+	}
+
+	utilities.WriteSuccessResponse(w, r, payload, h.logger)
+}
+
+func (h *AuthHTTPHandler) revokePersonalAccessToken(w http.ResponseWriter, r *http.Request) {
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	if _, err := h.authServiceClient.Client.RevokePersonalAccessToken(ctx, &authpbv1.RevokePersonalAccessTokenRequest{
+		Id: chi.URLParam(r, "tokenID"),
+	}); err != nil {
+		utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+		return
+	}
+
+	utilities.WriteSuccessResponse(w, r, nil, h.logger)
+}
+
+func (h *AuthHTTPHandler) setUserRoles(w http.ResponseWriter, r *http.Request) {
+	var req payload.SetUserRolesRequest
+	if err := utilities.ReadJSON(w, r, &req); err != nil {
+		utilities.WriteRequestErrorResponse(w, r, err.Error(), h.logger)
+		return
+	}
+
+	if errs := validator.ValidateStruct(req); errs != nil {
+		utilities.WriteValidationErrorResponse(w, r, errs, h.logger)
+		return
+	}
+
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	grpcResp, err := h.authServiceClient.Client.SetUserRoles(ctx, &authpbv1.SetUserRolesRequest{
+		UserId:      chi.URLParam(r, "userID"),
+		Roles:       req.Roles,
+		Permissions: req.Permissions,
+	})
+	if err != nil {
+		utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+		return
+	}
+
+	payload := &payload.SetUserRolesResponse{
+		UserID:      grpcResp.UserId,
+		Roles:       grpcResp.Roles,
+		Permissions: grpcResp.Permissions,
+	}
+
+	utilities.WriteSuccessResponse(w, r, payload, h.logger)
+}
+
+func personalAccessTokenFromProto(token *authpbv1.PersonalAccessToken) payload.PersonalAccessToken {
+	result := payload.PersonalAccessToken{
+		ID:        token.Id,
+		Name:      token.Name,
+		Scopes:    token.Scopes,
+		CreatedAt: token.CreatedAt.AsTime(),
+	}
+	if token.ExpiresAt != nil {
+		expiresAt := token.ExpiresAt.AsTime()
+		result.ExpiresAt = &expiresAt
+	}
+	if token.LastUsedAt != nil {
+		lastUsedAt := token.LastUsedAt.AsTime()
+		result.LastUsedAt = &lastUsedAt
+	}
+
+	return result
+}
+
+func passkeyFromProto(passkey *authpbv1.Passkey) payload.Passkey {
+	result := payload.Passkey{
+		ID:         passkey.Id,
+		Name:       passkey.Name,
+		Transports: passkey.Transports,
+		CreatedAt:  passkey.CreatedAt.AsTime(),
+	}
+	if passkey.LastUsedAt != nil {
+		lastUsedAt := passkey.LastUsedAt.AsTime()
+		result.LastUsedAt = &lastUsedAt
+	}
+
+	return result
+}
+
+// publicKeyCredentialType is the only credential type WebAuthn defines.
+const publicKeyCredentialType = "public-key"
+
+// decodeBase64URL decodes a base64url value of a WebAuthn response. Padding is optional,
+// since the specification leaves it out but some client libraries add it.
+func decodeBase64URL(value string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+}
+
+func identityFromProto(identity *authpbv1.Identity) payload.Identity {
+	return payload.Identity{
+		ID:          identity.Id,
+		Provider:    identity.Provider,
+		Email:       identity.Email,
+		CreatedAt:   identity.CreatedAt.AsTime(),
+		LastLoginAt: identity.LastLoginAt.AsTime(),
+	}
+}
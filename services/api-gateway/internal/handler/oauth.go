@@ -0,0 +1,344 @@
+package handler
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/api-gateway/internal/payload"
+	authpbv1 "github.com/vasapolrittideah/money-tracker-api/shared/protos/auth/v1"
+	"github.com/vasapolrittideah/money-tracker-api/shared/utilities"
+	"github.com/vasapolrittideah/money-tracker-api/shared/validator"
+)
+
+func (h *AuthHTTPHandler) createOAuthClient(w http.ResponseWriter, r *http.Request) {
+	var req payload.CreateOAuthClientRequest
+	if err := utilities.ReadJSON(w, r, &req); err != nil {
+		utilities.WriteRequestErrorResponse(w, r, err.Error(), h.logger)
+		return
+	}
+
+	if errs := validator.ValidateStruct(req); errs != nil {
+		utilities.WriteValidationErrorResponse(w, r, errs, h.logger)
+		return
+	}
+
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	grpcResp, err := h.authServiceClient.Client.CreateOAuthClient(ctx, &authpbv1.CreateOAuthClientRequest{
+		Name:         req.Name,
+		RedirectUris: req.RedirectURIs,
+		Scopes:       req.Scopes,
+		Confidential: req.Confidential,
+	})
+	if err != nil {
+		utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+		return
+	}
+
+	payload := &payload.CreateOAuthClientResponse{
+		Client:       oauthClientFromProto(grpcResp.Client),
+		ClientSecret: grpcResp.ClientSecret,
+	}
+
+	utilities.WriteSuccessResponse(w, r, payload, h.logger)
+}
+
+func (h *AuthHTTPHandler) listOAuthClients(w http.ResponseWriter, r *http.Request) {
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	grpcResp, err := h.authServiceClient.Client.ListOAuthClients(ctx, &authpbv1.ListOAuthClientsRequest{})
+	if err != nil {
+		utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+		return
+	}
+
+	clients := make([]payload.OAuthClient, 0, len(grpcResp.Clients))
+	for _, client := range grpcResp.Clients {
+		clients = append(clients, oauthClientFromProto(client))
+	}
+
+	payload := &payload.ListOAuthClientsResponse{
+		Clients: clients,
+	}
+
+	utilities.WriteSuccessResponse(w, r, payload, h.logger)
+}
+
+func (h *AuthHTTPHandler) deleteOAuthClient(w http.ResponseWriter, r *http.Request) {
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	if _, err := h.authServiceClient.Client.DeleteOAuthClient(ctx, &authpbv1.DeleteOAuthClientRequest{
+		ClientId: chi.URLParam(r, "clientID"),
+	}); err != nil {
+		utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+		return
+	}
+
+	utilities.WriteSuccessResponse(w, r, nil, h.logger)
+}
+
+func (h *AuthHTTPHandler) listOAuthConsents(w http.ResponseWriter, r *http.Request) {
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	grpcResp, err := h.authServiceClient.Client.ListOAuthConsents(ctx, &authpbv1.ListOAuthConsentsRequest{})
+	if err != nil {
+		utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+		return
+	}
+
+	consents := make([]payload.OAuthConsent, 0, len(grpcResp.Consents))
+	for _, consent := range grpcResp.Consents {
+		consents = append(consents, payload.OAuthConsent{
+			ClientID:   consent.ClientId,
+			ClientName: consent.ClientName,
+			Scopes:     consent.Scopes,
+			CreatedAt:  consent.CreatedAt.AsTime(),
+			UpdatedAt:  consent.UpdatedAt.AsTime(),
+		})
+	}
+
+	payload := &payload.ListOAuthConsentsResponse{
+		Consents: consents,
+	}
+
+	utilities.WriteSuccessResponse(w, r, payload, h.logger)
+}
+
+func (h *AuthHTTPHandler) revokeOAuthConsent(w http.ResponseWriter, r *http.Request) {
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	if _, err := h.authServiceClient.Client.RevokeOAuthConsent(ctx, &authpbv1.RevokeOAuthConsentRequest{
+		ClientId: chi.URLParam(r, "clientID"),
+	}); err != nil {
+		utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+		return
+	}
+
+	utilities.WriteSuccessResponse(w, r, nil, h.logger)
+}
+
+// getOAuthAuthorizationRequest validates the authorization request a third-party app sent the user
+// with and returns what the consent page shows them.
+func (h *AuthHTTPHandler) getOAuthAuthorizationRequest(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	req := payload.OAuthAuthorizationRequest{
+		ResponseType:        query.Get("response_type"),
+		ClientID:            query.Get("client_id"),
+		RedirectURI:         query.Get("redirect_uri"),
+		Scope:               query.Get("scope"),
+		State:               query.Get("state"),
+		CodeChallenge:       query.Get("code_challenge"),
+		CodeChallengeMethod: query.Get("code_challenge_method"),
+	}
+	if errs := validator.ValidateStruct(req); errs != nil {
+		utilities.WriteValidationErrorResponse(w, r, errs, h.logger)
+		return
+	}
+
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	grpcResp, err := h.authServiceClient.Client.GetOAuthAuthorizationRequest(
+		ctx,
+		&authpbv1.GetOAuthAuthorizationRequestRequest{
+			Request: oauthAuthorizationRequestToProto(req),
+		},
+	)
+	if err != nil {
+		utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+		return
+	}
+
+	payload := &payload.OAuthAuthorizationPromptResponse{
+		ClientID:   grpcResp.ClientId,
+		ClientName: grpcResp.ClientName,
+		Scopes:     grpcResp.Scopes,
+		Consented:  grpcResp.Consented,
+	}
+
+	utilities.WriteSuccessResponse(w, r, payload, h.logger)
+}
+
+// authorizeOAuthClient records the user's decision on the consent page. The page sends the user to
+// the returned redirect URI, which carries the authorization code or the error for the app.
+func (h *AuthHTTPHandler) authorizeOAuthClient(w http.ResponseWriter, r *http.Request) {
+	var req payload.AuthorizeOAuthClientRequest
+	if err := utilities.ReadJSON(w, r, &req); err != nil {
+		utilities.WriteRequestErrorResponse(w, r, err.Error(), h.logger)
+		return
+	}
+
+	if errs := validator.ValidateStruct(req); errs != nil {
+		utilities.WriteValidationErrorResponse(w, r, errs, h.logger)
+		return
+	}
+
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	grpcResp, err := h.authServiceClient.Client.AuthorizeOAuthClient(ctx, &authpbv1.AuthorizeOAuthClientRequest{
+		Request:  oauthAuthorizationRequestToProto(req.OAuthAuthorizationRequest),
+		Approved: req.Approved,
+	})
+	if err != nil {
+		utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+		return
+	}
+
+	payload := &payload.AuthorizeOAuthClientResponse{
+		RedirectURI: grpcResp.RedirectUri,
+	}
+
+	utilities.WriteSuccessResponse(w, r, payload, h.logger)
+}
+
+// exchangeOAuthToken is the token endpoint of RFC 6749. It takes a form body and answers in the
+// format OAuth client libraries expect instead of the API's envelope.
+func (h *AuthHTTPHandler) exchangeOAuthToken(w http.ResponseWriter, r *http.Request) {
+	clientID, clientSecret, ok := oauthClientCredentials(r)
+	if !ok {
+		h.writeOAuthError(w, r, http.StatusBadRequest, "invalid_request", "malformed client credentials")
+		return
+	}
+
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	grpcResp, err := h.authServiceClient.Client.ExchangeOAuthToken(ctx, &authpbv1.ExchangeOAuthTokenRequest{
+		GrantType:    r.PostFormValue("grant_type"),
+		ClientId:     clientID,
+		ClientSecret: clientSecret,
+		Code:         r.PostFormValue("code"),
+		RedirectUri:  r.PostFormValue("redirect_uri"),
+		CodeVerifier: r.PostFormValue("code_verifier"),
+		RefreshToken: r.PostFormValue("refresh_token"),
+	})
+	if err != nil {
+		utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+		return
+	}
+
+	if grpcResp.Error != "" {
+		httpStatus := http.StatusBadRequest
+		if grpcResp.Error == "invalid_client" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+			httpStatus = http.StatusUnauthorized
+		}
+
+		h.writeOAuthError(w, r, httpStatus, grpcResp.Error, grpcResp.ErrorDescription)
+		return
+	}
+
+	h.writeOAuthResponse(w, r, http.StatusOK, &payload.OAuthTokenResponse{
+		AccessToken:  grpcResp.AccessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    grpcResp.ExpiresIn,
+		RefreshToken: grpcResp.RefreshToken,
+		Scope:        strings.Join(grpcResp.Scopes, " "),
+	})
+}
+
+// revokeOAuthToken is the revocation endpoint of RFC 7009. Unknown tokens are not an error, so an
+// empty 200 is returned for them as well.
+func (h *AuthHTTPHandler) revokeOAuthToken(w http.ResponseWriter, r *http.Request) {
+	clientID, clientSecret, ok := oauthClientCredentials(r)
+	if !ok {
+		h.writeOAuthError(w, r, http.StatusBadRequest, "invalid_request", "malformed client credentials")
+		return
+	}
+
+	token := r.PostFormValue("token")
+	if token == "" {
+		h.writeOAuthError(w, r, http.StatusBadRequest, "invalid_request", "token is required")
+		return
+	}
+
+	ctx := utilities.ForwardHTTPHeadersToGRPC(r.Context(), r, nil)
+
+	if _, err := h.authServiceClient.Client.RevokeOAuthToken(ctx, &authpbv1.RevokeOAuthTokenRequest{
+		ClientId:     clientID,
+		ClientSecret: clientSecret,
+		Token:        token,
+	}); err != nil {
+		if status.Code(err) == codes.Unauthenticated {
+			w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+			h.writeOAuthError(w, r, http.StatusUnauthorized, "invalid_client", status.Convert(err).Message())
+			return
+		}
+
+		utilities.WriteInternalErrorResponse(w, r, err, h.logger)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *AuthHTTPHandler) writeOAuthError(
+	w http.ResponseWriter,
+	r *http.Request,
+	httpStatus int,
+	code, description string,
+) {
+	h.writeOAuthResponse(w, r, httpStatus, &payload.OAuthErrorResponse{
+		Error:            code,
+		ErrorDescription: description,
+	})
+}
+
+func (h *AuthHTTPHandler) writeOAuthResponse(w http.ResponseWriter, r *http.Request, httpStatus int, value any) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+
+	if err := utilities.WriteJSON(w, httpStatus, value); err != nil {
+		h.logger.Error().Err(err).
+			Str("method", r.Method).
+			Str("path", r.URL.Path).
+			Msg("failed to write oauth response")
+	}
+}
+
+// oauthClientCredentials returns the credentials of the client, sent either with HTTP Basic
+// authentication or in the form body. Public clients only send their client ID.
+func oauthClientCredentials(r *http.Request) (string, string, bool) {
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return r.PostFormValue("client_id"), r.PostFormValue("client_secret"), true
+	}
+
+	// RFC 6749 has clients form-encode their credentials before the Basic encoding
+	clientID, err := url.QueryUnescape(username)
+	if err != nil {
+		return "", "", false
+	}
+	clientSecret, err := url.QueryUnescape(password)
+	if err != nil {
+		return "", "", false
+	}
+
+	return clientID, clientSecret, true
+}
+
+func oauthAuthorizationRequestToProto(req payload.OAuthAuthorizationRequest) *authpbv1.OAuthAuthorizationRequest {
+	return &authpbv1.OAuthAuthorizationRequest{
+		ResponseType:        req.ResponseType,
+		ClientId:            req.ClientID,
+		RedirectUri:         req.RedirectURI,
+		Scope:               req.Scope,
+		State:               req.State,
+		CodeChallenge:       req.CodeChallenge,
+		CodeChallengeMethod: req.CodeChallengeMethod,
+	}
+}
+
+func oauthClientFromProto(client *authpbv1.OAuthClient) payload.OAuthClient {
+	return payload.OAuthClient{
+		ID:           client.Id,
+		Name:         client.Name,
+		RedirectURIs: client.RedirectUris,
+		Scopes:       client.Scopes,
+		Confidential: client.Confidential,
+		CreatedAt:    client.CreatedAt.AsTime(),
+	}
+}
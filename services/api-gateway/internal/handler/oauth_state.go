@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"net/url"
+)
+
+// oauthStateCookie holds the state of the OAuth flow a browser started, binding the flow to it. A
+// callback is only accepted from the browser holding its state, so nobody can start a flow and have
+// someone else complete it, e.g. to sign them in to the attacker's account or link their external
+// account to it.
+const oauthStateCookie = "oauth_state"
+
+// setOAuthStateCookie sets the cookie holding the state of the flow authorizationURL starts. It
+// outlives neither the browser session nor the state, which the auth service expires itself.
+func setOAuthStateCookie(w http.ResponseWriter, authorizationURL string) error {
+	parsedURL, err := url.Parse(authorizationURL)
+	if err != nil {
+		return err
+	}
+
+	state := parsedURL.Query().Get("state")
+	if state == "" {
+		return errors.New("authorization URL has no state")
+	}
+
+	// Some providers, such as Apple, post the callback from their own site, which only sends
+	// cookies that allow cross-site requests
+	http.SetCookie(w, &http.Cookie{
+		Name:     oauthStateCookie,
+		Value:    state,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteNoneMode,
+	})
+
+	return nil
+}
+
+// consumeOAuthStateCookie reports whether the request carries the cookie holding state, and clears
+// the cookie as the state can only be used once.
+func consumeOAuthStateCookie(w http.ResponseWriter, r *http.Request, state string) bool {
+	cookie, err := r.Cookie(oauthStateCookie)
+	if err != nil {
+		return false
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oauthStateCookie,
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteNoneMode,
+	})
+
+	return subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(state)) == 1
+}
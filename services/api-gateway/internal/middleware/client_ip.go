@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// ClientIP sets the remote address of requests that come through a trusted proxy to the client
+// address the proxies recorded in X-Forwarded-For. The header is read from the right, skipping the
+// trusted proxies, as anything to the left of the last trusted hop was sent by the client and can be
+// made up. Requests from any other address keep their remote address, which is what the services
+// throttle logins by.
+func ClientIP(trustedProxies []netip.Prefix) func(http.Handler) http.Handler {
+	isTrusted := func(addr netip.Addr) bool {
+		for _, prefix := range trustedProxies {
+			if prefix.Contains(addr.Unmap()) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			host, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil {
+				host = r.RemoteAddr
+			}
+
+			remoteAddr, err := netip.ParseAddr(host)
+			if err != nil || !isTrusted(remoteAddr) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+			for i := len(hops) - 1; i >= 0; i-- {
+				hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+				if err != nil {
+					break
+				}
+
+				remoteAddr = hop
+				if !isTrusted(hop) {
+					break
+				}
+			}
+
+			r.RemoteAddr = remoteAddr.Unmap().String()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"time"
+
+	"github.com/vasapolrittideah/money-tracker-api/shared/utilities"
+)
+
+// deviceIDCookieMaxAge is how long a device is remembered after it was last seen.
+const deviceIDCookieMaxAge = 400 * 24 * time.Hour
+
+// DeviceID gives clients without a device ID cookie a random one and renews it on every request, so
+// the services can recognize the device when it signs in again. The new ID is also added to the
+// request, so the services see it on the very first sign-in.
+func DeviceID() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cookie, err := r.Cookie(utilities.DeviceIDCookie)
+			if err != nil || cookie.Value == "" {
+				deviceID := make([]byte, 32)
+				if _, err := rand.Read(deviceID); err != nil {
+					next.ServeHTTP(w, r)
+					return
+				}
+
+				cookie = &http.Cookie{Name: utilities.DeviceIDCookie, Value: base64.RawURLEncoding.EncodeToString(deviceID)}
+				r.AddCookie(cookie)
+			}
+
+			http.SetCookie(w, &http.Cookie{
+				Name:     utilities.DeviceIDCookie,
+				Value:    cookie.Value,
+				Path:     "/",
+				MaxAge:   int(deviceIDCookieMaxAge.Seconds()),
+				HttpOnly: true,
+				Secure:   true,
+				SameSite: http.SameSiteLaxMode,
+			})
+			next.ServeHTTP(w, r)
+		})
+	}
+}
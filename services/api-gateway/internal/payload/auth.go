@@ -1,13 +1,17 @@
 package payload
 
+import "time"
+
 type LoginRequest struct {
 	Email    string `json:"email"    validate:"required,email"`
 	Password string `json:"password" validate:"required"`
 }
 
 type LoginResponse struct {
-	AccessToken  string `json:"access_token"`
-	RefreshToken string `json:"refresh_token"`
+	AccessToken  string `json:"access_token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	MFARequired  bool   `json:"mfa_required"`
+	MFAToken     string `json:"mfa_token,omitempty"`
 }
 
 type RegisterRequest struct {
@@ -16,6 +20,359 @@ type RegisterRequest struct {
 }
 
 type RegisterResponse struct {
+	AccessToken          string `json:"access_token,omitempty"`
+	RefreshToken         string `json:"refresh_token,omitempty"`
+	VerificationRequired bool   `json:"verification_required"`
+}
+
+type LoginWithGoogleRequest struct {
+	IDToken string `json:"id_token" validate:"required"`
+}
+
+type LoginWithGoogleResponse struct {
+	AccessToken  string `json:"access_token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	MFARequired  bool   `json:"mfa_required"`
+	MFAToken     string `json:"mfa_token,omitempty"`
+}
+
+type RequestMagicLinkRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type LoginWithMagicLinkRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type LoginWithMagicLinkResponse struct {
+	AccessToken  string `json:"access_token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	MFARequired  bool   `json:"mfa_required"`
+	MFAToken     string `json:"mfa_token,omitempty"`
+}
+
+type CompleteOAuthLoginRequest struct {
+	State string `validate:"required"`
+	Code  string `validate:"required"`
+}
+
+type CompleteOAuthLoginResponse struct {
+	AccessToken    string    `json:"access_token,omitempty"`
+	RefreshToken   string    `json:"refresh_token,omitempty"`
+	LinkedIdentity *Identity `json:"linked_identity,omitempty"`
+	MFARequired    bool      `json:"mfa_required"`
+	MFAToken       string    `json:"mfa_token,omitempty"`
+}
+
+type VerifyEmailRequest struct {
+	Email string `json:"email" validate:"required,email"`
+	Code  string `json:"code"  validate:"required,len=6,numeric"`
+}
+
+type ResendVerificationEmailRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type RefreshTokensRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type RefreshTokensResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// JSONWebKeySet is served as a plain RFC 7517 key set rather than wrapped in an API response,
+// since it is read by JWT libraries.
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password"     validate:"required"`
+}
+
+type RequestEmailChangeRequest struct {
+	NewEmail string `json:"new_email" validate:"required,email"`
+	Password string `json:"password"`
+}
+
+type EmailChangeTokenRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type DeleteAccountRequest struct {
+	Password string `json:"password"`
+}
+
+type DeleteAccountResponse struct {
+	PurgeAt time.Time `json:"purge_at"`
+}
+
+type RequestDataExportResponse struct {
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type DownloadDataExportRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type ListSecurityEventsRequest struct {
+	PageSize  int32  `validate:"omitempty,min=1,max=100"`
+	PageToken string `validate:"omitempty,hexadecimal,len=24"`
+	UserID    string `validate:"omitempty,hexadecimal,len=24"`
+}
+
+type SecurityEvent struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	Outcome   string    `json:"outcome"`
+	Reason    string    `json:"reason,omitempty"`
+	Method    string    `json:"method,omitempty"`
+	IPAddress string    `json:"ip_address,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ListSecurityEventsResponse struct {
+	Events        []SecurityEvent `json:"events"`
+	NextPageToken string          `json:"next_page_token,omitempty"`
+}
+
+type LoginAlertsResponse struct {
+	Rule string `json:"rule"`
+}
+
+type UpdateLoginAlertsRequest struct {
+	Rule string `json:"rule" validate:"required,oneof=new_device always off"`
+}
+
+type ReportLoginRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type PersonalAccessToken struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type CreatePersonalAccessTokenRequest struct {
+	Name      string     `json:"name"       validate:"required,max=100"`
+	Scopes    []string   `json:"scopes"     validate:"required,min=1,dive,required"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	Password  string     `json:"password"`
+	Code      string     `json:"code"`
+}
+
+// CreatePersonalAccessTokenResponse is the only response that contains the token itself.
+type CreatePersonalAccessTokenResponse struct {
+	PersonalAccessToken PersonalAccessToken `json:"personal_access_token"`
+	Token               string              `json:"token"`
+}
+
+type ListPersonalAccessTokensResponse struct {
+	Tokens []PersonalAccessToken `json:"tokens"`
+}
+
+type Session struct {
+	ID           string    `json:"id"`
+	IPAddress    string    `json:"ip_address,omitempty"`
+	UserAgent    string    `json:"user_agent,omitempty"`
+	Current      bool      `json:"current"`
+	CreatedAt    time.Time `json:"created_at"`
+	LastActiveAt time.Time `json:"last_active_at"`
+}
+
+type ListSessionsResponse struct {
+	Sessions []Session `json:"sessions"`
+}
+
+type Identity struct {
+	ID          string    `json:"id"`
+	Provider    string    `json:"provider"`
+	Email       string    `json:"email,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	LastLoginAt time.Time `json:"last_login_at"`
+}
+
+type ListIdentitiesResponse struct {
+	Identities []Identity `json:"identities"`
+}
+
+type LinkIdentityRequest struct {
+	Provider string `json:"provider" validate:"required"`
+	Password string `json:"password"`
+	IDToken  string `json:"id_token"`
+}
+
+type LinkIdentityResponse struct {
+	Identity         *Identity `json:"identity,omitempty"`
+	AuthorizationURL string    `json:"authorization_url,omitempty"`
+}
+
+type VerifyMFARequest struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	Code     string `json:"code"      validate:"required"`
+}
+
+type VerifyMFAResponse struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
 }
+
+type EnrollTOTPRequest struct {
+	Password string `json:"password"`
+}
+
+type EnrollTOTPResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type ConfirmTOTPRequest struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+type DisableTOTPRequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code"     validate:"required"`
+}
+
+type RegenerateRecoveryCodesRequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code"     validate:"required"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type Passkey struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Transports []string   `json:"transports,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// The passkey ceremonies use the JSON encoding of the WebAuthn specification, so browsers can pass
+// the options to PublicKeyCredential.parseCreationOptionsFromJSON and parseRequestOptionsFromJSON,
+// and send the result of PublicKeyCredential.toJSON back as is. Binary values are base64url encoded.
+
+type BeginPasskeyRegistrationRequest struct {
+	Password string `json:"password"`
+}
+
+type BeginPasskeyRegistrationResponse struct {
+	PublicKey PublicKeyCredentialCreationOptions `json:"publicKey"`
+}
+
+type PublicKeyCredentialCreationOptions struct {
+	Challenge              string                          `json:"challenge"`
+	RP                     PublicKeyCredentialRPEntity     `json:"rp"`
+	User                   PublicKeyCredentialUserEntity   `json:"user"`
+	PubKeyCredParams       []PublicKeyCredentialParameters `json:"pubKeyCredParams"`
+	Timeout                int64                           `json:"timeout"`
+	ExcludeCredentials     []PublicKeyCredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection AuthenticatorSelectionCriteria  `json:"authenticatorSelection"`
+	Attestation            string                          `json:"attestation"`
+}
+
+type PublicKeyCredentialRPEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type PublicKeyCredentialUserEntity struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+type PublicKeyCredentialParameters struct {
+	Type string `json:"type"`
+	Alg  int64  `json:"alg"`
+}
+
+type PublicKeyCredentialDescriptor struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+type AuthenticatorSelectionCriteria struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+type FinishPasskeyRegistrationRequest struct {
+	ID       string                           `json:"id"       validate:"required"`
+	Type     string                           `json:"type"     validate:"required,eq=public-key"`
+	Response AuthenticatorAttestationResponse `json:"response"`
+	Name     string                           `json:"name"     validate:"max=64"`
+}
+
+type AuthenticatorAttestationResponse struct {
+	ClientDataJSON    string   `json:"clientDataJSON"    validate:"required"`
+	AttestationObject string   `json:"attestationObject" validate:"required"`
+	Transports        []string `json:"transports"`
+}
+
+type FinishPasskeyRegistrationResponse struct {
+	Passkey Passkey `json:"passkey"`
+}
+
+type BeginPasskeyLoginResponse struct {
+	PublicKey PublicKeyCredentialRequestOptions `json:"publicKey"`
+}
+
+type PublicKeyCredentialRequestOptions struct {
+	Challenge        string `json:"challenge"`
+	RPID             string `json:"rpId"`
+	Timeout          int64  `json:"timeout"`
+	UserVerification string `json:"userVerification"`
+}
+
+type FinishPasskeyLoginRequest struct {
+	ID       string                         `json:"id"       validate:"required"`
+	Type     string                         `json:"type"     validate:"required,eq=public-key"`
+	Response AuthenticatorAssertionResponse `json:"response"`
+}
+
+type AuthenticatorAssertionResponse struct {
+	ClientDataJSON    string `json:"clientDataJSON"    validate:"required"`
+	AuthenticatorData string `json:"authenticatorData" validate:"required"`
+	Signature         string `json:"signature"         validate:"required"`
+	UserHandle        string `json:"userHandle"`
+}
+
+type FinishPasskeyLoginResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+type SetUserRolesRequest struct {
+	Roles       []string `json:"roles"       validate:"dive,required"`
+	Permissions []string `json:"permissions" validate:"dive,required"`
+}
+
+type SetUserRolesResponse struct {
+	UserID      string   `json:"user_id"`
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+}
@@ -6,18 +6,27 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
 
 	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/config"
 	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/handler"
 	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/repository"
 	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/usecase"
+	authtypes "github.com/vasapolrittideah/money-tracker-api/services/auth-service/pkg/types"
 	"github.com/vasapolrittideah/money-tracker-api/shared/auth"
 	"github.com/vasapolrittideah/money-tracker-api/shared/database"
 	"github.com/vasapolrittideah/money-tracker-api/shared/discovery"
+	"github.com/vasapolrittideah/money-tracker-api/shared/interceptor"
 	"github.com/vasapolrittideah/money-tracker-api/shared/logger"
+	"github.com/vasapolrittideah/money-tracker-api/shared/mailer"
+	authpbv1 "github.com/vasapolrittideah/money-tracker-api/shared/protos/auth/v1"
+	"github.com/vasapolrittideah/money-tracker-api/shared/provider"
+	"github.com/vasapolrittideah/money-tracker-api/shared/security"
 	"github.com/vasapolrittideah/money-tracker-api/shared/utilities"
+	"github.com/vasapolrittideah/money-tracker-api/shared/webauthn"
 )
 
 func main() {
@@ -58,14 +67,379 @@ func main() {
 		authServiceCfg.Token.Issuer,
 	)
 
-	identityRepo := repository.NewIdentityMongoRepository(mongodb.GetDatabase())
+	accessTokenSigningKey, err := auth.ParseSigningKeyPEM([]byte(authServiceCfg.Token.AccessTokenSigningKey))
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to parse access token signing key")
+	}
+	accessTokenVerificationKeys := make([]auth.VerificationKey, 0, len(authServiceCfg.Token.AccessTokenVerificationKeys))
+	for _, keyPEM := range authServiceCfg.Token.AccessTokenVerificationKeys {
+		key, err := auth.ParseVerificationKeyPEM([]byte(keyPEM))
+		if err != nil {
+			logger.Fatal().Err(err).Msg("failed to parse access token verification key")
+		}
+		accessTokenVerificationKeys = append(accessTokenVerificationKeys, key)
+	}
+	accessTokenKeys := auth.NewKeySet(accessTokenSigningKey, accessTokenVerificationKeys...)
+
+	serviceCallerKeys := make(map[string]auth.VerificationKey, len(authServiceCfg.ServiceAuth.CallerKeys))
+	for serviceName, keyPEM := range authServiceCfg.ServiceAuth.CallerKeys {
+		key, err := auth.ParseVerificationKeyPEM([]byte(keyPEM))
+		if err != nil {
+			logger.Fatal().Err(err).Str("serviceName", serviceName).Msg("failed to parse service caller key")
+		}
+		serviceCallerKeys[serviceName] = key
+	}
+	serviceTokenVerifier := auth.NewServiceTokenVerifier(authServiceCfg.Name, serviceCallerKeys)
+
+	mailer := mailer.NewMailer(logger)
+
+	identityRepo := repository.NewIdentityMongoRepository(ctx, logger, mongodb.GetDatabase())
 	sessionRepo := repository.NewSessionMongoRepository(mongodb.GetDatabase())
 	userRepo := repository.NewUserMongoRepository(ctx, logger, mongodb.GetDatabase())
+	passwordResetTokenRepo := repository.NewPasswordResetTokenMongoRepository(ctx, logger, mongodb.GetDatabase())
+	oauthStateRepo := repository.NewOAuthStateMongoRepository(ctx, logger, mongodb.GetDatabase())
+	mfaChallengeRepo := repository.NewMFAChallengeMongoRepository(ctx, logger, mongodb.GetDatabase())
+	webAuthnCredentialRepo := repository.NewWebAuthnCredentialMongoRepository(ctx, logger, mongodb.GetDatabase())
+	webAuthnSessionRepo := repository.NewWebAuthnSessionMongoRepository(ctx, logger, mongodb.GetDatabase())
+	loginAttemptRepo := repository.NewLoginAttemptMongoRepository(ctx, logger, mongodb.GetDatabase())
+	emailChangeRepo := repository.NewEmailChangeMongoRepository(ctx, logger, mongodb.GetDatabase())
+	accountRepo := repository.NewAccountMongoRepository(mongodb.GetDatabase())
+	dataExportRepo := repository.NewDataExportMongoRepository(ctx, logger, mongodb.GetDatabase())
+	authEventRepo := repository.NewAuthEventMongoRepository(ctx, logger, mongodb.GetDatabase())
+	loginAlertRepo := repository.NewLoginAlertMongoRepository(ctx, logger, mongodb.GetDatabase())
+	magicLinkTokenRepo := repository.NewMagicLinkTokenMongoRepository(ctx, logger, mongodb.GetDatabase())
+	personalAccessTokenRepo := repository.NewPersonalAccessTokenMongoRepository(ctx, logger, mongodb.GetDatabase())
+	oauthClientRepo := repository.NewOAuthClientMongoRepository(ctx, logger, mongodb.GetDatabase())
+	oauthConsentRepo := repository.NewOAuthConsentMongoRepository(ctx, logger, mongodb.GetDatabase())
+	oauthCodeRepo := repository.NewOAuthAuthorizationCodeMongoRepository(ctx, logger, mongodb.GetDatabase())
+
+	encryptor, err := security.NewEncryptor(authServiceCfg.MFA.EncryptionKey)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create MFA secret encryptor")
+	}
+
+	googleProvider := provider.NewGoogleOAuthProvider(authServiceCfg.Google.ClientID, authServiceCfg.Google.Endpoint)
+
+	oidcProviders := make([]provider.Provider, 0, len(authServiceCfg.OAuth.Providers))
+	for _, providerCfg := range authServiceCfg.OAuth.Providers {
+		oidcProviders = append(oidcProviders, provider.NewOIDCProvider(provider.OIDCConfig{
+			Name:         providerCfg.Name,
+			Issuer:       providerCfg.Issuer,
+			ClientID:     providerCfg.ClientID,
+			ClientSecret: providerCfg.ClientSecret,
+			RedirectURL:  providerCfg.RedirectURL,
+			Scopes:       providerCfg.Scopes,
+		}))
+	}
+	providerRegistry, err := provider.NewRegistry(oidcProviders...)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create provider registry")
+	}
+
+	passwordPolicy := &security.PasswordPolicy{
+		MinLength:     authServiceCfg.PasswordPolicy.MinLength,
+		MaxLength:     authServiceCfg.PasswordPolicy.MaxLength,
+		RequireUpper:  authServiceCfg.PasswordPolicy.RequireUpper,
+		RequireLower:  authServiceCfg.PasswordPolicy.RequireLower,
+		RequireDigit:  authServiceCfg.PasswordPolicy.RequireDigit,
+		RequireSymbol: authServiceCfg.PasswordPolicy.RequireSymbol,
+		MinScore:      authServiceCfg.PasswordPolicy.MinScore,
+	}
+	if authServiceCfg.PasswordPolicy.BreachedPasswordsDir != "" {
+		breachedPasswords, err := security.NewBreachedPasswordList(authServiceCfg.PasswordPolicy.BreachedPasswordsDir)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("failed to load breached password list")
+		}
+		passwordPolicy.BreachedPasswords = breachedPasswords
+	}
+
+	passwordHasher, err := security.NewPasswordHasher(security.PasswordHashingParams{
+		MemoryCost:  authServiceCfg.PasswordHashing.MemoryCostKiB,
+		TimeCost:    authServiceCfg.PasswordHashing.TimeCost,
+		Parallelism: authServiceCfg.PasswordHashing.Parallelism,
+		SaltLength:  authServiceCfg.PasswordHashing.SaltLength,
+		HashLength:  authServiceCfg.PasswordHashing.HashLength,
+	}, authServiceCfg.PasswordHashing.Pepper)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create password hasher")
+	}
+
+	relyingParty := webauthn.NewRelyingParty(
+		authServiceCfg.WebAuthn.RPID,
+		authServiceCfg.WebAuthn.RPName,
+		authServiceCfg.WebAuthn.Origins,
+	)
+
+	authEventRecorder := usecase.NewAuthEventRecorder(logger, authEventRepo, authServiceCfg)
+	loginThrottle := usecase.NewLoginThrottle(logger, loginAttemptRepo, mailer, authServiceCfg)
+	reauthenticator := usecase.NewReauthenticator(
+		userRepo,
+		sessionRepo,
+		passwordHasher,
+		encryptor,
+		loginThrottle,
+		authServiceCfg,
+	)
+	emailVerificationUsecase := usecase.NewEmailVerificationUsecase(userRepo, mailer, authServiceCfg)
+	sessionIssuer := usecase.NewSessionIssuer(
+		logger,
+		identityRepo,
+		sessionRepo,
+		userRepo,
+		mfaChallengeRepo,
+		loginAlertRepo,
+		authEventRecorder,
+		mailer,
+		jwtAuthenticator,
+		accessTokenKeys,
+		authServiceCfg,
+	)
+	authUsecase := usecase.NewAuthUsecase(
+		logger,
+		identityRepo,
+		sessionRepo,
+		userRepo,
+		oauthStateRepo,
+		mfaChallengeRepo,
+		webAuthnCredentialRepo,
+		webAuthnSessionRepo,
+		personalAccessTokenRepo,
+		loginThrottle,
+		sessionIssuer,
+		emailVerificationUsecase,
+		authEventRecorder,
+		googleProvider,
+		providerRegistry,
+		encryptor,
+		relyingParty,
+		mailer,
+		passwordPolicy,
+		passwordHasher,
+		jwtAuthenticator,
+		accessTokenKeys,
+		authServiceCfg,
+	)
+	magicLinkUsecase := usecase.NewMagicLinkUsecase(
+		userRepo,
+		magicLinkTokenRepo,
+		sessionIssuer,
+		authEventRecorder,
+		jwtAuthenticator,
+		mailer,
+		authServiceCfg,
+	)
+	oauthServerUsecase := usecase.NewOAuthServerUsecase(
+		userRepo,
+		sessionRepo,
+		oauthClientRepo,
+		oauthConsentRepo,
+		oauthCodeRepo,
+		sessionIssuer,
+		authEventRecorder,
+		jwtAuthenticator,
+		accessTokenKeys,
+		authServiceCfg,
+	)
+	passwordResetUsecase := usecase.NewPasswordResetUsecase(
+		userRepo,
+		passwordResetTokenRepo,
+		personalAccessTokenRepo,
+		loginAttemptRepo,
+		passwordPolicy,
+		passwordHasher,
+		jwtAuthenticator,
+		authEventRecorder,
+		mailer,
+		authServiceCfg,
+	)
+	sessionUsecase := usecase.NewSessionUsecase(sessionRepo)
+	identityUsecase := usecase.NewIdentityUsecase(
+		identityRepo,
+		userRepo,
+		oauthStateRepo,
+		webAuthnCredentialRepo,
+		googleProvider,
+		providerRegistry,
+		reauthenticator,
+		authServiceCfg,
+	)
+	mfaUsecase := usecase.NewMFAUsecase(userRepo, encryptor, reauthenticator, loginThrottle, authServiceCfg)
+	passkeyUsecase := usecase.NewPasskeyUsecase(
+		userRepo,
+		webAuthnCredentialRepo,
+		webAuthnSessionRepo,
+		relyingParty,
+		reauthenticator,
+		authServiceCfg,
+	)
+	emailChangeUsecase := usecase.NewEmailChangeUsecase(
+		userRepo,
+		emailChangeRepo,
+		reauthenticator,
+		authEventRecorder,
+		mailer,
+		authServiceCfg,
+	)
+	accountUsecase := usecase.NewAccountUsecase(
+		logger,
+		userRepo,
+		sessionRepo,
+		accountRepo,
+		reauthenticator,
+		authEventRecorder,
+		mailer,
+		authServiceCfg,
+	)
+	dataExportUsecase := usecase.NewDataExportUsecase(
+		userRepo,
+		dataExportRepo,
+		usecase.NewAuthDataExportSources(
+			userRepo,
+			identityRepo,
+			sessionRepo,
+			passwordResetTokenRepo,
+			webAuthnCredentialRepo,
+		),
+		mailer,
+		authServiceCfg,
+	)
+	securityEventUsecase := usecase.NewSecurityEventUsecase(authEventRepo)
+	loginAlertUsecase := usecase.NewLoginAlertUsecase(
+		userRepo,
+		sessionRepo,
+		personalAccessTokenRepo,
+		loginAlertRepo,
+		passwordResetUsecase,
+		authEventRecorder,
+	)
+	personalAccessTokenUsecase := usecase.NewPersonalAccessTokenUsecase(
+		userRepo,
+		personalAccessTokenRepo,
+		reauthenticator,
+		authEventRecorder,
+		authServiceCfg,
+	)
+	oauthClientUsecase := usecase.NewOAuthClientUsecase(
+		oauthClientRepo,
+		oauthConsentRepo,
+		sessionRepo,
+		authEventRecorder,
+		authServiceCfg,
+	)
 
-	authUsecase := usecase.NewAuthUsecase(identityRepo, sessionRepo, userRepo, jwtAuthenticator, authServiceCfg)
+	roleUsecase := usecase.NewRoleUsecase(logger, userRepo, authEventRecorder)
+	if err := roleUsecase.BootstrapAdmins(ctx, authServiceCfg.AdminEmails); err != nil {
+		logger.Fatal().Err(err).Msg("failed to bootstrap admins")
+	}
 
-	grpcServer := grpc.NewServer()
-	handler.NewAuthGRPCHandler(grpcServer, logger, authUsecase)
+	publicMethods := []string{
+		authpbv1.AuthService_Login_FullMethodName,
+		authpbv1.AuthService_Register_FullMethodName,
+		authpbv1.AuthService_LoginWithGoogle_FullMethodName,
+		authpbv1.AuthService_RequestMagicLink_FullMethodName,
+		authpbv1.AuthService_LoginWithMagicLink_FullMethodName,
+		authpbv1.AuthService_StartOAuthLogin_FullMethodName,
+		authpbv1.AuthService_CompleteOAuthLogin_FullMethodName,
+		authpbv1.AuthService_VerifyMFA_FullMethodName,
+		authpbv1.AuthService_BeginPasskeyLogin_FullMethodName,
+		authpbv1.AuthService_FinishPasskeyLogin_FullMethodName,
+		authpbv1.AuthService_VerifyEmail_FullMethodName,
+		authpbv1.AuthService_ResendVerificationEmail_FullMethodName,
+		authpbv1.AuthService_RefreshTokens_FullMethodName,
+		authpbv1.AuthService_GetJSONWebKeySet_FullMethodName,
+		// The token to introspect is sent in the request rather than as the caller's credentials
+		authpbv1.AuthService_Introspect_FullMethodName,
+		authpbv1.AuthService_RequestPasswordReset_FullMethodName,
+		// Email change links are opened from the mailbox, where the user may not be logged in
+		authpbv1.AuthService_ConfirmEmailChange_FullMethodName,
+		authpbv1.AuthService_CancelEmailChange_FullMethodName,
+		// The link is opened from the mailbox, and the reported sign-in may be the only session
+		authpbv1.AuthService_ReportLogin_FullMethodName,
+		// Third-party apps authenticate with their client credentials in the request
+		authpbv1.AuthService_ExchangeOAuthToken_FullMethodName,
+		authpbv1.AuthService_RevokeOAuthToken_FullMethodName,
+	}
+	passwordResetMethods := []string{
+		authpbv1.AuthService_ResetPassword_FullMethodName,
+		authpbv1.AuthService_ValidatePasswordResetToken_FullMethodName,
+	}
+
+	// The gateway exposes the whole API. Other services may only introspect the tokens their callers
+	// send, and only the ones configured to.
+	serviceAllowList := interceptor.ServiceAllowList{
+		"/" + authpbv1.AuthService_ServiceDesc.ServiceName + "/*": {"api-gateway"},
+		authpbv1.AuthService_Introspect_FullMethodName:            authServiceCfg.ServiceAuth.IntrospectionCallers,
+	}
+	// Consul checks the health of the service without a service token
+	serviceAuthExemptMethods := []string{
+		grpc_health_v1.Health_Check_FullMethodName,
+		grpc_health_v1.Health_Watch_FullMethodName,
+	}
+
+	// Under the limited policy, unverified users can sign in and verify their address, but cannot
+	// give anyone lasting access to the account or have its data sent to the unverified address
+	var verifiedEmailMethods []string
+	if authServiceCfg.EmailVerification.LoginPolicy == config.UnverifiedLoginLimited {
+		verifiedEmailMethods = []string{
+			authpbv1.AuthService_CreatePersonalAccessToken_FullMethodName,
+			authpbv1.AuthService_CreateOAuthClient_FullMethodName,
+			authpbv1.AuthService_AuthorizeOAuthClient_FullMethodName,
+			authpbv1.AuthService_RequestDataExport_FullMethodName,
+		}
+	}
+
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			interceptor.NewServiceAuthInterceptor(serviceTokenVerifier, serviceAllowList, serviceAuthExemptMethods),
+			interceptor.NewJWTInterceptor(
+				jwtAuthenticator,
+				accessTokenKeys,
+				append(publicMethods, passwordResetMethods...),
+				sessionUsecase,
+				personalAccessTokenUsecase,
+			),
+			interceptor.NewMethodJWTInterceptor(
+				jwtAuthenticator,
+				auth.Secret(authServiceCfg.Token.PasswordResetTokenSecret),
+				passwordResetMethods,
+			),
+			interceptor.NewPersonalAccessTokenInterceptor(handler.PersonalAccessTokenScopes),
+			interceptor.NewVerifiedEmailInterceptor(verifiedEmailMethods),
+			interceptor.NewAuthorizationInterceptor(interceptor.MethodAuthorizationRules{
+				authpbv1.AuthService_SetUserRoles_FullMethodName: interceptor.RequirePermission(
+					authtypes.PermissionUsersWrite,
+				),
+			}),
+		),
+		grpc.ChainStreamInterceptor(
+			interceptor.NewServiceAuthStreamInterceptor(
+				serviceTokenVerifier,
+				serviceAllowList,
+				serviceAuthExemptMethods,
+			),
+		),
+	)
+	handler.NewAuthGRPCHandler(
+		grpcServer,
+		logger,
+		authUsecase,
+		magicLinkUsecase,
+		oauthServerUsecase,
+		passwordResetUsecase,
+		sessionUsecase,
+		emailVerificationUsecase,
+		identityUsecase,
+		mfaUsecase,
+		passkeyUsecase,
+		emailChangeUsecase,
+		accountUsecase,
+		dataExportUsecase,
+		securityEventUsecase,
+		loginAlertUsecase,
+		personalAccessTokenUsecase,
+		oauthClientUsecase,
+		roleUsecase,
+	)
 
 	utilities.RegisterHealthServer(grpcServer)
 
@@ -83,6 +457,25 @@ func main() {
 		}
 	}()
 
+	go func() {
+		ticker := time.NewTicker(authServiceCfg.AccountDeletion.PurgeInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				purged, err := accountUsecase.PurgeDeletedAccounts(ctx)
+				if err != nil {
+					logger.Error().Err(err).Msg("failed to purge deleted accounts")
+				} else if purged > 0 {
+					logger.Info().Int("count", purged).Msg("purged deleted accounts")
+				}
+			}
+		}
+	}()
+
 	go func() {
 		signalChan := make(chan os.Signal, 1)
 		signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM)
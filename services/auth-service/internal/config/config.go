@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/caarlos0/env/v11"
@@ -9,20 +10,257 @@ import (
 
 // AuthServiceConfig contains the configuration for the auth service.
 type AuthServiceConfig struct {
-	Environment     string `env:"ENVIRONMENT"`
-	Name            string `env:"SERVICE_NAME"`
-	Address         string `env:"SERVICE_ADDRESS"`
-	RegisterAddress string `env:"SERVICE_REGISTER_ADDRESS"`
-	Token           TokenConfig
+	Environment         string `env:"ENVIRONMENT"`
+	Name                string `env:"SERVICE_NAME"`
+	Address             string `env:"SERVICE_ADDRESS"`
+	RegisterAddress     string `env:"SERVICE_REGISTER_ADDRESS"`
+	AppPasswordResetURL string `env:"APP_PASSWORD_RESET_URL"`
+	AppMagicLinkURL     string `env:"APP_MAGIC_LINK_URL"`
+	// ReauthenticationMaxAge is how recently a user without a password must have logged in
+	// to perform sensitive actions that otherwise require their password.
+	ReauthenticationMaxAge time.Duration `env:"REAUTHENTICATION_MAX_AGE" envDefault:"5m"`
+	// AdminEmails are the email addresses of the users given the admin role at startup, so the first
+	// admin does not have to be created by hand. The users must have registered and verified their
+	// address; removing an address later does not take the role away.
+	AdminEmails         []string `env:"ADMIN_EMAILS" envSeparator:","`
+	Token               TokenConfig
+	ServiceAuth         ServiceAuthConfig
+	EmailVerification   EmailVerificationConfig
+	EmailChange         EmailChangeConfig
+	AccountDeletion     AccountDeletionConfig
+	DataExport          DataExportConfig
+	AuditLog            AuditLogConfig
+	LoginAlert          LoginAlertConfig
+	PersonalAccessToken PersonalAccessTokenConfig
+	AuthorizationServer AuthorizationServerConfig
+	Google              GoogleOAuthConfig
+	OAuth               OAuthConfig
+	LoginThrottle       LoginThrottleConfig
+	PasswordPolicy      PasswordPolicyConfig
+	PasswordHashing     PasswordHashingConfig
+	MFA                 MFAConfig
+	WebAuthn            WebAuthnConfig
 }
 
 // TokenConfig contains the configuration for JWT tokens.
 type TokenConfig struct {
-	AccessTokenSecret     string        `env:"ACCESS_TOKEN_SECRET"`
-	RefreshTokenSecret    string        `env:"REFRESH_TOKEN_SECRET"`
-	AccessTokenExpiresIn  time.Duration `env:"ACCESS_TOKEN_EXPIRES_IN"`
-	RefreshTokenExpiresIn time.Duration `env:"REFRESH_TOKEN_EXPIRES_IN"`
-	Issuer                string        `env:"TOKEN_ISSUER"`
+	// AccessTokenSigningKey is the PEM encoded RSA or Ed25519 private key access tokens are signed
+	// with. Its public key is published at the gateway's /.well-known/jwks.json route.
+	AccessTokenSigningKey string `env:"ACCESS_TOKEN_SIGNING_KEY"`
+	// AccessTokenVerificationKeys are PEM encoded public keys access tokens are accepted from besides
+	// the signing key. To rotate keys, publish the next key here before signing with it, and keep the
+	// previous key here until the tokens it signed have expired.
+	AccessTokenVerificationKeys []string      `env:"ACCESS_TOKEN_VERIFICATION_KEYS"  envSeparator:";"`
+	RefreshTokenSecret          string        `env:"REFRESH_TOKEN_SECRET"`
+	PasswordResetTokenSecret    string        `env:"PASSWORD_RESET_TOKEN_SECRET"`
+	MFATokenSecret              string        `env:"MFA_TOKEN_SECRET"`
+	MagicLinkTokenSecret        string        `env:"MAGIC_LINK_TOKEN_SECRET"`
+	AccessTokenExpiresIn        time.Duration `env:"ACCESS_TOKEN_EXPIRES_IN"`
+	RefreshTokenExpiresIn       time.Duration `env:"REFRESH_TOKEN_EXPIRES_IN"`
+	PasswordResetTokenExpiresIn time.Duration `env:"PASSWORD_RESET_TOKEN_EXPIRES_IN"`
+	MFATokenExpiresIn           time.Duration `env:"MFA_TOKEN_EXPIRES_IN"            envDefault:"5m"`
+	MagicLinkTokenExpiresIn     time.Duration `env:"MAGIC_LINK_TOKEN_EXPIRES_IN"     envDefault:"15m"`
+	Issuer                      string        `env:"TOKEN_ISSUER"`
+}
+
+// ServiceAuthConfig contains the configuration for authenticating calls from other services.
+type ServiceAuthConfig struct {
+	// CallerKeys are the PEM encoded public keys of the services that may call this one, by service
+	// name, e.g. "api-gateway:<PEM>;reporting-service:<PEM>". Which methods each of them may call is
+	// set where the gRPC server is created.
+	CallerKeys map[string]string `env:"SERVICE_CALLER_KEYS" envSeparator:";"`
+	// IntrospectionCallers are the services besides the API gateway that may call Introspect, so they
+	// can accept access tokens and personal access tokens themselves, e.g. "reporting-service".
+	IntrospectionCallers []string `env:"SERVICE_INTROSPECTION_CALLERS" envSeparator:","`
+}
+
+// UnverifiedLoginPolicy controls what users who have not verified their email address may do.
+type UnverifiedLoginPolicy string
+
+const (
+	// UnverifiedLoginAllow lets unverified users log in like verified ones.
+	UnverifiedLoginAllow UnverifiedLoginPolicy = "allow"
+
+	// UnverifiedLoginLimited lets unverified users log in, but their tokens carry
+	// email_verified=false and the methods guarded by interceptor.NewVerifiedEmailInterceptor
+	// reject them.
+	UnverifiedLoginLimited UnverifiedLoginPolicy = "limited"
+
+	// UnverifiedLoginDeny rejects logins until the email address is verified.
+	UnverifiedLoginDeny UnverifiedLoginPolicy = "deny"
+)
+
+// UnmarshalText parses a policy, rejecting unknown ones so a typo does not silently allow
+// unverified users in.
+func (p *UnverifiedLoginPolicy) UnmarshalText(text []byte) error {
+	switch policy := UnverifiedLoginPolicy(text); policy {
+	case UnverifiedLoginAllow, UnverifiedLoginLimited, UnverifiedLoginDeny:
+		*p = policy
+		return nil
+	default:
+		return fmt.Errorf("unknown unverified login policy %q", text)
+	}
+}
+
+// EmailVerificationConfig contains the configuration for email address verification.
+type EmailVerificationConfig struct {
+	CodeExpiresIn  time.Duration         `env:"EMAIL_VERIFICATION_CODE_EXPIRES_IN" envDefault:"15m"`
+	ResendInterval time.Duration         `env:"EMAIL_VERIFICATION_RESEND_INTERVAL" envDefault:"1m"`
+	MaxAttempts    int                   `env:"EMAIL_VERIFICATION_MAX_ATTEMPTS"    envDefault:"5"`
+	LoginPolicy    UnverifiedLoginPolicy `env:"UNVERIFIED_LOGIN_POLICY"            envDefault:"limited"`
+}
+
+// EmailChangeConfig contains the configuration for changing the email address of an account.
+type EmailChangeConfig struct {
+	// ConfirmURL and CancelURL are the app pages the links in the emails lead to. The token is
+	// added as the token query parameter.
+	ConfirmURL     string        `env:"APP_EMAIL_CHANGE_CONFIRM_URL"`
+	CancelURL      string        `env:"APP_EMAIL_CHANGE_CANCEL_URL"`
+	TokenExpiresIn time.Duration `env:"EMAIL_CHANGE_TOKEN_EXPIRES_IN" envDefault:"24h"`
+}
+
+// AccountDeletionConfig contains the configuration for deleting accounts.
+type AccountDeletionConfig struct {
+	// GracePeriod is how long a deleted account can still be restored by logging in before it is purged.
+	GracePeriod    time.Duration `env:"ACCOUNT_DELETION_GRACE_PERIOD" envDefault:"720h"`
+	PurgeInterval  time.Duration `env:"ACCOUNT_PURGE_INTERVAL"        envDefault:"1h"`
+	PurgeBatchSize int64         `env:"ACCOUNT_PURGE_BATCH_SIZE"      envDefault:"100"`
+}
+
+// DataExportConfig contains the configuration for personal data exports. URL is the app page the link
+// in the email leads to, with the token added as the token query parameter. RequestInterval is how long
+// a user has to wait before requesting another export.
+type DataExportConfig struct {
+	URL             string        `env:"APP_DATA_EXPORT_URL"`
+	ExpiresIn       time.Duration `env:"DATA_EXPORT_EXPIRES_IN"       envDefault:"168h"`
+	RequestInterval time.Duration `env:"DATA_EXPORT_REQUEST_INTERVAL" envDefault:"24h"`
+}
+
+// AuditLogConfig contains the configuration for the security audit log.
+type AuditLogConfig struct {
+	// Retention is how long authentication events are kept.
+	Retention time.Duration `env:"AUDIT_LOG_RETENTION" envDefault:"2160h"`
+}
+
+// LoginAlertConfig contains the configuration for emailing users about new sign-ins. ReportURL is the
+// app page the "this wasn't me" link leads to, with the token added as the token query parameter.
+type LoginAlertConfig struct {
+	ReportURL      string        `env:"APP_LOGIN_ALERT_REPORT_URL"`
+	TokenExpiresIn time.Duration `env:"LOGIN_ALERT_TOKEN_EXPIRES_IN" envDefault:"168h"`
+}
+
+// PersonalAccessTokenConfig contains the configuration for the tokens users create for scripts and
+// integrations. Scopes are the scopes a token can be granted, and MaxPerUser caps how many tokens a
+// user can have.
+type PersonalAccessTokenConfig struct {
+	Scopes     []string `env:"PAT_SCOPES"       envDefault:"read,write" envSeparator:","`
+	MaxPerUser int      `env:"PAT_MAX_PER_USER" envDefault:"50"`
+}
+
+// AuthorizationServerConfig contains the configuration for letting third-party apps access accounts
+// with OAuth 2.0. Scopes are the scopes apps can be registered for and ask users to consent to.
+type AuthorizationServerConfig struct {
+	Scopes                     []string      `env:"AUTHORIZATION_SERVER_SCOPES"   envDefault:"read,write" envSeparator:","`
+	AuthorizationCodeExpiresIn time.Duration `env:"AUTHORIZATION_CODE_EXPIRES_IN" envDefault:"10m"`
+}
+
+// LoginThrottleConfig contains the configuration for slowing down password guessing. Failed logins are
+// counted per account and per client IP address, and both are checked before a password is verified.
+type LoginThrottleConfig struct {
+	// FreeAttempts is how many failed logins are allowed before every further attempt has to wait.
+	FreeAttempts int `env:"LOGIN_FREE_ATTEMPTS" envDefault:"3"`
+	// BackoffBase is the wait after the first failure past FreeAttempts. It doubles with every
+	// further failure, up to BackoffMax.
+	BackoffBase time.Duration `env:"LOGIN_BACKOFF_BASE" envDefault:"1s"`
+	BackoffMax  time.Duration `env:"LOGIN_BACKOFF_MAX"  envDefault:"5m"`
+	// AccountLockoutThreshold and IPLockoutThreshold are the numbers of failures after which the
+	// account or IP address is locked out for LockoutDuration. Many users can share an IP address,
+	// so its threshold is higher. Zero disables the lockout, leaving only the backoff.
+	//
+	// Anyone who knows an email address can lock its account out of password login by failing on
+	// purpose. The lockout stops guessing from many addresses at once, which the backoff alone does
+	// not, and the owner can still sign in with a passkey, a magic link or a password reset, which
+	// also lifts the lock. Deployments that would rather not trade that for availability can set
+	// AccountLockoutThreshold to 0.
+	AccountLockoutThreshold int           `env:"LOGIN_ACCOUNT_LOCKOUT_THRESHOLD" envDefault:"10"`
+	IPLockoutThreshold      int           `env:"LOGIN_IP_LOCKOUT_THRESHOLD"      envDefault:"100"`
+	LockoutDuration         time.Duration `env:"LOGIN_LOCKOUT_DURATION"          envDefault:"30m"`
+	// FailureWindow is how long failed logins are remembered after the last one.
+	FailureWindow time.Duration `env:"LOGIN_FAILURE_WINDOW" envDefault:"24h"`
+}
+
+// PasswordPolicyConfig contains the rules new passwords have to follow.
+type PasswordPolicyConfig struct {
+	MinLength     int  `env:"PASSWORD_MIN_LENGTH"     envDefault:"10"`
+	MaxLength     int  `env:"PASSWORD_MAX_LENGTH"     envDefault:"128"`
+	RequireUpper  bool `env:"PASSWORD_REQUIRE_UPPER"`
+	RequireLower  bool `env:"PASSWORD_REQUIRE_LOWER"`
+	RequireDigit  bool `env:"PASSWORD_REQUIRE_DIGIT"`
+	RequireSymbol bool `env:"PASSWORD_REQUIRE_SYMBOL"`
+	// MinScore is the lowest accepted strength score, from 0 for passwords guessed within a thousand
+	// attempts to 4 for those that take ten billion or more.
+	MinScore int `env:"PASSWORD_MIN_SCORE" envDefault:"3"`
+	// BreachedPasswordsDir is a local copy of a breached password list in the k-anonymity range
+	// format, with one <PREFIX>.txt file per SHA-1 hash prefix. The check is skipped when it is empty.
+	BreachedPasswordsDir string `env:"BREACHED_PASSWORDS_DIR"`
+}
+
+// PasswordHashingConfig contains the Argon2id parameters new password hashes are created with.
+// Hashes created with other parameters are upgraded the next time their owner logs in.
+type PasswordHashingConfig struct {
+	MemoryCostKiB uint32 `env:"ARGON2_MEMORY_COST_KIB" envDefault:"65536"`
+	TimeCost      uint32 `env:"ARGON2_TIME_COST"        envDefault:"3"`
+	Parallelism   uint8  `env:"ARGON2_PARALLELISM"      envDefault:"4"`
+	SaltLength    uint32 `env:"ARGON2_SALT_LENGTH"      envDefault:"16"`
+	HashLength    uint32 `env:"ARGON2_HASH_LENGTH"      envDefault:"32"`
+	// Pepper is a secret mixed into passwords before hashing and kept out of the database.
+	// Passwords are hashed without one when it is empty. Once set it cannot be removed or changed
+	// without locking out the users whose hashes were created with it.
+	Pepper string `env:"PASSWORD_PEPPER"`
+}
+
+// GoogleOAuthConfig contains the configuration for signing in with Google.
+type GoogleOAuthConfig struct {
+	ClientID string `env:"GOOGLE_CLIENT_ID"`
+	// Endpoint overrides the base URL of the Google OAuth2 API, e.g. to point at a local stub server.
+	Endpoint string `env:"GOOGLE_OAUTH_ENDPOINT"`
+}
+
+// OAuthConfig contains the configuration for logging in with external OpenID Connect providers.
+type OAuthConfig struct {
+	StateExpiresIn time.Duration `env:"OAUTH_STATE_EXPIRES_IN" envDefault:"10m"`
+	// Providers are read from OIDC_PROVIDERS_0_NAME, OIDC_PROVIDERS_0_ISSUER, OIDC_PROVIDERS_1_NAME and so on.
+	Providers []OIDCProviderConfig `envPrefix:"OIDC_PROVIDERS"`
+}
+
+// OIDCProviderConfig contains the configuration of a single OpenID Connect provider.
+type OIDCProviderConfig struct {
+	Name         string   `env:"NAME"`
+	Issuer       string   `env:"ISSUER"`
+	ClientID     string   `env:"CLIENT_ID"`
+	ClientSecret string   `env:"CLIENT_SECRET"`
+	RedirectURL  string   `env:"REDIRECT_URL"`
+	Scopes       []string `env:"SCOPES"        envDefault:"email"`
+}
+
+// MFAConfig contains the configuration for two-factor authentication.
+type MFAConfig struct {
+	// EncryptionKey is the base64 encoded 32 byte key TOTP secrets are encrypted with.
+	EncryptionKey     string `env:"MFA_ENCRYPTION_KEY"`
+	TOTPIssuer        string `env:"MFA_TOTP_ISSUER"         envDefault:"Money Tracker"`
+	MaxAttempts       int    `env:"MFA_MAX_ATTEMPTS"        envDefault:"5"`
+	RecoveryCodeCount int    `env:"MFA_RECOVERY_CODE_COUNT" envDefault:"10"`
+}
+
+// WebAuthnConfig contains the configuration for logging in with passkeys.
+type WebAuthnConfig struct {
+	// RPID is the domain passkeys are registered for, e.g. example.com. It cannot change without
+	// invalidating every registered passkey.
+	RPID   string `env:"WEBAUTHN_RP_ID"`
+	RPName string `env:"WEBAUTHN_RP_NAME" envDefault:"Money Tracker"`
+	// Origins are the exact origins the web and mobile clients run passkey ceremonies from.
+	Origins []string      `env:"WEBAUTHN_ORIGINS"`
+	Timeout time.Duration `env:"WEBAUTHN_TIMEOUT" envDefault:"5m"`
 }
 
 // NewAuthServiceConfig creates a new AuthServiceConfig instance from environment variables.
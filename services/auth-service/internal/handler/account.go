@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/usecase"
+	authpbv1 "github.com/vasapolrittideah/money-tracker-api/shared/protos/auth/v1"
+)
+
+func (h *authGRPCHandler) DeleteAccount(
+	ctx context.Context,
+	req *authpbv1.DeleteAccountRequest,
+) (*authpbv1.DeleteAccountResponse, error) {
+	userID, sessionID, err := sessionFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	params := usecase.DeleteAccountParams{
+		UserID:    userID,
+		SessionID: sessionID,
+		Password:  req.GetPassword(),
+		Client:    clientInfoFromContext(ctx),
+	}
+
+	purgeAt, err := h.accountUsecase.DeleteAccount(ctx, params)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to delete account")
+
+		switch {
+		case errors.Is(err, usecase.ErrReauthenticationRequired):
+			return nil, status.Errorf(codes.Unauthenticated, "re-authentication is required")
+		case errors.Is(err, usecase.ErrTooManyLoginAttempts):
+			return nil, status.Errorf(codes.ResourceExhausted, "too many login attempts, please try again later")
+		case errors.Is(err, usecase.ErrInvalidCredentials):
+			return nil, status.Errorf(codes.Unauthenticated, "invalid credentials")
+		default:
+			return nil, status.Errorf(codes.Internal, "something went wrong")
+		}
+	}
+
+	return &authpbv1.DeleteAccountResponse{
+		PurgeAt: timestamppb.New(purgeAt),
+	}, nil
+}
@@ -3,34 +3,82 @@ package handler
 import (
 	"context"
 	"errors"
+	"strings"
 
 	"github.com/rs/zerolog"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/usecase"
+	"github.com/vasapolrittideah/money-tracker-api/shared/interceptor"
 	authpbv1 "github.com/vasapolrittideah/money-tracker-api/shared/protos/auth/v1"
 )
 
 type authGRPCHandler struct {
 	authpbv1.UnimplementedAuthServiceServer
 
-	logger               *zerolog.Logger
-	authUsecase          usecase.AuthUsecase
-	passwordResetUsecase usecase.PasswordResetUsecase
+	logger                     *zerolog.Logger
+	authUsecase                usecase.AuthUsecase
+	magicLinkUsecase           usecase.MagicLinkUsecase
+	oauthServerUsecase         usecase.OAuthServerUsecase
+	passwordResetUsecase       usecase.PasswordResetUsecase
+	sessionUsecase             usecase.SessionUsecase
+	emailVerificationUsecase   usecase.EmailVerificationUsecase
+	identityUsecase            usecase.IdentityUsecase
+	mfaUsecase                 usecase.MFAUsecase
+	passkeyUsecase             usecase.PasskeyUsecase
+	emailChangeUsecase         usecase.EmailChangeUsecase
+	accountUsecase             usecase.AccountUsecase
+	dataExportUsecase          usecase.DataExportUsecase
+	securityEventUsecase       usecase.SecurityEventUsecase
+	loginAlertUsecase          usecase.LoginAlertUsecase
+	personalAccessTokenUsecase usecase.PersonalAccessTokenUsecase
+	oauthClientUsecase         usecase.OAuthClientUsecase
+	roleUsecase                usecase.RoleUsecase
 }
 
 func NewAuthGRPCHandler(
 	server *grpc.Server,
 	logger *zerolog.Logger,
 	authUsecase usecase.AuthUsecase,
+	magicLinkUsecase usecase.MagicLinkUsecase,
+	oauthServerUsecase usecase.OAuthServerUsecase,
 	passwordResetUsecase usecase.PasswordResetUsecase,
+	sessionUsecase usecase.SessionUsecase,
+	emailVerificationUsecase usecase.EmailVerificationUsecase,
+	identityUsecase usecase.IdentityUsecase,
+	mfaUsecase usecase.MFAUsecase,
+	passkeyUsecase usecase.PasskeyUsecase,
+	emailChangeUsecase usecase.EmailChangeUsecase,
+	accountUsecase usecase.AccountUsecase,
+	dataExportUsecase usecase.DataExportUsecase,
+	securityEventUsecase usecase.SecurityEventUsecase,
+	loginAlertUsecase usecase.LoginAlertUsecase,
+	personalAccessTokenUsecase usecase.PersonalAccessTokenUsecase,
+	oauthClientUsecase usecase.OAuthClientUsecase,
+	roleUsecase usecase.RoleUsecase,
 ) authpbv1.AuthServiceServer {
 	handler := &authGRPCHandler{
-		logger:               logger,
-		authUsecase:          authUsecase,
-		passwordResetUsecase: passwordResetUsecase,
+		logger:                     logger,
+		authUsecase:                authUsecase,
+		magicLinkUsecase:           magicLinkUsecase,
+		oauthServerUsecase:         oauthServerUsecase,
+		passwordResetUsecase:       passwordResetUsecase,
+		sessionUsecase:             sessionUsecase,
+		emailVerificationUsecase:   emailVerificationUsecase,
+		identityUsecase:            identityUsecase,
+		mfaUsecase:                 mfaUsecase,
+		passkeyUsecase:             passkeyUsecase,
+		emailChangeUsecase:         emailChangeUsecase,
+		accountUsecase:             accountUsecase,
+		dataExportUsecase:          dataExportUsecase,
+		securityEventUsecase:       securityEventUsecase,
+		loginAlertUsecase:          loginAlertUsecase,
+		personalAccessTokenUsecase: personalAccessTokenUsecase,
+		oauthClientUsecase:         oauthClientUsecase,
+		roleUsecase:                roleUsecase,
 	}
 	authpbv1.RegisterAuthServiceServer(server, handler)
 
@@ -41,23 +89,37 @@ func (h *authGRPCHandler) Login(ctx context.Context, req *authpbv1.LoginRequest)
 	params := usecase.LoginParams{
 		Email:    req.GetEmail(),
 		Password: req.GetPassword(),
+		Client:   clientInfoFromContext(ctx),
 	}
 
-	tokens, err := h.authUsecase.Login(ctx, params)
+	result, err := h.authUsecase.Login(ctx, params)
 	if err != nil {
 		h.logger.Error().Err(err).Msg("failed to login")
 
 		switch {
 		case errors.Is(err, usecase.ErrInvalidCredentials):
 			return nil, status.Errorf(codes.Unauthenticated, "invalid credentials")
+		case errors.Is(err, usecase.ErrTooManyLoginAttempts):
+			return nil, status.Errorf(codes.ResourceExhausted, "too many login attempts, please try again later")
+		case errors.Is(err, usecase.ErrEmailNotVerified):
+			return nil, status.Errorf(codes.FailedPrecondition, "email has not been verified")
+		case errors.Is(err, usecase.ErrPasswordResetRequired):
+			return nil, status.Errorf(codes.FailedPrecondition, "password has to be reset, check your email")
 		default:
 			return nil, status.Errorf(codes.Internal, "something went wrong")
 		}
 	}
 
+	if result.MFAToken != "" {
+		return &authpbv1.LoginResponse{
+			MfaRequired: true,
+			MfaToken:    result.MFAToken,
+		}, nil
+	}
+
 	return &authpbv1.LoginResponse{
-		AccessToken:  tokens.AccessToken,
-		RefreshToken: tokens.RefreshToken,
+		AccessToken:  result.Tokens.AccessToken,
+		RefreshToken: result.Tokens.RefreshToken,
 	}, nil
 }
 
@@ -68,6 +130,7 @@ func (h *authGRPCHandler) Register(
 	params := usecase.RegisterParams{
 		Email:    req.GetEmail(),
 		Password: req.GetPassword(),
+		Client:   clientInfoFromContext(ctx),
 	}
 
 	tokens, err := h.authUsecase.Register(ctx, params)
@@ -77,13 +140,162 @@ func (h *authGRPCHandler) Register(
 		switch {
 		case errors.Is(err, usecase.ErrUserAlreadyExists):
 			return nil, status.Errorf(codes.AlreadyExists, "user already exists")
+		case errors.Is(err, usecase.ErrPasswordPolicyViolation):
+			return nil, status.Error(codes.InvalidArgument, err.Error())
 		default:
 			return nil, status.Errorf(codes.Internal, "something went wrong")
 		}
 	}
 
+	// No tokens are issued when the user must verify their email before logging in
+	if tokens == nil {
+		return &authpbv1.RegisterResponse{VerificationRequired: true}, nil
+	}
+
 	return &authpbv1.RegisterResponse{
 		AccessToken:  tokens.AccessToken,
 		RefreshToken: tokens.RefreshToken,
 	}, nil
 }
+
+func (h *authGRPCHandler) LoginWithGoogle(
+	ctx context.Context,
+	req *authpbv1.LoginWithGoogleRequest,
+) (*authpbv1.LoginWithGoogleResponse, error) {
+	idToken := req.GetIdToken()
+	if idToken == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "id token is required")
+	}
+
+	params := usecase.LoginWithGoogleParams{
+		IDToken: idToken,
+		Client:  clientInfoFromContext(ctx),
+	}
+
+	result, err := h.authUsecase.LoginWithGoogle(ctx, params)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to login with google")
+
+		switch {
+		case errors.Is(err, usecase.ErrInvalidIDToken):
+			return nil, status.Errorf(codes.Unauthenticated, "invalid id token")
+		case errors.Is(err, usecase.ErrUserAlreadyExists):
+			return nil, status.Errorf(codes.AlreadyExists, "an account with this email already exists")
+		case errors.Is(err, usecase.ErrEmailNotVerified):
+			return nil, status.Errorf(codes.FailedPrecondition, "email has not been verified")
+		default:
+			return nil, status.Errorf(codes.Internal, "something went wrong")
+		}
+	}
+
+	if result.MFAToken != "" {
+		return &authpbv1.LoginWithGoogleResponse{
+			MfaRequired: true,
+			MfaToken:    result.MFAToken,
+		}, nil
+	}
+
+	return &authpbv1.LoginWithGoogleResponse{
+		AccessToken:  result.Tokens.AccessToken,
+		RefreshToken: result.Tokens.RefreshToken,
+	}, nil
+}
+
+func (h *authGRPCHandler) RefreshTokens(
+	ctx context.Context,
+	req *authpbv1.RefreshTokensRequest,
+) (*authpbv1.RefreshTokensResponse, error) {
+	refreshToken := req.GetRefreshToken()
+	if refreshToken == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "refresh token is required")
+	}
+
+	tokens, err := h.authUsecase.RefreshTokens(ctx, refreshToken)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to refresh tokens")
+
+		switch {
+		case errors.Is(err, usecase.ErrInvalidRefreshToken):
+			return nil, status.Errorf(codes.Unauthenticated, "invalid refresh token")
+		case errors.Is(err, usecase.ErrRefreshTokenReused):
+			return nil, status.Errorf(codes.Unauthenticated, "refresh token has already been used")
+		case errors.Is(err, usecase.ErrSessionRevoked):
+			return nil, status.Errorf(codes.Unauthenticated, "session has been revoked")
+		default:
+			return nil, status.Errorf(codes.Internal, "something went wrong")
+		}
+	}
+
+	return &authpbv1.RefreshTokensResponse{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+	}, nil
+}
+
+func (h *authGRPCHandler) GetJSONWebKeySet(
+	ctx context.Context,
+	req *authpbv1.GetJSONWebKeySetRequest,
+) (*authpbv1.GetJSONWebKeySetResponse, error) {
+	jwks := h.authUsecase.JSONWebKeySet()
+
+	keys := make([]*authpbv1.JSONWebKey, 0, len(jwks.Keys))
+	for _, key := range jwks.Keys {
+		keys = append(keys, &authpbv1.JSONWebKey{
+			Kty: key.KeyType,
+			Kid: key.KeyID,
+			Use: key.Use,
+			Alg: key.Algorithm,
+			N:   key.N,
+			E:   key.E,
+			Crv: key.Curve,
+			X:   key.X,
+		})
+	}
+
+	return &authpbv1.GetJSONWebKeySetResponse{
+		Keys: keys,
+	}, nil
+}
+
+func (h *authGRPCHandler) Introspect(
+	ctx context.Context,
+	req *authpbv1.IntrospectRequest,
+) (*authpbv1.IntrospectResponse, error) {
+	token := req.GetToken()
+	if token == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "token is required")
+	}
+
+	var introspection *usecase.TokenIntrospection
+	var err error
+	if strings.HasPrefix(token, interceptor.PersonalAccessTokenPrefix) {
+		introspection, err = h.personalAccessTokenUsecase.IntrospectPersonalAccessToken(ctx, token)
+	} else {
+		introspection, err = h.authUsecase.Introspect(ctx, token)
+	}
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to introspect token")
+		return nil, status.Errorf(codes.Internal, "something went wrong")
+	}
+
+	if !introspection.Active {
+		return &authpbv1.IntrospectResponse{Active: false}, nil
+	}
+
+	resp := &authpbv1.IntrospectResponse{
+		Active:      true,
+		TokenType:   introspection.TokenType,
+		UserId:      introspection.UserID,
+		SessionId:   introspection.SessionID,
+		ClientId:    introspection.ClientID,
+		Scopes:      introspection.Scopes,
+		Roles:       introspection.Roles,
+		Permissions: introspection.Permissions,
+	}
+	// Personal access tokens may not expire
+	if !introspection.ExpiresAt.IsZero() {
+		resp.ExpiresAt = timestamppb.New(introspection.ExpiresAt)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/usecase"
+	authpbv1 "github.com/vasapolrittideah/money-tracker-api/shared/protos/auth/v1"
+)
+
+func (h *authGRPCHandler) RequestDataExport(
+	ctx context.Context,
+	req *authpbv1.RequestDataExportRequest,
+) (*authpbv1.RequestDataExportResponse, error) {
+	userID, _, err := sessionFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt, err := h.dataExportUsecase.RequestDataExport(ctx, userID)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to request data export")
+
+		switch {
+		case errors.Is(err, usecase.ErrDataExportRequestedRecently):
+			return nil, status.Errorf(codes.ResourceExhausted, "a data export has been requested recently")
+		default:
+			return nil, status.Errorf(codes.Internal, "something went wrong")
+		}
+	}
+
+	return &authpbv1.RequestDataExportResponse{
+		ExpiresAt: timestamppb.New(expiresAt),
+	}, nil
+}
+
+func (h *authGRPCHandler) DownloadDataExport(
+	ctx context.Context,
+	req *authpbv1.DownloadDataExportRequest,
+) (*authpbv1.DownloadDataExportResponse, error) {
+	if req.GetToken() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "token is required")
+	}
+
+	userID, _, err := sessionFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	archive, err := h.dataExportUsecase.DownloadDataExport(ctx, userID, req.GetToken())
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to download data export")
+
+		switch {
+		case errors.Is(err, usecase.ErrInvalidDataExportToken):
+			return nil, status.Errorf(codes.NotFound, "data export not found or expired")
+		default:
+			return nil, status.Errorf(codes.Internal, "something went wrong")
+		}
+	}
+
+	return &authpbv1.DownloadDataExportResponse{
+		Archive: archive,
+	}, nil
+}
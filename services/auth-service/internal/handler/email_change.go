@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/usecase"
+	authpbv1 "github.com/vasapolrittideah/money-tracker-api/shared/protos/auth/v1"
+)
+
+func (h *authGRPCHandler) RequestEmailChange(
+	ctx context.Context,
+	req *authpbv1.RequestEmailChangeRequest,
+) (*authpbv1.RequestEmailChangeResponse, error) {
+	if req.GetNewEmail() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "new email is required")
+	}
+
+	userID, sessionID, err := sessionFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	params := usecase.RequestEmailChangeParams{
+		UserID:    userID,
+		SessionID: sessionID,
+		Password:  req.GetPassword(),
+		NewEmail:  req.GetNewEmail(),
+		Client:    clientInfoFromContext(ctx),
+	}
+
+	if err := h.emailChangeUsecase.RequestEmailChange(ctx, params); err != nil {
+		h.logger.Error().Err(err).Msg("failed to request email change")
+
+		switch {
+		case errors.Is(err, usecase.ErrReauthenticationRequired):
+			return nil, status.Errorf(codes.Unauthenticated, "re-authentication is required")
+		case errors.Is(err, usecase.ErrTooManyLoginAttempts):
+			return nil, status.Errorf(codes.ResourceExhausted, "too many login attempts, please try again later")
+		case errors.Is(err, usecase.ErrInvalidCredentials):
+			return nil, status.Errorf(codes.Unauthenticated, "invalid credentials")
+		case errors.Is(err, usecase.ErrEmailUnchanged):
+			return nil, status.Errorf(codes.InvalidArgument, "new email is the same as the current one")
+		case errors.Is(err, usecase.ErrEmailAlreadyInUse):
+			return nil, status.Errorf(codes.AlreadyExists, "email is already in use")
+		default:
+			return nil, status.Errorf(codes.Internal, "something went wrong")
+		}
+	}
+
+	return &authpbv1.RequestEmailChangeResponse{}, nil
+}
+
+func (h *authGRPCHandler) ConfirmEmailChange(
+	ctx context.Context,
+	req *authpbv1.ConfirmEmailChangeRequest,
+) (*authpbv1.ConfirmEmailChangeResponse, error) {
+	if req.GetToken() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "token is required")
+	}
+
+	if err := h.emailChangeUsecase.ConfirmEmailChange(ctx, req.GetToken(), clientInfoFromContext(ctx)); err != nil {
+		h.logger.Error().Err(err).Msg("failed to confirm email change")
+
+		switch {
+		case errors.Is(err, usecase.ErrInvalidEmailChangeToken):
+			return nil, status.Errorf(codes.InvalidArgument, "invalid or expired token")
+		case errors.Is(err, usecase.ErrEmailAlreadyInUse):
+			return nil, status.Errorf(codes.AlreadyExists, "email is already in use")
+		default:
+			return nil, status.Errorf(codes.Internal, "something went wrong")
+		}
+	}
+
+	return &authpbv1.ConfirmEmailChangeResponse{}, nil
+}
+
+func (h *authGRPCHandler) CancelEmailChange(
+	ctx context.Context,
+	req *authpbv1.CancelEmailChangeRequest,
+) (*authpbv1.CancelEmailChangeResponse, error) {
+	if req.GetToken() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "token is required")
+	}
+
+	if err := h.emailChangeUsecase.CancelEmailChange(ctx, req.GetToken(), clientInfoFromContext(ctx)); err != nil {
+		h.logger.Error().Err(err).Msg("failed to cancel email change")
+
+		switch {
+		case errors.Is(err, usecase.ErrInvalidEmailChangeToken):
+			return nil, status.Errorf(codes.InvalidArgument, "invalid or expired token")
+		default:
+			return nil, status.Errorf(codes.Internal, "something went wrong")
+		}
+	}
+
+	return &authpbv1.CancelEmailChangeResponse{}, nil
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/usecase"
+	authpbv1 "github.com/vasapolrittideah/money-tracker-api/shared/protos/auth/v1"
+)
+
+func (h *authGRPCHandler) VerifyEmail(
+	ctx context.Context,
+	req *authpbv1.VerifyEmailRequest,
+) (*authpbv1.VerifyEmailResponse, error) {
+	email := req.GetEmail()
+	if email == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "email is required")
+	}
+
+	code := req.GetCode()
+	if code == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "verification code is required")
+	}
+
+	err := h.emailVerificationUsecase.VerifyEmail(ctx, email, code)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to verify email")
+
+		switch {
+		case errors.Is(err, usecase.ErrInvalidVerificationCode):
+			return nil, status.Errorf(codes.InvalidArgument, "invalid verification code")
+		case errors.Is(err, usecase.ErrVerificationCodeExpired):
+			return nil, status.Errorf(codes.FailedPrecondition, "verification code has expired")
+		case errors.Is(err, usecase.ErrTooManyVerificationAttempts):
+			return nil, status.Errorf(codes.ResourceExhausted, "too many verification attempts")
+		case errors.Is(err, usecase.ErrEmailAlreadyVerified):
+			return nil, status.Errorf(codes.FailedPrecondition, "email has already been verified")
+		default:
+			return nil, status.Errorf(codes.Internal, "something went wrong")
+		}
+	}
+
+	return &authpbv1.VerifyEmailResponse{}, nil
+}
+
+func (h *authGRPCHandler) ResendVerificationEmail(
+	ctx context.Context,
+	req *authpbv1.ResendVerificationEmailRequest,
+) (*authpbv1.ResendVerificationEmailResponse, error) {
+	email := req.GetEmail()
+	if email == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "email is required")
+	}
+
+	err := h.emailVerificationUsecase.ResendVerificationEmail(ctx, email)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to resend verification email")
+
+		switch {
+		case errors.Is(err, usecase.ErrVerificationResendTooSoon):
+			return nil, status.Errorf(codes.ResourceExhausted, "verification email was sent too recently")
+		default:
+			return nil, status.Errorf(codes.Internal, "something went wrong")
+		}
+	}
+
+	return &authpbv1.ResendVerificationEmailResponse{}, nil
+}
@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/model"
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/usecase"
+	authpbv1 "github.com/vasapolrittideah/money-tracker-api/shared/protos/auth/v1"
+)
+
+func (h *authGRPCHandler) ListIdentities(
+	ctx context.Context,
+	_ *authpbv1.ListIdentitiesRequest,
+) (*authpbv1.ListIdentitiesResponse, error) {
+	userID, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	identities, err := h.identityUsecase.ListIdentities(ctx, userID)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to list identities")
+		return nil, status.Errorf(codes.Internal, "something went wrong")
+	}
+
+	resp := &authpbv1.ListIdentitiesResponse{
+		Identities: make([]*authpbv1.Identity, 0, len(identities)),
+	}
+	for i := range identities {
+		resp.Identities = append(resp.Identities, identityToProto(&identities[i]))
+	}
+
+	return resp, nil
+}
+
+func (h *authGRPCHandler) LinkIdentity(
+	ctx context.Context,
+	req *authpbv1.LinkIdentityRequest,
+) (*authpbv1.LinkIdentityResponse, error) {
+	providerName := req.GetProvider()
+	if providerName == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "provider is required")
+	}
+
+	userID, sessionID, err := sessionFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	params := usecase.LinkIdentityParams{
+		UserID:    userID,
+		SessionID: sessionID,
+		Provider:  providerName,
+		Password:  req.GetPassword(),
+		IDToken:   req.GetIdToken(),
+	}
+
+	result, err := h.identityUsecase.LinkIdentity(ctx, params)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to link identity")
+
+		switch {
+		case errors.Is(err, usecase.ErrReauthenticationRequired):
+			return nil, status.Errorf(codes.Unauthenticated, "re-authentication is required")
+		case errors.Is(err, usecase.ErrTooManyLoginAttempts):
+			return nil, status.Errorf(codes.ResourceExhausted, "too many login attempts, please try again later")
+		case errors.Is(err, usecase.ErrInvalidCredentials):
+			return nil, status.Errorf(codes.Unauthenticated, "invalid credentials")
+		case errors.Is(err, usecase.ErrInvalidIDToken):
+			return nil, status.Errorf(codes.Unauthenticated, "invalid id token")
+		case errors.Is(err, usecase.ErrUnknownProvider):
+			return nil, status.Errorf(codes.NotFound, "unknown provider")
+		case errors.Is(err, usecase.ErrIdentityAlreadyLinked):
+			return nil, status.Errorf(codes.AlreadyExists, "account is already linked to another user")
+		case errors.Is(err, usecase.ErrProviderAlreadyLinked):
+			return nil, status.Errorf(codes.FailedPrecondition, "an account of this provider is already linked")
+		default:
+			return nil, status.Errorf(codes.Internal, "something went wrong")
+		}
+	}
+
+	if result.Identity != nil {
+		return &authpbv1.LinkIdentityResponse{
+			Identity: identityToProto(result.Identity),
+		}, nil
+	}
+
+	return &authpbv1.LinkIdentityResponse{
+		AuthorizationUrl: result.AuthorizationURL,
+	}, nil
+}
+
+func (h *authGRPCHandler) UnlinkIdentity(
+	ctx context.Context,
+	req *authpbv1.UnlinkIdentityRequest,
+) (*authpbv1.UnlinkIdentityResponse, error) {
+	identityID := req.GetIdentityId()
+	if identityID == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "identity ID is required")
+	}
+
+	userID, _, err := sessionFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.identityUsecase.UnlinkIdentity(ctx, userID, identityID); err != nil {
+		h.logger.Error().Err(err).Msg("failed to unlink identity")
+
+		switch {
+		case errors.Is(err, usecase.ErrIdentityNotFound):
+			return nil, status.Errorf(codes.NotFound, "identity not found")
+		case errors.Is(err, usecase.ErrLastLoginMethod):
+			return nil, status.Errorf(codes.FailedPrecondition, "cannot unlink the last login method")
+		default:
+			return nil, status.Errorf(codes.Internal, "something went wrong")
+		}
+	}
+
+	return &authpbv1.UnlinkIdentityResponse{}, nil
+}
+
+func identityToProto(identity *model.Identity) *authpbv1.Identity {
+	return &authpbv1.Identity{
+		Id:          identity.ID.Hex(),
+		Provider:    identity.Provider,
+		Email:       identity.Email,
+		CreatedAt:   timestamppb.New(identity.CreatedAt),
+		LastLoginAt: timestamppb.New(identity.LastLoginAt),
+	}
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/usecase"
+	authpbv1 "github.com/vasapolrittideah/money-tracker-api/shared/protos/auth/v1"
+)
+
+func (h *authGRPCHandler) GetLoginAlerts(
+	ctx context.Context,
+	_ *authpbv1.GetLoginAlertsRequest,
+) (*authpbv1.GetLoginAlertsResponse, error) {
+	userID, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rule, err := h.loginAlertUsecase.GetLoginAlerts(ctx, userID)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to get login alerts")
+		return nil, status.Errorf(codes.Internal, "something went wrong")
+	}
+
+	return &authpbv1.GetLoginAlertsResponse{
+		Rule: rule,
+	}, nil
+}
+
+func (h *authGRPCHandler) UpdateLoginAlerts(
+	ctx context.Context,
+	req *authpbv1.UpdateLoginAlertsRequest,
+) (*authpbv1.UpdateLoginAlertsResponse, error) {
+	userID, _, err := sessionFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.loginAlertUsecase.UpdateLoginAlerts(ctx, userID, req.GetRule()); err != nil {
+		h.logger.Error().Err(err).Msg("failed to update login alerts")
+
+		switch {
+		case errors.Is(err, usecase.ErrInvalidLoginAlertRule):
+			return nil, status.Errorf(codes.InvalidArgument, "rule must be one of new_device, always or off")
+		default:
+			return nil, status.Errorf(codes.Internal, "something went wrong")
+		}
+	}
+
+	return &authpbv1.UpdateLoginAlertsResponse{}, nil
+}
+
+func (h *authGRPCHandler) ReportLogin(
+	ctx context.Context,
+	req *authpbv1.ReportLoginRequest,
+) (*authpbv1.ReportLoginResponse, error) {
+	if req.GetToken() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "token is required")
+	}
+
+	if err := h.loginAlertUsecase.ReportLogin(ctx, req.GetToken(), clientInfoFromContext(ctx)); err != nil {
+		h.logger.Error().Err(err).Msg("failed to report login")
+
+		switch {
+		case errors.Is(err, usecase.ErrInvalidLoginAlertToken):
+			return nil, status.Errorf(codes.InvalidArgument, "invalid or expired token")
+		default:
+			return nil, status.Errorf(codes.Internal, "something went wrong")
+		}
+	}
+
+	return &authpbv1.ReportLoginResponse{}, nil
+}
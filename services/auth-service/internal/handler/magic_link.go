@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/usecase"
+	authpbv1 "github.com/vasapolrittideah/money-tracker-api/shared/protos/auth/v1"
+)
+
+func (h *authGRPCHandler) RequestMagicLink(
+	ctx context.Context,
+	req *authpbv1.RequestMagicLinkRequest,
+) (*authpbv1.RequestMagicLinkResponse, error) {
+	if req.GetEmail() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "email is required")
+	}
+
+	params := usecase.RequestMagicLinkParams{
+		Email:  req.GetEmail(),
+		Client: clientInfoFromContext(ctx),
+	}
+
+	if err := h.magicLinkUsecase.RequestMagicLink(ctx, params); err != nil {
+		h.logger.Error().Err(err).Msg("failed to request magic link")
+		return nil, status.Errorf(codes.Internal, "something went wrong")
+	}
+
+	return &authpbv1.RequestMagicLinkResponse{}, nil
+}
+
+func (h *authGRPCHandler) LoginWithMagicLink(
+	ctx context.Context,
+	req *authpbv1.LoginWithMagicLinkRequest,
+) (*authpbv1.LoginWithMagicLinkResponse, error) {
+	if req.GetToken() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "token is required")
+	}
+
+	params := usecase.LoginWithMagicLinkParams{
+		Token:  req.GetToken(),
+		Client: clientInfoFromContext(ctx),
+	}
+
+	result, err := h.magicLinkUsecase.LoginWithMagicLink(ctx, params)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to login with magic link")
+
+		switch {
+		case errors.Is(err, usecase.ErrInvalidMagicLink):
+			return nil, status.Errorf(codes.Unauthenticated, "invalid or expired magic link")
+		default:
+			return nil, status.Errorf(codes.Internal, "something went wrong")
+		}
+	}
+
+	if result.MFAToken != "" {
+		return &authpbv1.LoginWithMagicLinkResponse{
+			MfaRequired: true,
+			MfaToken:    result.MFAToken,
+		}, nil
+	}
+
+	return &authpbv1.LoginWithMagicLinkResponse{
+		AccessToken:  result.Tokens.AccessToken,
+		RefreshToken: result.Tokens.RefreshToken,
+	}, nil
+}
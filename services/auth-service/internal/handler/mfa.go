@@ -0,0 +1,223 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/usecase"
+	authpbv1 "github.com/vasapolrittideah/money-tracker-api/shared/protos/auth/v1"
+)
+
+func (h *authGRPCHandler) VerifyMFA(
+	ctx context.Context,
+	req *authpbv1.VerifyMFARequest,
+) (*authpbv1.VerifyMFAResponse, error) {
+	mfaToken := req.GetMfaToken()
+	if mfaToken == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "MFA token is required")
+	}
+
+	code := req.GetCode()
+	if code == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "code is required")
+	}
+
+	params := usecase.VerifyMFAParams{
+		MFAToken: mfaToken,
+		Code:     code,
+		Client:   clientInfoFromContext(ctx),
+	}
+
+	tokens, err := h.authUsecase.VerifyMFA(ctx, params)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to verify MFA")
+
+		switch {
+		case errors.Is(err, usecase.ErrInvalidMFAToken):
+			return nil, status.Errorf(codes.Unauthenticated, "invalid or expired MFA token")
+		case errors.Is(err, usecase.ErrInvalidMFACode):
+			return nil, status.Errorf(codes.Unauthenticated, "invalid MFA code")
+		case errors.Is(err, usecase.ErrTooManyMFAAttempts):
+			return nil, status.Errorf(codes.ResourceExhausted, "too many MFA attempts")
+		default:
+			return nil, status.Errorf(codes.Internal, "something went wrong")
+		}
+	}
+
+	return &authpbv1.VerifyMFAResponse{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+	}, nil
+}
+
+func (h *authGRPCHandler) EnrollTOTP(
+	ctx context.Context,
+	req *authpbv1.EnrollTOTPRequest,
+) (*authpbv1.EnrollTOTPResponse, error) {
+	userID, sessionID, err := sessionFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	params := usecase.EnrollTOTPParams{
+		UserID:    userID,
+		SessionID: sessionID,
+		Password:  req.GetPassword(),
+	}
+
+	enrollment, err := h.mfaUsecase.EnrollTOTP(ctx, params)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to enroll TOTP")
+
+		switch {
+		case errors.Is(err, usecase.ErrTOTPAlreadyEnabled):
+			return nil, status.Errorf(codes.FailedPrecondition, "TOTP is already enabled")
+		case errors.Is(err, usecase.ErrReauthenticationRequired):
+			return nil, status.Errorf(codes.Unauthenticated, "re-authentication is required")
+		case errors.Is(err, usecase.ErrTooManyLoginAttempts):
+			return nil, status.Errorf(codes.ResourceExhausted, "too many login attempts, please try again later")
+		case errors.Is(err, usecase.ErrInvalidCredentials):
+			return nil, status.Errorf(codes.Unauthenticated, "invalid credentials")
+		default:
+			return nil, status.Errorf(codes.Internal, "something went wrong")
+		}
+	}
+
+	return &authpbv1.EnrollTOTPResponse{
+		Secret:          enrollment.Secret,
+		ProvisioningUri: enrollment.ProvisioningURI,
+	}, nil
+}
+
+func (h *authGRPCHandler) ConfirmTOTP(
+	ctx context.Context,
+	req *authpbv1.ConfirmTOTPRequest,
+) (*authpbv1.ConfirmTOTPResponse, error) {
+	code := req.GetCode()
+	if code == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "code is required")
+	}
+
+	userID, _, err := sessionFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	recoveryCodes, err := h.mfaUsecase.ConfirmTOTP(ctx, userID, code)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to confirm TOTP")
+
+		switch {
+		case errors.Is(err, usecase.ErrTOTPAlreadyEnabled):
+			return nil, status.Errorf(codes.FailedPrecondition, "TOTP is already enabled")
+		case errors.Is(err, usecase.ErrTOTPNotEnrolled):
+			return nil, status.Errorf(codes.FailedPrecondition, "TOTP enrollment has not been started")
+		case errors.Is(err, usecase.ErrTooManyMFAAttempts):
+			return nil, status.Errorf(codes.ResourceExhausted, "too many MFA attempts")
+		case errors.Is(err, usecase.ErrInvalidMFACode):
+			return nil, status.Errorf(codes.InvalidArgument, "invalid MFA code")
+		default:
+			return nil, status.Errorf(codes.Internal, "something went wrong")
+		}
+	}
+
+	return &authpbv1.ConfirmTOTPResponse{
+		RecoveryCodes: recoveryCodes,
+	}, nil
+}
+
+func (h *authGRPCHandler) DisableTOTP(
+	ctx context.Context,
+	req *authpbv1.DisableTOTPRequest,
+) (*authpbv1.DisableTOTPResponse, error) {
+	code := req.GetCode()
+	if code == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "code is required")
+	}
+
+	userID, sessionID, err := sessionFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	params := usecase.DisableTOTPParams{
+		UserID:    userID,
+		SessionID: sessionID,
+		Password:  req.GetPassword(),
+		Code:      code,
+	}
+
+	if err := h.mfaUsecase.DisableTOTP(ctx, params); err != nil {
+		h.logger.Error().Err(err).Msg("failed to disable TOTP")
+
+		switch {
+		case errors.Is(err, usecase.ErrTOTPNotEnabled):
+			return nil, status.Errorf(codes.FailedPrecondition, "TOTP is not enabled")
+		case errors.Is(err, usecase.ErrReauthenticationRequired):
+			return nil, status.Errorf(codes.Unauthenticated, "re-authentication is required")
+		case errors.Is(err, usecase.ErrTooManyLoginAttempts):
+			return nil, status.Errorf(codes.ResourceExhausted, "too many login attempts, please try again later")
+		case errors.Is(err, usecase.ErrInvalidCredentials):
+			return nil, status.Errorf(codes.Unauthenticated, "invalid credentials")
+		case errors.Is(err, usecase.ErrTooManyMFAAttempts):
+			return nil, status.Errorf(codes.ResourceExhausted, "too many MFA attempts")
+		case errors.Is(err, usecase.ErrInvalidMFACode):
+			return nil, status.Errorf(codes.InvalidArgument, "invalid MFA code")
+		default:
+			return nil, status.Errorf(codes.Internal, "something went wrong")
+		}
+	}
+
+	return &authpbv1.DisableTOTPResponse{}, nil
+}
+
+func (h *authGRPCHandler) RegenerateRecoveryCodes(
+	ctx context.Context,
+	req *authpbv1.RegenerateRecoveryCodesRequest,
+) (*authpbv1.RegenerateRecoveryCodesResponse, error) {
+	code := req.GetCode()
+	if code == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "code is required")
+	}
+
+	userID, sessionID, err := sessionFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	params := usecase.RegenerateRecoveryCodesParams{
+		UserID:    userID,
+		SessionID: sessionID,
+		Password:  req.GetPassword(),
+		Code:      code,
+	}
+
+	recoveryCodes, err := h.mfaUsecase.RegenerateRecoveryCodes(ctx, params)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to regenerate recovery codes")
+
+		switch {
+		case errors.Is(err, usecase.ErrTOTPNotEnabled):
+			return nil, status.Errorf(codes.FailedPrecondition, "TOTP is not enabled")
+		case errors.Is(err, usecase.ErrReauthenticationRequired):
+			return nil, status.Errorf(codes.Unauthenticated, "re-authentication is required")
+		case errors.Is(err, usecase.ErrTooManyLoginAttempts):
+			return nil, status.Errorf(codes.ResourceExhausted, "too many login attempts, please try again later")
+		case errors.Is(err, usecase.ErrInvalidCredentials):
+			return nil, status.Errorf(codes.Unauthenticated, "invalid credentials")
+		case errors.Is(err, usecase.ErrTooManyMFAAttempts):
+			return nil, status.Errorf(codes.ResourceExhausted, "too many MFA attempts")
+		case errors.Is(err, usecase.ErrInvalidMFACode):
+			return nil, status.Errorf(codes.InvalidArgument, "invalid MFA code")
+		default:
+			return nil, status.Errorf(codes.Internal, "something went wrong")
+		}
+	}
+
+	return &authpbv1.RegenerateRecoveryCodesResponse{
+		RecoveryCodes: recoveryCodes,
+	}, nil
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/usecase"
+	authpbv1 "github.com/vasapolrittideah/money-tracker-api/shared/protos/auth/v1"
+)
+
+func (h *authGRPCHandler) StartOAuthLogin(
+	ctx context.Context,
+	req *authpbv1.StartOAuthLoginRequest,
+) (*authpbv1.StartOAuthLoginResponse, error) {
+	providerName := req.GetProvider()
+	if providerName == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "provider is required")
+	}
+
+	authorizationURL, err := h.authUsecase.StartOAuthLogin(ctx, providerName)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to start oauth login")
+
+		switch {
+		case errors.Is(err, usecase.ErrUnknownProvider):
+			return nil, status.Errorf(codes.NotFound, "unknown provider")
+		default:
+			return nil, status.Errorf(codes.Internal, "something went wrong")
+		}
+	}
+
+	return &authpbv1.StartOAuthLoginResponse{
+		AuthorizationUrl: authorizationURL,
+	}, nil
+}
+
+func (h *authGRPCHandler) CompleteOAuthLogin(
+	ctx context.Context,
+	req *authpbv1.CompleteOAuthLoginRequest,
+) (*authpbv1.CompleteOAuthLoginResponse, error) {
+	if req.GetProvider() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "provider is required")
+	}
+	if req.GetState() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "state is required")
+	}
+	if req.GetCode() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "code is required")
+	}
+
+	params := usecase.CompleteOAuthLoginParams{
+		Provider: req.GetProvider(),
+		State:    req.GetState(),
+		Code:     req.GetCode(),
+		Client:   clientInfoFromContext(ctx),
+	}
+
+	result, err := h.authUsecase.CompleteOAuthLogin(ctx, params)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to complete oauth login")
+
+		switch {
+		case errors.Is(err, usecase.ErrUnknownProvider):
+			return nil, status.Errorf(codes.NotFound, "unknown provider")
+		case errors.Is(err, usecase.ErrInvalidOAuthState):
+			return nil, status.Errorf(codes.Unauthenticated, "invalid or expired state")
+		case errors.Is(err, usecase.ErrInvalidAuthorizationCode):
+			return nil, status.Errorf(codes.Unauthenticated, "invalid authorization code")
+		case errors.Is(err, usecase.ErrInvalidIDToken):
+			return nil, status.Errorf(codes.Unauthenticated, "invalid id token")
+		case errors.Is(err, usecase.ErrProviderEmailRequired):
+			return nil, status.Errorf(codes.FailedPrecondition, "provider did not share an email address")
+		case errors.Is(err, usecase.ErrUserAlreadyExists):
+			return nil, status.Errorf(codes.AlreadyExists, "an account with this email already exists")
+		case errors.Is(err, usecase.ErrEmailNotVerified):
+			return nil, status.Errorf(codes.FailedPrecondition, "email has not been verified")
+		case errors.Is(err, usecase.ErrIdentityAlreadyLinked):
+			return nil, status.Errorf(codes.AlreadyExists, "account is already linked to another user")
+		case errors.Is(err, usecase.ErrProviderAlreadyLinked):
+			return nil, status.Errorf(codes.FailedPrecondition, "an account of this provider is already linked")
+		default:
+			return nil, status.Errorf(codes.Internal, "something went wrong")
+		}
+	}
+
+	if result.LinkedIdentity != nil {
+		return &authpbv1.CompleteOAuthLoginResponse{
+			LinkedIdentity: identityToProto(result.LinkedIdentity),
+		}, nil
+	}
+
+	if result.Login.MFAToken != "" {
+		return &authpbv1.CompleteOAuthLoginResponse{
+			MfaRequired: true,
+			MfaToken:    result.Login.MFAToken,
+		}, nil
+	}
+
+	return &authpbv1.CompleteOAuthLoginResponse{
+		AccessToken:  result.Login.Tokens.AccessToken,
+		RefreshToken: result.Login.Tokens.RefreshToken,
+	}, nil
+}
@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/model"
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/usecase"
+	authpbv1 "github.com/vasapolrittideah/money-tracker-api/shared/protos/auth/v1"
+)
+
+func (h *authGRPCHandler) CreateOAuthClient(
+	ctx context.Context,
+	req *authpbv1.CreateOAuthClientRequest,
+) (*authpbv1.CreateOAuthClientResponse, error) {
+	if req.GetName() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "name is required")
+	}
+
+	userID, _, err := sessionFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	params := usecase.CreateOAuthClientParams{
+		UserID:       userID,
+		Name:         req.GetName(),
+		RedirectURIs: req.GetRedirectUris(),
+		Scopes:       req.GetScopes(),
+		Confidential: req.GetConfidential(),
+	}
+
+	client, secret, err := h.oauthClientUsecase.CreateOAuthClient(ctx, params)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to create oauth client")
+
+		switch {
+		case errors.Is(err, usecase.ErrInvalidRedirectURI):
+			return nil, status.Errorf(
+				codes.InvalidArgument,
+				"redirect uris must be absolute https or loopback http urls without a fragment",
+			)
+		case errors.Is(err, usecase.ErrInvalidScope):
+			return nil, status.Errorf(codes.InvalidArgument, "scopes must be one or more of the supported scopes")
+		default:
+			return nil, status.Errorf(codes.Internal, "something went wrong")
+		}
+	}
+
+	return &authpbv1.CreateOAuthClientResponse{
+		Client:       oauthClientToProto(client),
+		ClientSecret: secret,
+	}, nil
+}
+
+func (h *authGRPCHandler) ListOAuthClients(
+	ctx context.Context,
+	_ *authpbv1.ListOAuthClientsRequest,
+) (*authpbv1.ListOAuthClientsResponse, error) {
+	userID, _, err := sessionFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	clients, err := h.oauthClientUsecase.ListOAuthClients(ctx, userID)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to list oauth clients")
+		return nil, status.Errorf(codes.Internal, "something went wrong")
+	}
+
+	resp := &authpbv1.ListOAuthClientsResponse{
+		Clients: make([]*authpbv1.OAuthClient, 0, len(clients)),
+	}
+	for _, client := range clients {
+		resp.Clients = append(resp.Clients, oauthClientToProto(client))
+	}
+
+	return resp, nil
+}
+
+func (h *authGRPCHandler) DeleteOAuthClient(
+	ctx context.Context,
+	req *authpbv1.DeleteOAuthClientRequest,
+) (*authpbv1.DeleteOAuthClientResponse, error) {
+	if req.GetClientId() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "client id is required")
+	}
+
+	userID, _, err := sessionFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.oauthClientUsecase.DeleteOAuthClient(ctx, userID, req.GetClientId()); err != nil {
+		h.logger.Error().Err(err).Msg("failed to delete oauth client")
+
+		switch {
+		case errors.Is(err, usecase.ErrOAuthClientNotFound):
+			return nil, status.Errorf(codes.NotFound, "oauth client not found")
+		default:
+			return nil, status.Errorf(codes.Internal, "something went wrong")
+		}
+	}
+
+	return &authpbv1.DeleteOAuthClientResponse{}, nil
+}
+
+func (h *authGRPCHandler) ListOAuthConsents(
+	ctx context.Context,
+	_ *authpbv1.ListOAuthConsentsRequest,
+) (*authpbv1.ListOAuthConsentsResponse, error) {
+	userID, _, err := sessionFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	consents, err := h.oauthClientUsecase.ListOAuthConsents(ctx, userID)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to list oauth consents")
+		return nil, status.Errorf(codes.Internal, "something went wrong")
+	}
+
+	resp := &authpbv1.ListOAuthConsentsResponse{
+		Consents: make([]*authpbv1.OAuthConsent, 0, len(consents)),
+	}
+	for _, details := range consents {
+		resp.Consents = append(resp.Consents, &authpbv1.OAuthConsent{
+			ClientId:   details.Consent.ClientID,
+			ClientName: details.Client.Name,
+			Scopes:     details.Consent.Scopes,
+			CreatedAt:  timestamppb.New(details.Consent.CreatedAt),
+			UpdatedAt:  timestamppb.New(details.Consent.UpdatedAt),
+		})
+	}
+
+	return resp, nil
+}
+
+func (h *authGRPCHandler) RevokeOAuthConsent(
+	ctx context.Context,
+	req *authpbv1.RevokeOAuthConsentRequest,
+) (*authpbv1.RevokeOAuthConsentResponse, error) {
+	if req.GetClientId() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "client id is required")
+	}
+
+	userID, _, err := sessionFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.oauthClientUsecase.RevokeOAuthConsent(
+		ctx,
+		userID,
+		req.GetClientId(),
+		clientInfoFromContext(ctx),
+	); err != nil {
+		h.logger.Error().Err(err).Msg("failed to revoke oauth consent")
+
+		switch {
+		case errors.Is(err, usecase.ErrOAuthConsentNotFound):
+			return nil, status.Errorf(codes.NotFound, "oauth consent not found")
+		default:
+			return nil, status.Errorf(codes.Internal, "something went wrong")
+		}
+	}
+
+	return &authpbv1.RevokeOAuthConsentResponse{}, nil
+}
+
+func oauthClientToProto(client *model.OAuthClient) *authpbv1.OAuthClient {
+	return &authpbv1.OAuthClient{
+		Id:           client.ID.Hex(),
+		Name:         client.Name,
+		RedirectUris: client.RedirectURIs,
+		Scopes:       client.Scopes,
+		Confidential: client.Confidential(),
+		CreatedAt:    timestamppb.New(client.CreatedAt),
+	}
+}
@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/usecase"
+	authpbv1 "github.com/vasapolrittideah/money-tracker-api/shared/protos/auth/v1"
+)
+
+func (h *authGRPCHandler) GetOAuthAuthorizationRequest(
+	ctx context.Context,
+	req *authpbv1.GetOAuthAuthorizationRequestRequest,
+) (*authpbv1.GetOAuthAuthorizationRequestResponse, error) {
+	userID, _, err := sessionFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	prompt, err := h.oauthServerUsecase.GetOAuthAuthorizationRequest(
+		ctx,
+		userID,
+		oauthAuthorizationRequestFromProto(req.GetRequest()),
+	)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to get oauth authorization request")
+		return nil, oauthAuthorizationError(err)
+	}
+
+	return &authpbv1.GetOAuthAuthorizationRequestResponse{
+		ClientId:   prompt.Client.ID.Hex(),
+		ClientName: prompt.Client.Name,
+		Scopes:     prompt.Scopes,
+		Consented:  prompt.Consented,
+	}, nil
+}
+
+func (h *authGRPCHandler) AuthorizeOAuthClient(
+	ctx context.Context,
+	req *authpbv1.AuthorizeOAuthClientRequest,
+) (*authpbv1.AuthorizeOAuthClientResponse, error) {
+	userID, _, err := sessionFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	params := usecase.AuthorizeOAuthClientParams{
+		UserID:   userID,
+		Request:  oauthAuthorizationRequestFromProto(req.GetRequest()),
+		Approved: req.GetApproved(),
+		Client:   clientInfoFromContext(ctx),
+	}
+
+	redirectURI, err := h.oauthServerUsecase.AuthorizeOAuthClient(ctx, params)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to authorize oauth client")
+		return nil, oauthAuthorizationError(err)
+	}
+
+	return &authpbv1.AuthorizeOAuthClientResponse{
+		RedirectUri: redirectURI,
+	}, nil
+}
+
+// ExchangeOAuthToken serves the token endpoint of RFC 6749. The errors a client is expected to handle
+// are part of the protocol, so they are returned in the error field rather than as gRPC errors.
+func (h *authGRPCHandler) ExchangeOAuthToken(
+	ctx context.Context,
+	req *authpbv1.ExchangeOAuthTokenRequest,
+) (*authpbv1.ExchangeOAuthTokenResponse, error) {
+	params := usecase.ExchangeOAuthTokenParams{
+		GrantType:    req.GetGrantType(),
+		ClientID:     req.GetClientId(),
+		ClientSecret: req.GetClientSecret(),
+		Code:         req.GetCode(),
+		RedirectURI:  req.GetRedirectUri(),
+		CodeVerifier: req.GetCodeVerifier(),
+		RefreshToken: req.GetRefreshToken(),
+	}
+
+	tokens, err := h.oauthServerUsecase.ExchangeOAuthToken(ctx, params)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to exchange oauth token")
+
+		resp := &authpbv1.ExchangeOAuthTokenResponse{ErrorDescription: err.Error()}
+		switch {
+		case errors.Is(err, usecase.ErrUnsupportedGrantType):
+			resp.Error = "unsupported_grant_type"
+		case errors.Is(err, usecase.ErrInvalidOAuthClient):
+			resp.Error = "invalid_client"
+		case errors.Is(err, usecase.ErrInvalidOAuthGrant),
+			errors.Is(err, usecase.ErrInvalidRefreshToken),
+			errors.Is(err, usecase.ErrRefreshTokenReused),
+			errors.Is(err, usecase.ErrSessionRevoked):
+			resp.Error = "invalid_grant"
+		default:
+			return nil, status.Errorf(codes.Internal, "something went wrong")
+		}
+
+		return resp, nil
+	}
+
+	return &authpbv1.ExchangeOAuthTokenResponse{
+		AccessToken:  tokens.Tokens.AccessToken,
+		RefreshToken: tokens.Tokens.RefreshToken,
+		ExpiresIn:    int64(tokens.ExpiresIn.Seconds()),
+		Scopes:       tokens.Scopes,
+	}, nil
+}
+
+func (h *authGRPCHandler) RevokeOAuthToken(
+	ctx context.Context,
+	req *authpbv1.RevokeOAuthTokenRequest,
+) (*authpbv1.RevokeOAuthTokenResponse, error) {
+	if req.GetToken() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "token is required")
+	}
+
+	params := usecase.RevokeOAuthTokenParams{
+		ClientID:     req.GetClientId(),
+		ClientSecret: req.GetClientSecret(),
+		Token:        req.GetToken(),
+	}
+
+	if err := h.oauthServerUsecase.RevokeOAuthToken(ctx, params); err != nil {
+		h.logger.Error().Err(err).Msg("failed to revoke oauth token")
+
+		switch {
+		case errors.Is(err, usecase.ErrInvalidOAuthClient):
+			return nil, status.Errorf(codes.Unauthenticated, "invalid client")
+		default:
+			return nil, status.Errorf(codes.Internal, "something went wrong")
+		}
+	}
+
+	return &authpbv1.RevokeOAuthTokenResponse{}, nil
+}
+
+func oauthAuthorizationRequestFromProto(req *authpbv1.OAuthAuthorizationRequest) usecase.OAuthAuthorizationRequest {
+	return usecase.OAuthAuthorizationRequest{
+		ResponseType:        req.GetResponseType(),
+		ClientID:            req.GetClientId(),
+		RedirectURI:         req.GetRedirectUri(),
+		Scope:               req.GetScope(),
+		State:               req.GetState(),
+		CodeChallenge:       req.GetCodeChallenge(),
+		CodeChallengeMethod: req.GetCodeChallengeMethod(),
+	}
+}
+
+// oauthAuthorizationError maps the errors of checking an authorization request to gRPC errors.
+func oauthAuthorizationError(err error) error {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidOAuthClient):
+		return status.Errorf(codes.InvalidArgument, "unknown client")
+	case errors.Is(err, usecase.ErrInvalidRedirectURI):
+		return status.Errorf(codes.InvalidArgument, "redirect uri is not registered for the client")
+	case errors.Is(err, usecase.ErrUnsupportedResponseType):
+		return status.Errorf(codes.InvalidArgument, "response type must be code")
+	case errors.Is(err, usecase.ErrInvalidCodeChallenge):
+		return status.Errorf(codes.InvalidArgument, "code challenge using the S256 method is required")
+	case errors.Is(err, usecase.ErrInvalidScope):
+		return status.Errorf(codes.InvalidArgument, "scope is not allowed for the client")
+	default:
+		return status.Errorf(codes.Internal, "something went wrong")
+	}
+}
@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/model"
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/usecase"
+	authpbv1 "github.com/vasapolrittideah/money-tracker-api/shared/protos/auth/v1"
+)
+
+func (h *authGRPCHandler) BeginPasskeyRegistration(
+	ctx context.Context,
+	req *authpbv1.BeginPasskeyRegistrationRequest,
+) (*authpbv1.BeginPasskeyRegistrationResponse, error) {
+	userID, sessionID, err := sessionFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	params := usecase.BeginPasskeyRegistrationParams{
+		UserID:    userID,
+		SessionID: sessionID,
+		Password:  req.GetPassword(),
+	}
+
+	options, err := h.passkeyUsecase.BeginPasskeyRegistration(ctx, params)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to begin passkey registration")
+
+		switch {
+		case errors.Is(err, usecase.ErrReauthenticationRequired):
+			return nil, status.Errorf(codes.Unauthenticated, "re-authentication is required")
+		case errors.Is(err, usecase.ErrTooManyLoginAttempts):
+			return nil, status.Errorf(codes.ResourceExhausted, "too many login attempts, please try again later")
+		case errors.Is(err, usecase.ErrInvalidCredentials):
+			return nil, status.Errorf(codes.Unauthenticated, "invalid credentials")
+		default:
+			return nil, status.Errorf(codes.Internal, "something went wrong")
+		}
+	}
+
+	return &authpbv1.BeginPasskeyRegistrationResponse{
+		Challenge:            options.Challenge,
+		RpId:                 options.RPID,
+		RpName:               options.RPName,
+		UserHandle:           options.UserHandle,
+		UserName:             options.UserName,
+		ExcludeCredentialIds: options.ExcludeCredentialIDs,
+		TimeoutMs:            options.Timeout.Milliseconds(),
+	}, nil
+}
+
+func (h *authGRPCHandler) FinishPasskeyRegistration(
+	ctx context.Context,
+	req *authpbv1.FinishPasskeyRegistrationRequest,
+) (*authpbv1.FinishPasskeyRegistrationResponse, error) {
+	if len(req.GetClientDataJson()) == 0 || len(req.GetAttestationObject()) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "client data and attestation object are required")
+	}
+
+	userID, _, err := sessionFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	params := usecase.FinishPasskeyRegistrationParams{
+		UserID:            userID,
+		ClientDataJSON:    req.GetClientDataJson(),
+		AttestationObject: req.GetAttestationObject(),
+		Transports:        req.GetTransports(),
+		Name:              req.GetName(),
+	}
+
+	credential, err := h.passkeyUsecase.FinishPasskeyRegistration(ctx, params)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to finish passkey registration")
+
+		switch {
+		case errors.Is(err, usecase.ErrInvalidPasskeyChallenge):
+			return nil, status.Errorf(codes.FailedPrecondition, "invalid or expired passkey challenge")
+		case errors.Is(err, usecase.ErrInvalidPasskey):
+			return nil, status.Errorf(codes.InvalidArgument, "invalid passkey")
+		case errors.Is(err, usecase.ErrPasskeyAlreadyRegistered):
+			return nil, status.Errorf(codes.AlreadyExists, "passkey is already registered")
+		default:
+			return nil, status.Errorf(codes.Internal, "something went wrong")
+		}
+	}
+
+	return &authpbv1.FinishPasskeyRegistrationResponse{
+		Passkey: passkeyToProto(credential),
+	}, nil
+}
+
+func (h *authGRPCHandler) BeginPasskeyLogin(
+	ctx context.Context,
+	req *authpbv1.BeginPasskeyLoginRequest,
+) (*authpbv1.BeginPasskeyLoginResponse, error) {
+	options, err := h.authUsecase.BeginPasskeyLogin(ctx)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to begin passkey login")
+		return nil, status.Errorf(codes.Internal, "something went wrong")
+	}
+
+	return &authpbv1.BeginPasskeyLoginResponse{
+		Challenge: options.Challenge,
+		RpId:      options.RPID,
+		TimeoutMs: options.Timeout.Milliseconds(),
+	}, nil
+}
+
+func (h *authGRPCHandler) FinishPasskeyLogin(
+	ctx context.Context,
+	req *authpbv1.FinishPasskeyLoginRequest,
+) (*authpbv1.FinishPasskeyLoginResponse, error) {
+	if len(req.GetCredentialId()) == 0 || len(req.GetClientDataJson()) == 0 ||
+		len(req.GetAuthenticatorData()) == 0 || len(req.GetSignature()) == 0 {
+		return nil, status.Errorf(
+			codes.InvalidArgument,
+			"credential ID, client data, authenticator data and signature are required",
+		)
+	}
+
+	params := usecase.FinishPasskeyLoginParams{
+		CredentialID:      req.GetCredentialId(),
+		ClientDataJSON:    req.GetClientDataJson(),
+		AuthenticatorData: req.GetAuthenticatorData(),
+		Signature:         req.GetSignature(),
+		UserHandle:        req.GetUserHandle(),
+		Client:            clientInfoFromContext(ctx),
+	}
+
+	tokens, err := h.authUsecase.FinishPasskeyLogin(ctx, params)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to finish passkey login")
+
+		switch {
+		case errors.Is(err, usecase.ErrInvalidPasskeyChallenge):
+			return nil, status.Errorf(codes.Unauthenticated, "invalid or expired passkey challenge")
+		case errors.Is(err, usecase.ErrInvalidPasskey):
+			return nil, status.Errorf(codes.Unauthenticated, "invalid passkey")
+		case errors.Is(err, usecase.ErrEmailNotVerified):
+			return nil, status.Errorf(codes.FailedPrecondition, "email has not been verified")
+		default:
+			return nil, status.Errorf(codes.Internal, "something went wrong")
+		}
+	}
+
+	return &authpbv1.FinishPasskeyLoginResponse{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+	}, nil
+}
+
+func passkeyToProto(credential *model.WebAuthnCredential) *authpbv1.Passkey {
+	passkey := &authpbv1.Passkey{
+		Id:         credential.ID.Hex(),
+		Name:       credential.Name,
+		Transports: credential.Transports,
+		CreatedAt:  timestamppb.New(credential.CreatedAt),
+	}
+	if credential.LastUsedAt != nil {
+		passkey.LastUsedAt = timestamppb.New(*credential.LastUsedAt)
+	}
+
+	return passkey
+}
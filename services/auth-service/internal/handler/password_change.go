@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/usecase"
+	authpbv1 "github.com/vasapolrittideah/money-tracker-api/shared/protos/auth/v1"
+)
+
+func (h *authGRPCHandler) ChangePassword(
+	ctx context.Context,
+	req *authpbv1.ChangePasswordRequest,
+) (*authpbv1.ChangePasswordResponse, error) {
+	if req.GetCurrentPassword() == "" || req.GetNewPassword() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "current and new password are required")
+	}
+
+	userID, sessionID, err := sessionFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	params := usecase.ChangePasswordParams{
+		UserID:          userID,
+		SessionID:       sessionID,
+		CurrentPassword: req.GetCurrentPassword(),
+		NewPassword:     req.GetNewPassword(),
+		Client:          clientInfoFromContext(ctx),
+	}
+
+	if err := h.authUsecase.ChangePassword(ctx, params); err != nil {
+		h.logger.Error().Err(err).Msg("failed to change password")
+
+		switch {
+		case errors.Is(err, usecase.ErrPasswordNotSet):
+			return nil, status.Errorf(codes.FailedPrecondition, "account has no password, use password reset to set one")
+		case errors.Is(err, usecase.ErrInvalidCredentials):
+			return nil, status.Errorf(codes.Unauthenticated, "invalid credentials")
+		case errors.Is(err, usecase.ErrTooManyLoginAttempts):
+			return nil, status.Errorf(codes.ResourceExhausted, "too many login attempts, please try again later")
+		case errors.Is(err, usecase.ErrPasswordPolicyViolation):
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		default:
+			return nil, status.Errorf(codes.Internal, "something went wrong")
+		}
+	}
+
+	return &authpbv1.ChangePasswordResponse{}, nil
+}
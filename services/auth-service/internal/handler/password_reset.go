@@ -22,7 +22,7 @@ func (h *authGRPCHandler) RequestPasswordReset(
 		return nil, status.Errorf(codes.InvalidArgument, "email is required")
 	}
 
-	err := h.passwordResetUsecase.RequestPasswordReset(ctx, email)
+	err := h.passwordResetUsecase.RequestPasswordReset(ctx, email, clientInfoFromContext(ctx))
 	if err != nil {
 		h.logger.Error().Err(err).Msg("failed to request password reset")
 		return nil, status.Errorf(codes.Internal, "something went wrong")
@@ -50,7 +50,7 @@ func (h *authGRPCHandler) ResetPassword(
 		return nil, status.Errorf(codes.Unauthenticated, "invalid JTI claim")
 	}
 
-	err := h.passwordResetUsecase.ResetPassword(ctx, jti, newPassword)
+	err := h.passwordResetUsecase.ResetPassword(ctx, jti, newPassword, clientInfoFromContext(ctx))
 	if err != nil {
 		h.logger.Error().Err(err).Msg("failed to reset password")
 
@@ -61,6 +61,8 @@ func (h *authGRPCHandler) ResetPassword(
 			return nil, status.Errorf(codes.FailedPrecondition, "password reset token has already been used")
 		case errors.Is(err, usecase.ErrTokenExpired):
 			return nil, status.Errorf(codes.Unauthenticated, "password reset token has expired")
+		case errors.Is(err, usecase.ErrPasswordPolicyViolation):
+			return nil, status.Error(codes.InvalidArgument, err.Error())
 		case errors.Is(err, usecase.ErrInvalidToken):
 			return nil, status.Errorf(codes.Unauthenticated, "invalid password reset token")
 		default:
@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/model"
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/usecase"
+	authtypes "github.com/vasapolrittideah/money-tracker-api/services/auth-service/pkg/types"
+	"github.com/vasapolrittideah/money-tracker-api/shared/interceptor"
+	authpbv1 "github.com/vasapolrittideah/money-tracker-api/shared/protos/auth/v1"
+)
+
+// PersonalAccessTokenScopes are the methods personal access tokens may call and the scope each one
+// needs. Scripts can read the account, but managing it takes a signed-in session. The handlers of
+// these methods read the user with userFromContext.
+var PersonalAccessTokenScopes = interceptor.PersonalAccessTokenScopes{
+	authpbv1.AuthService_ListSecurityEvents_FullMethodName: authtypes.ScopeRead,
+	authpbv1.AuthService_ListIdentities_FullMethodName:     authtypes.ScopeRead,
+	authpbv1.AuthService_GetLoginAlerts_FullMethodName:     authtypes.ScopeRead,
+}
+
+func (h *authGRPCHandler) CreatePersonalAccessToken(
+	ctx context.Context,
+	req *authpbv1.CreatePersonalAccessTokenRequest,
+) (*authpbv1.CreatePersonalAccessTokenResponse, error) {
+	if req.GetName() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "name is required")
+	}
+
+	userID, sessionID, err := sessionFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	params := usecase.CreatePersonalAccessTokenParams{
+		UserID:    userID,
+		SessionID: sessionID,
+		Password:  req.GetPassword(),
+		Code:      req.GetCode(),
+		Name:      req.GetName(),
+		Scopes:    req.GetScopes(),
+		Client:    clientInfoFromContext(ctx),
+	}
+	if req.GetExpiresAt() != nil {
+		expiresAt := req.GetExpiresAt().AsTime()
+		params.ExpiresAt = &expiresAt
+	}
+
+	token, tokenStr, err := h.personalAccessTokenUsecase.CreatePersonalAccessToken(ctx, params)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to create personal access token")
+
+		switch {
+		case errors.Is(err, usecase.ErrReauthenticationRequired):
+			return nil, status.Errorf(codes.Unauthenticated, "re-authentication is required")
+		case errors.Is(err, usecase.ErrTooManyLoginAttempts):
+			return nil, status.Errorf(codes.ResourceExhausted, "too many login attempts, please try again later")
+		case errors.Is(err, usecase.ErrInvalidCredentials):
+			return nil, status.Errorf(codes.Unauthenticated, "invalid credentials")
+		case errors.Is(err, usecase.ErrInvalidMFACode):
+			return nil, status.Errorf(codes.Unauthenticated, "invalid MFA code")
+		case errors.Is(err, usecase.ErrTooManyMFAAttempts):
+			return nil, status.Errorf(codes.ResourceExhausted, "too many MFA attempts")
+		case errors.Is(err, usecase.ErrInvalidScope):
+			return nil, status.Errorf(codes.InvalidArgument, "scopes must be one or more of the supported scopes")
+		case errors.Is(err, usecase.ErrInvalidExpiry):
+			return nil, status.Errorf(codes.InvalidArgument, "expiry must be in the future")
+		case errors.Is(err, usecase.ErrTooManyPersonalAccessTokens):
+			return nil, status.Errorf(codes.ResourceExhausted, "too many personal access tokens")
+		default:
+			return nil, status.Errorf(codes.Internal, "something went wrong")
+		}
+	}
+
+	return &authpbv1.CreatePersonalAccessTokenResponse{
+		PersonalAccessToken: personalAccessTokenToProto(token),
+		Token:               tokenStr,
+	}, nil
+}
+
+func (h *authGRPCHandler) ListPersonalAccessTokens(
+	ctx context.Context,
+	_ *authpbv1.ListPersonalAccessTokensRequest,
+) (*authpbv1.ListPersonalAccessTokensResponse, error) {
+	userID, _, err := sessionFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := h.personalAccessTokenUsecase.ListPersonalAccessTokens(ctx, userID)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to list personal access tokens")
+		return nil, status.Errorf(codes.Internal, "something went wrong")
+	}
+
+	resp := &authpbv1.ListPersonalAccessTokensResponse{
+		Tokens: make([]*authpbv1.PersonalAccessToken, 0, len(tokens)),
+	}
+	for _, token := range tokens {
+		resp.Tokens = append(resp.Tokens, personalAccessTokenToProto(token))
+	}
+
+	return resp, nil
+}
+
+func (h *authGRPCHandler) RevokePersonalAccessToken(
+	ctx context.Context,
+	req *authpbv1.RevokePersonalAccessTokenRequest,
+) (*authpbv1.RevokePersonalAccessTokenResponse, error) {
+	if req.GetId() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "id is required")
+	}
+
+	userID, _, err := sessionFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.personalAccessTokenUsecase.RevokePersonalAccessToken(
+		ctx,
+		userID,
+		req.GetId(),
+		clientInfoFromContext(ctx),
+	); err != nil {
+		h.logger.Error().Err(err).Msg("failed to revoke personal access token")
+
+		switch {
+		case errors.Is(err, usecase.ErrPersonalAccessTokenNotFound):
+			return nil, status.Errorf(codes.NotFound, "personal access token not found")
+		default:
+			return nil, status.Errorf(codes.Internal, "something went wrong")
+		}
+	}
+
+	return &authpbv1.RevokePersonalAccessTokenResponse{}, nil
+}
+
+func personalAccessTokenToProto(token *model.PersonalAccessToken) *authpbv1.PersonalAccessToken {
+	pat := &authpbv1.PersonalAccessToken{
+		Id:        token.ID.Hex(),
+		Name:      token.Name,
+		Scopes:    token.Scopes,
+		CreatedAt: timestamppb.New(token.CreatedAt),
+	}
+	if token.ExpiresAt != nil {
+		pat.ExpiresAt = timestamppb.New(*token.ExpiresAt)
+	}
+	if token.LastUsedAt != nil {
+		pat.LastUsedAt = timestamppb.New(*token.LastUsedAt)
+	}
+
+	return pat
+}
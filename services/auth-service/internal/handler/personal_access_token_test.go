@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/model"
+	"github.com/vasapolrittideah/money-tracker-api/shared/interceptor"
+	authpbv1 "github.com/vasapolrittideah/money-tracker-api/shared/protos/auth/v1"
+)
+
+// newTestPersonalAccessToken returns a token of the user with the given scopes, stored the way the
+// personal access token usecase stores it.
+func newTestPersonalAccessToken(
+	repo *fakePersonalAccessTokenRepository,
+	userID string,
+	scopes ...string,
+) string {
+	token := interceptor.PersonalAccessTokenPrefix + bson.NewObjectID().Hex()
+	hash := sha256.Sum256([]byte(token))
+
+	repo.tokens = append(repo.tokens, &model.PersonalAccessToken{
+		ID:        bson.NewObjectID(),
+		UserID:    userID,
+		Name:      "test",
+		TokenHash: hex.EncodeToString(hash[:]),
+		Scopes:    scopes,
+		CreatedAt: time.Now(),
+	})
+
+	return token
+}
+
+func newTestRepositories(userID bson.ObjectID) testRepositories {
+	return testRepositories{
+		users:                &fakeUserRepository{users: []*model.User{{ID: userID, Verified: true}}},
+		personalAccessTokens: &fakePersonalAccessTokenRepository{},
+		authEvents:           &fakeAuthEventRepository{},
+	}
+}
+
+func TestPersonalAccessTokenCanListSecurityEvents(t *testing.T) {
+	userID := bson.NewObjectID()
+	repos := newTestRepositories(userID)
+	for _, eventUserID := range []string{userID.Hex(), bson.NewObjectID().Hex()} {
+		repos.authEvents.events = append(repos.authEvents.events, &model.AuthEvent{
+			ID:      bson.NewObjectID(),
+			UserID:  eventUserID,
+			Type:    model.AuthEventLogin,
+			Outcome: model.AuthEventOutcomeSuccess,
+		})
+	}
+	token := newTestPersonalAccessToken(repos.personalAccessTokens, userID.Hex(), "read")
+	client := startTestServer(t, repos)
+
+	resp, err := client.ListSecurityEvents(withBearerToken(token), &authpbv1.ListSecurityEventsRequest{})
+	if err != nil {
+		t.Fatalf("ListSecurityEvents() error = %v", err)
+	}
+	if len(resp.GetEvents()) != 1 || resp.GetEvents()[0].GetId() != repos.authEvents.events[0].ID.Hex() {
+		t.Fatalf("ListSecurityEvents() events = %v, want only the event of the token's user", resp.GetEvents())
+	}
+
+	if repos.personalAccessTokens.tokens[0].LastUsedAt == nil {
+		t.Error("the use of the token was not recorded")
+	}
+}
+
+func TestPersonalAccessTokenCannotManageTheAccount(t *testing.T) {
+	userID := bson.NewObjectID()
+	repos := newTestRepositories(userID)
+	token := newTestPersonalAccessToken(repos.personalAccessTokens, userID.Hex(), "read", "write")
+	client := startTestServer(t, repos)
+
+	_, err := client.ListPersonalAccessTokens(withBearerToken(token), &authpbv1.ListPersonalAccessTokensRequest{})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("ListPersonalAccessTokens() error = %v, want code %v", err, codes.PermissionDenied)
+	}
+}
+
+func TestPersonalAccessTokenNeedsTheScopeOfTheMethod(t *testing.T) {
+	userID := bson.NewObjectID()
+	repos := newTestRepositories(userID)
+	token := newTestPersonalAccessToken(repos.personalAccessTokens, userID.Hex(), "write")
+	client := startTestServer(t, repos)
+
+	_, err := client.ListSecurityEvents(withBearerToken(token), &authpbv1.ListSecurityEventsRequest{})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("ListSecurityEvents() error = %v, want code %v", err, codes.PermissionDenied)
+	}
+}
+
+func TestUnknownPersonalAccessTokenIsRejected(t *testing.T) {
+	userID := bson.NewObjectID()
+	repos := newTestRepositories(userID)
+	client := startTestServer(t, repos)
+
+	_, err := client.ListSecurityEvents(withBearerToken("pat_unknown"), &authpbv1.ListSecurityEventsRequest{})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("ListSecurityEvents() error = %v, want code %v", err, codes.Unauthenticated)
+	}
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/model"
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/repository"
+)
+
+// The fakes below keep their records in memory and follow the documented behaviour of the MongoDB
+// repositories. Each embeds its repository interface, so calling a method a test does not expect
+// panics instead of silently doing nothing.
+
+type fakeUserRepository struct {
+	repository.UserRepository
+
+	mu    sync.Mutex
+	users []*model.User
+}
+
+func (r *fakeUserRepository) GetUser(_ context.Context, id string) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, user := range r.users {
+		if user.ID.Hex() == id {
+			userCopy := *user
+			return &userCopy, nil
+		}
+	}
+
+	return nil, mongo.ErrNoDocuments
+}
+
+type fakePersonalAccessTokenRepository struct {
+	repository.PersonalAccessTokenRepository
+
+	mu     sync.Mutex
+	tokens []*model.PersonalAccessToken
+}
+
+func (r *fakePersonalAccessTokenRepository) GetPersonalAccessTokenByHash(
+	_ context.Context,
+	tokenHash string,
+) (*model.PersonalAccessToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.tokens {
+		if token.TokenHash == tokenHash && (token.ExpiresAt == nil || token.ExpiresAt.After(time.Now())) {
+			tokenCopy := *token
+			return &tokenCopy, nil
+		}
+	}
+
+	return nil, mongo.ErrNoDocuments
+}
+
+func (r *fakePersonalAccessTokenRepository) UpdateLastUsed(_ context.Context, id string, usedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.tokens {
+		if token.ID.Hex() == id {
+			token.LastUsedAt = &usedAt
+		}
+	}
+
+	return nil
+}
+
+type fakeAuthEventRepository struct {
+	repository.AuthEventRepository
+
+	mu     sync.Mutex
+	events []*model.AuthEvent
+}
+
+func (r *fakeAuthEventRepository) CreateAuthEvent(_ context.Context, event *model.AuthEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if event.ID.IsZero() {
+		event.ID = bson.NewObjectID()
+	}
+	r.events = append(r.events, event)
+
+	return nil
+}
+
+func (r *fakeAuthEventRepository) ListAuthEventsByUserID(
+	_ context.Context,
+	userID, beforeID string,
+	limit int64,
+) ([]*model.AuthEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var events []*model.AuthEvent
+	for _, event := range slices.Backward(r.events) {
+		if event.UserID != userID || (beforeID != "" && event.ID.Hex() >= beforeID) {
+			continue
+		}
+		events = append(events, event)
+		if int64(len(events)) == limit {
+			break
+		}
+	}
+
+	return events, nil
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/usecase"
+	authpbv1 "github.com/vasapolrittideah/money-tracker-api/shared/protos/auth/v1"
+)
+
+// SetUserRoles is only reachable with the users:write permission, which the authorization
+// interceptor checks.
+func (h *authGRPCHandler) SetUserRoles(
+	ctx context.Context,
+	req *authpbv1.SetUserRolesRequest,
+) (*authpbv1.SetUserRolesResponse, error) {
+	if req.GetUserId() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "user_id is required")
+	}
+
+	actorID, _, err := sessionFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := h.roleUsecase.SetUserRoles(ctx, usecase.SetUserRolesParams{
+		ActorID:     actorID,
+		UserID:      req.GetUserId(),
+		Roles:       req.GetRoles(),
+		Permissions: req.GetPermissions(),
+		Client:      clientInfoFromContext(ctx),
+	})
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to set user roles")
+
+		switch {
+		case errors.Is(err, usecase.ErrUserNotFound):
+			return nil, status.Errorf(codes.NotFound, "user not found")
+		case errors.Is(err, usecase.ErrUnknownRole):
+			return nil, status.Errorf(codes.InvalidArgument, "unknown role")
+		case errors.Is(err, usecase.ErrUnknownPermission):
+			return nil, status.Errorf(codes.InvalidArgument, "unknown permission")
+		default:
+			return nil, status.Errorf(codes.Internal, "something went wrong")
+		}
+	}
+
+	return &authpbv1.SetUserRolesResponse{
+		UserId:      user.ID.Hex(),
+		Roles:       user.Roles,
+		Permissions: user.Permissions,
+	}, nil
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/usecase"
+	authtypes "github.com/vasapolrittideah/money-tracker-api/services/auth-service/pkg/types"
+	"github.com/vasapolrittideah/money-tracker-api/shared/interceptor"
+	authpbv1 "github.com/vasapolrittideah/money-tracker-api/shared/protos/auth/v1"
+)
+
+func (h *authGRPCHandler) ListSecurityEvents(
+	ctx context.Context,
+	req *authpbv1.ListSecurityEventsRequest,
+) (*authpbv1.ListSecurityEventsResponse, error) {
+	userID, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Users review their own account; support staff can look into the accounts of others
+	if req.GetUserId() != "" && req.GetUserId() != userID {
+		if !interceptor.HasPermission(ctx, authtypes.PermissionUsersRead) {
+			return nil, status.Errorf(codes.PermissionDenied, "not allowed to list the events of another user")
+		}
+		userID = req.GetUserId()
+	}
+
+	params := usecase.ListSecurityEventsParams{
+		UserID:    userID,
+		PageSize:  int64(req.GetPageSize()),
+		PageToken: req.GetPageToken(),
+	}
+
+	events, nextPageToken, err := h.securityEventUsecase.ListSecurityEvents(ctx, params)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to list security events")
+
+		switch {
+		case errors.Is(err, usecase.ErrInvalidPageToken):
+			return nil, status.Errorf(codes.InvalidArgument, "invalid page token")
+		default:
+			return nil, status.Errorf(codes.Internal, "something went wrong")
+		}
+	}
+
+	resp := &authpbv1.ListSecurityEventsResponse{
+		Events:        make([]*authpbv1.SecurityEvent, 0, len(events)),
+		NextPageToken: nextPageToken,
+	}
+	for _, event := range events {
+		resp.Events = append(resp.Events, &authpbv1.SecurityEvent{
+			Id:        event.ID.Hex(),
+			Type:      event.Type,
+			Outcome:   event.Outcome,
+			Reason:    event.Reason,
+			Method:    event.Method,
+			IpAddress: event.IPAddress,
+			UserAgent: event.UserAgent,
+			CreatedAt: timestamppb.New(event.CreatedAt),
+		})
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/model"
+	authtypes "github.com/vasapolrittideah/money-tracker-api/services/auth-service/pkg/types"
+	authpbv1 "github.com/vasapolrittideah/money-tracker-api/shared/protos/auth/v1"
+)
+
+// newTestSecurityEvents adds an event for each of the users, in order, and returns them.
+func newTestSecurityEvents(repo *fakeAuthEventRepository, userIDs ...string) []*model.AuthEvent {
+	events := make([]*model.AuthEvent, 0, len(userIDs))
+	for _, userID := range userIDs {
+		event := &model.AuthEvent{
+			ID:      bson.NewObjectID(),
+			UserID:  userID,
+			Type:    model.AuthEventLogin,
+			Outcome: model.AuthEventOutcomeSuccess,
+		}
+		repo.events = append(repo.events, event)
+		events = append(events, event)
+	}
+
+	return events
+}
+
+func TestListSecurityEventsOfAnotherUserNeedsPermission(t *testing.T) {
+	userID, otherUserID := bson.NewObjectID().Hex(), bson.NewObjectID().Hex()
+	repos := newTestRepositories(bson.NewObjectID())
+	newTestSecurityEvents(repos.authEvents, userID, otherUserID)
+	client := startTestServer(t, repos)
+
+	_, err := client.ListSecurityEvents(
+		withBearerToken(newTestAccessToken(t, userID)),
+		&authpbv1.ListSecurityEventsRequest{UserId: otherUserID},
+	)
+	if status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("ListSecurityEvents() error = %v, want code %v", err, codes.PermissionDenied)
+	}
+
+	resp, err := client.ListSecurityEvents(
+		withBearerToken(newTestAccessToken(t, userID, authtypes.PermissionUsersRead)),
+		&authpbv1.ListSecurityEventsRequest{UserId: otherUserID},
+	)
+	if err != nil {
+		t.Fatalf("ListSecurityEvents() with %s error = %v", authtypes.PermissionUsersRead, err)
+	}
+	if len(resp.GetEvents()) != 1 {
+		t.Fatalf("ListSecurityEvents() with %s events = %v, want 1 event", authtypes.PermissionUsersRead, resp.GetEvents())
+	}
+}
+
+func TestListSecurityEventsPageTokenOfAnotherUser(t *testing.T) {
+	userID, otherUserID := bson.NewObjectID().Hex(), bson.NewObjectID().Hex()
+	repos := newTestRepositories(bson.NewObjectID())
+	events := newTestSecurityEvents(repos.authEvents, otherUserID, userID, otherUserID)
+	client := startTestServer(t, repos)
+
+	// The newest event of the other user as the page token only marks where to start in the caller's log
+	resp, err := client.ListSecurityEvents(
+		withBearerToken(newTestAccessToken(t, userID)),
+		&authpbv1.ListSecurityEventsRequest{PageToken: events[2].ID.Hex()},
+	)
+	if err != nil {
+		t.Fatalf("ListSecurityEvents() error = %v", err)
+	}
+	if len(resp.GetEvents()) != 1 || resp.GetEvents()[0].GetId() != events[1].ID.Hex() {
+		t.Fatalf("ListSecurityEvents() events = %v, want only the event of the caller", resp.GetEvents())
+	}
+}
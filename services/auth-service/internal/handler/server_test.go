@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rs/zerolog"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/config"
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/usecase"
+	"github.com/vasapolrittideah/money-tracker-api/shared/auth"
+	"github.com/vasapolrittideah/money-tracker-api/shared/interceptor"
+	authpbv1 "github.com/vasapolrittideah/money-tracker-api/shared/protos/auth/v1"
+)
+
+const (
+	testTokenIssuer       = "money-tracker"
+	testAccessTokenSecret = "access-token-secret"
+)
+
+// testRepositories are the repositories behind the usecases of a test server.
+type testRepositories struct {
+	users                *fakeUserRepository
+	personalAccessTokens *fakePersonalAccessTokenRepository
+	authEvents           *fakeAuthEventRepository
+}
+
+// startTestServer serves the auth service over an in-memory connection, authenticating calls with
+// the same interceptors as the service, and returns a client for it. Access tokens are signed with
+// testAccessTokenSecret.
+func startTestServer(t *testing.T, repos testRepositories) authpbv1.AuthServiceClient {
+	t.Helper()
+
+	logger := zerolog.Nop()
+	authServiceCfg := &config.AuthServiceConfig{
+		PersonalAccessToken: config.PersonalAccessTokenConfig{Scopes: []string{"read", "write"}},
+	}
+	authEvents := usecase.NewAuthEventRecorder(&logger, repos.authEvents, authServiceCfg)
+	personalAccessTokenUsecase := usecase.NewPersonalAccessTokenUsecase(
+		repos.users,
+		repos.personalAccessTokens,
+		nil,
+		authEvents,
+		authServiceCfg,
+	)
+
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			interceptor.NewJWTInterceptor(
+				auth.NewJWTAuthenticator(testTokenIssuer, testTokenIssuer),
+				auth.Secret(testAccessTokenSecret),
+				nil,
+				nil,
+				personalAccessTokenUsecase,
+			),
+			interceptor.NewPersonalAccessTokenInterceptor(PersonalAccessTokenScopes),
+		),
+	)
+	NewAuthGRPCHandler(
+		server,
+		&logger,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		usecase.NewSecurityEventUsecase(repos.authEvents),
+		nil,
+		personalAccessTokenUsecase,
+		nil,
+		nil,
+	)
+
+	lis := bufconn.Listen(1 << 20)
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient(
+		"passthrough:///auth-service",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return authpbv1.NewAuthServiceClient(conn)
+}
+
+// withBearerToken returns a context that sends token the way the API gateway forwards it.
+func withBearerToken(token string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
+
+// newTestAccessToken returns an access token for a session of the user with the given permissions.
+// The session is not stored; the test server does not check sessions.
+func newTestAccessToken(t *testing.T, userID string, permissions ...string) string {
+	t.Helper()
+
+	jwtAuth := auth.NewJWTAuthenticator(testTokenIssuer, testTokenIssuer)
+	token, err := jwtAuth.GenerateToken(jwt.MapClaims{
+		"iss":            testTokenIssuer,
+		"aud":            testTokenIssuer,
+		"exp":            time.Now().Add(time.Hour).Unix(),
+		"user_id":        userID,
+		"session_id":     bson.NewObjectID().Hex(),
+		"email_verified": true,
+		"permissions":    permissions,
+	}, testAccessTokenSecret)
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+
+	return token
+}
@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/usecase"
+	"github.com/vasapolrittideah/money-tracker-api/shared/interceptor"
+	authpbv1 "github.com/vasapolrittideah/money-tracker-api/shared/protos/auth/v1"
+	"github.com/vasapolrittideah/money-tracker-api/shared/utilities"
+)
+
+func (h *authGRPCHandler) ListSessions(
+	ctx context.Context,
+	_ *authpbv1.ListSessionsRequest,
+) (*authpbv1.ListSessionsResponse, error) {
+	userID, currentSessionID, err := sessionFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := h.sessionUsecase.ListSessions(ctx, userID)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to list sessions")
+		return nil, status.Errorf(codes.Internal, "something went wrong")
+	}
+
+	resp := &authpbv1.ListSessionsResponse{
+		Sessions: make([]*authpbv1.Session, 0, len(sessions)),
+	}
+	for _, session := range sessions {
+		pbSession := &authpbv1.Session{
+			Id:           session.ID.Hex(),
+			Current:      session.ID.Hex() == currentSessionID,
+			CreatedAt:    timestamppb.New(session.CreatedAt),
+			LastActiveAt: timestamppb.New(session.UpdatedAt),
+		}
+		if session.IPAddress != nil {
+			pbSession.IpAddress = *session.IPAddress
+		}
+		if session.UserAgent != nil {
+			pbSession.UserAgent = *session.UserAgent
+		}
+
+		resp.Sessions = append(resp.Sessions, pbSession)
+	}
+
+	return resp, nil
+}
+
+func (h *authGRPCHandler) RevokeSession(
+	ctx context.Context,
+	req *authpbv1.RevokeSessionRequest,
+) (*authpbv1.RevokeSessionResponse, error) {
+	sessionID := req.GetSessionId()
+	if sessionID == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "session ID is required")
+	}
+
+	userID, _, err := sessionFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.sessionUsecase.RevokeSession(ctx, userID, sessionID); err != nil {
+		h.logger.Error().Err(err).Msg("failed to revoke session")
+
+		switch {
+		case errors.Is(err, usecase.ErrSessionNotFound):
+			return nil, status.Errorf(codes.NotFound, "session not found")
+		default:
+			return nil, status.Errorf(codes.Internal, "something went wrong")
+		}
+	}
+
+	return &authpbv1.RevokeSessionResponse{}, nil
+}
+
+func (h *authGRPCHandler) Logout(ctx context.Context, _ *authpbv1.LogoutRequest) (*authpbv1.LogoutResponse, error) {
+	userID, sessionID, err := sessionFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.sessionUsecase.Logout(ctx, userID, sessionID); err != nil {
+		h.logger.Error().Err(err).Msg("failed to logout")
+
+		switch {
+		case errors.Is(err, usecase.ErrSessionNotFound):
+			return nil, status.Errorf(codes.NotFound, "session not found")
+		default:
+			return nil, status.Errorf(codes.Internal, "something went wrong")
+		}
+	}
+
+	return &authpbv1.LogoutResponse{}, nil
+}
+
+func (h *authGRPCHandler) LogoutAll(
+	ctx context.Context,
+	_ *authpbv1.LogoutAllRequest,
+) (*authpbv1.LogoutAllResponse, error) {
+	userID, _, err := sessionFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.sessionUsecase.LogoutAll(ctx, userID); err != nil {
+		h.logger.Error().Err(err).Msg("failed to logout from all sessions")
+		return nil, status.Errorf(codes.Internal, "something went wrong")
+	}
+
+	return &authpbv1.LogoutAllResponse{}, nil
+}
+
+// sessionFromContext returns the user and session IDs from the access token claims
+// placed in the context by the JWT interceptor.
+func sessionFromContext(ctx context.Context) (string, string, error) {
+	claims, ok := ctx.Value(interceptor.UserClaimsKey).(jwt.MapClaims)
+	if !ok {
+		return "", "", status.Errorf(codes.Unauthenticated, "invalid access token claims")
+	}
+
+	userID, ok := claims["user_id"].(string)
+	if !ok || userID == "" {
+		return "", "", status.Errorf(codes.Unauthenticated, "invalid user ID claim")
+	}
+
+	sessionID, ok := claims["session_id"].(string)
+	if !ok || sessionID == "" {
+		return "", "", status.Errorf(codes.Unauthenticated, "invalid session ID claim")
+	}
+
+	// Scoped tokens are issued to third-party apps, which must not manage the account they were let into
+	if scope, _ := claims["scope"].(string); scope != "" {
+		return "", "", status.Errorf(codes.PermissionDenied, "scoped tokens cannot manage the account")
+	}
+
+	return userID, sessionID, nil
+}
+
+// userFromContext returns the user ID from the claims placed in the context by the JWT interceptor.
+// Unlike sessionFromContext it also accepts personal access tokens, which the personal access token
+// interceptor only lets through to the methods they may call.
+func userFromContext(ctx context.Context) (string, error) {
+	if !interceptor.IsPersonalAccessToken(ctx) {
+		userID, _, err := sessionFromContext(ctx)
+		return userID, err
+	}
+
+	claims, _ := ctx.Value(interceptor.UserClaimsKey).(jwt.MapClaims)
+	userID, ok := claims["user_id"].(string)
+	if !ok || userID == "" {
+		return "", status.Errorf(codes.Unauthenticated, "invalid user ID claim")
+	}
+
+	return userID, nil
+}
+
+// clientInfoFromContext returns the client IP address, user agent and device ID forwarded by the
+// API gateway.
+func clientInfoFromContext(ctx context.Context) usecase.ClientInfo {
+	ipAddress, userAgent := utilities.ClientInfoFromIncomingContext(ctx)
+
+	return usecase.ClientInfo{
+		IPAddress: ipAddress,
+		UserAgent: userAgent,
+		DeviceID:  utilities.ClientDeviceIDFromIncomingContext(ctx),
+	}
+}
@@ -0,0 +1,66 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Types of authentication events.
+const (
+	AuthEventLogin                     = "login"
+	AuthEventMFAVerification           = "mfa_verification"
+	AuthEventRegister                  = "register"
+	AuthEventIdentityLink              = "identity_link"
+	AuthEventRefreshTokenReuse         = "refresh_token_reuse"
+	AuthEventPasswordChange            = "password_change"
+	AuthEventPasswordResetRequest      = "password_reset_request"
+	AuthEventPasswordReset             = "password_reset"
+	AuthEventEmailChangeRequest        = "email_change_request"
+	AuthEventEmailChange               = "email_change"
+	AuthEventEmailChangeCancel         = "email_change_cancel"
+	AuthEventAccountDeletion           = "account_deletion"
+	AuthEventAccountRestore            = "account_restore"
+	AuthEventLoginReport               = "login_report"
+	AuthEventMagicLinkRequest          = "magic_link_request"
+	AuthEventPersonalAccessTokenCreate = "personal_access_token_create"
+	AuthEventPersonalAccessTokenRevoke = "personal_access_token_revoke"
+	AuthEventOAuthAuthorization        = "oauth_authorization"
+	AuthEventOAuthConsentRevoke        = "oauth_consent_revoke"
+	AuthEventRolesUpdate               = "roles_update"
+)
+
+// Outcomes of authentication events.
+const (
+	AuthEventOutcomeSuccess     = "success"
+	AuthEventOutcomeFailure     = "failure"
+	AuthEventOutcomeMFARequired = "mfa_required"
+)
+
+// Methods of authentication that are not identity providers.
+const (
+	AuthMethodPassword  = "password"
+	AuthMethodPasskey   = "passkey"
+	AuthMethodMagicLink = "magic_link"
+)
+
+// AuthEvent is an entry of the security audit log. Entries are only ever added, and are removed
+// once ExpiresAt passes. UserID is empty when the request could not be attributed to an account, in
+// which case Email holds the address that was tried. Method is how the user authenticated, such as
+// "password" or the name of an identity provider, and Reason explains a failure. A login whose first
+// factor was accepted has the mfa_required outcome until a matching mfa_verification event follows.
+// ActorID is set when another user, such as an administrator, made the change to the account.
+type AuthEvent struct {
+	ID        bson.ObjectID `bson:"_id,omitempty"`
+	Type      string        `bson:"type"`
+	UserID    string        `bson:"user_id,omitempty"`
+	Email     string        `bson:"email,omitempty"`
+	ActorID   string        `bson:"actor_id,omitempty"`
+	Method    string        `bson:"method,omitempty"`
+	IPAddress string        `bson:"ip_address,omitempty"`
+	UserAgent string        `bson:"user_agent,omitempty"`
+	Outcome   string        `bson:"outcome"`
+	Reason    string        `bson:"reason,omitempty"`
+	ExpiresAt time.Time     `bson:"expires_at"`
+	CreatedAt time.Time     `bson:"created_at"`
+}
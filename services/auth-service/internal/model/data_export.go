@@ -0,0 +1,19 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// DataExport is the archive of the personal data stored about a user, kept until ExpiresAt for the
+// user to download. The download token is emailed to the user; only its SHA-256 hash is stored.
+// A user has at most one export.
+type DataExport struct {
+	ID        bson.ObjectID `bson:"_id,omitempty"`
+	UserID    string        `bson:"user_id"`
+	TokenHash string        `bson:"token_hash"`
+	Archive   []byte        `bson:"archive"`
+	ExpiresAt time.Time     `bson:"expires_at"`
+	CreatedAt time.Time     `bson:"created_at"`
+}
@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// EmailChange is a pending change of a user's email address. The confirmation token is emailed to
+// the new address and the cancellation token to the old one; only their SHA-256 hashes are stored.
+// A user has at most one pending change.
+type EmailChange struct {
+	ID               bson.ObjectID `bson:"_id,omitempty"`
+	UserID           string        `bson:"user_id"`
+	OldEmail         string        `bson:"old_email"`
+	NewEmail         string        `bson:"new_email"`
+	ConfirmTokenHash string        `bson:"confirm_token_hash"`
+	CancelTokenHash  string        `bson:"cancel_token_hash"`
+	ExpiresAt        time.Time     `bson:"expires_at"`
+	CreatedAt        time.Time     `bson:"created_at"`
+}
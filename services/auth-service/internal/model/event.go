@@ -0,0 +1,17 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Event is a message for other services, stored in the events collection in the same transaction
+// as the change it describes. Consumers watch the collection with a change stream and resume from
+// the last event they handled. Payload is one of the event types in the shared contract package.
+type Event struct {
+	ID        bson.ObjectID `bson:"_id,omitempty"`
+	Type      string        `bson:"type"`
+	Payload   any           `bson:"payload"`
+	CreatedAt time.Time     `bson:"created_at"`
+}
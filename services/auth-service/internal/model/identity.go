@@ -6,6 +6,12 @@ import (
 	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
+// Identity providers a user can authenticate with.
+const (
+	IdentityProviderEmail  = "email"
+	IdentityProviderGoogle = "google"
+)
+
 // Identity represents a user's identity in the authentication system.
 // It stores the mapping between a user and their identities from both external
 // providers (Google, Facebook, etc.) and local authentication (email and password).
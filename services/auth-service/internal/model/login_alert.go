@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Rules for when a user is emailed about a new sign-in to their account.
+const (
+	// LoginAlertsNewDevice emails the user when a login comes from a device, recognized by its device
+	// ID cookie, never seen on the account. It applies when the user has not chosen a rule.
+	LoginAlertsNewDevice = "new_device"
+	LoginAlertsAlways    = "always"
+	LoginAlertsOff       = "off"
+)
+
+// LoginAlert is the "this wasn't me" link of a sign-in the user was emailed about. TokenHash holds
+// the SHA-256 hash of the token in the link, never the token itself.
+type LoginAlert struct {
+	ID        bson.ObjectID `bson:"_id,omitempty"`
+	UserID    string        `bson:"user_id"`
+	SessionID string        `bson:"session_id"`
+	TokenHash string        `bson:"token_hash"`
+	ExpiresAt time.Time     `bson:"expires_at"`
+	CreatedAt time.Time     `bson:"created_at"`
+}
@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// LoginAttempt tracks the recent failed logins of one account or one client IP address, or the
+// failed second factor checks of one signed in user. Key is "account:<email>", "ip:<address>" or
+// "mfa:<user ID>". The record is forgotten once ExpiresAt
+// passes without another failure or its lockout expires, and LockedUntil is only set while logins
+// are locked out.
+type LoginAttempt struct {
+	ID            bson.ObjectID `bson:"_id,omitempty"`
+	Key           string        `bson:"key"`
+	Failures      int           `bson:"failures"`
+	LastFailureAt time.Time     `bson:"last_failure_at"`
+	LockedUntil   *time.Time    `bson:"locked_until,omitempty"`
+	ExpiresAt     time.Time     `bson:"expires_at"`
+	CreatedAt     time.Time     `bson:"created_at"`
+}
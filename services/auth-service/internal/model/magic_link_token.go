@@ -0,0 +1,19 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// MagicLinkToken represents an emailed sign-in link with JTI (JWT Token Identifier).
+type MagicLinkToken struct {
+	ID        bson.ObjectID `bson:"_id,omitempty"`
+	UserID    string        `bson:"user_id"`
+	JTI       string        `bson:"jti"`
+	Email     string        `bson:"email"`
+	Used      bool          `bson:"used"`
+	ExpiresAt time.Time     `bson:"expires_at"`
+	CreatedAt time.Time     `bson:"created_at"`
+	UpdatedAt time.Time     `bson:"updated_at"`
+}
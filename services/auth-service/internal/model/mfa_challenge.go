@@ -0,0 +1,18 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// MFAChallenge represents a login that passed the first factor and waits for a second one.
+// It is identified by the JTI of the challenge token handed to the client.
+type MFAChallenge struct {
+	ID        bson.ObjectID `bson:"_id,omitempty"`
+	JTI       string        `bson:"jti"`
+	UserID    string        `bson:"user_id"`
+	Attempts  int           `bson:"attempts"`
+	ExpiresAt time.Time     `bson:"expires_at"`
+	CreatedAt time.Time     `bson:"created_at"`
+}
@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// OAuthAuthorizationCode is a single-use code a third-party app exchanges for tokens once the user
+// has allowed it access. CodeHash holds the SHA-256 hash of the code, and CodeChallenge the PKCE
+// challenge the app has to answer with its code verifier.
+type OAuthAuthorizationCode struct {
+	ID            bson.ObjectID `bson:"_id,omitempty"`
+	CodeHash      string        `bson:"code_hash"`
+	ClientID      string        `bson:"client_id"`
+	UserID        string        `bson:"user_id"`
+	RedirectURI   string        `bson:"redirect_uri"`
+	Scopes        []string      `bson:"scopes"`
+	CodeChallenge string        `bson:"code_challenge"`
+	ExpiresAt     time.Time     `bson:"expires_at"`
+	CreatedAt     time.Time     `bson:"created_at"`
+}
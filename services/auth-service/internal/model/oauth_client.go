@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// OAuthClient is a third-party app that can ask users for access to their account. Its ID is the
+// client_id of the app. UserID is the developer who registered it. SecretHash holds the SHA-256
+// hash of the client secret of confidential clients and is empty for public clients, such as
+// mobile and single-page apps, which rely on PKCE alone.
+type OAuthClient struct {
+	ID           bson.ObjectID `bson:"_id,omitempty"`
+	UserID       string        `bson:"user_id"`
+	Name         string        `bson:"name"`
+	SecretHash   string        `bson:"secret_hash,omitempty"`
+	RedirectURIs []string      `bson:"redirect_uris"`
+	Scopes       []string      `bson:"scopes"`
+	CreatedAt    time.Time     `bson:"created_at"`
+}
+
+// Confidential reports whether the client has to authenticate with its secret.
+func (c *OAuthClient) Confidential() bool {
+	return c.SecretHash != ""
+}
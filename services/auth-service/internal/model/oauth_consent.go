@@ -0,0 +1,18 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// OAuthConsent records the scopes a user has allowed a third-party app to access. A user has at
+// most one consent per app, which grows as the app is granted further scopes.
+type OAuthConsent struct {
+	ID        bson.ObjectID `bson:"_id,omitempty"`
+	UserID    string        `bson:"user_id"`
+	ClientID  string        `bson:"client_id"`
+	Scopes    []string      `bson:"scopes"`
+	CreatedAt time.Time     `bson:"created_at"`
+	UpdatedAt time.Time     `bson:"updated_at"`
+}
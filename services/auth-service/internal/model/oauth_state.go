@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// OAuthState holds what is needed to complete a login started with an external provider.
+// It is looked up by the state parameter on the callback and deleted as soon as it is used.
+// UserID is only set when the flow links the external account to an existing user instead of logging in.
+type OAuthState struct {
+	ID           bson.ObjectID `bson:"_id,omitempty"`
+	State        string        `bson:"state"`
+	Provider     string        `bson:"provider"`
+	UserID       string        `bson:"user_id,omitempty"`
+	Nonce        string        `bson:"nonce"`
+	CodeVerifier string        `bson:"code_verifier"`
+	ExpiresAt    time.Time     `bson:"expires_at"`
+	CreatedAt    time.Time     `bson:"created_at"`
+}
@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// PersonalAccessToken is a long-lived token a user creates for scripts and integrations. TokenHash
+// holds the SHA-256 hash of the token, which is only shown once when it is created. ExpiresAt is nil
+// for tokens that do not expire, and LastUsedAt is nil until the token is first used.
+type PersonalAccessToken struct {
+	ID         bson.ObjectID `bson:"_id,omitempty"`
+	UserID     string        `bson:"user_id"`
+	Name       string        `bson:"name"`
+	TokenHash  string        `bson:"token_hash"`
+	Scopes     []string      `bson:"scopes"`
+	ExpiresAt  *time.Time    `bson:"expires_at,omitempty"`
+	LastUsedAt *time.Time    `bson:"last_used_at,omitempty"`
+	CreatedAt  time.Time     `bson:"created_at"`
+}
@@ -0,0 +1,17 @@
+package model
+
+import authtypes "github.com/vasapolrittideah/money-tracker-api/services/auth-service/pkg/types"
+
+// Roles users can be given.
+const (
+	// RoleSupport is for staff who help users with their accounts.
+	RoleSupport = "support"
+	// RoleAdmin is for staff who manage accounts, including the roles of other users.
+	RoleAdmin = "admin"
+)
+
+// RolePermissions maps every role to the permissions it grants.
+var RolePermissions = map[string][]string{
+	RoleSupport: {authtypes.PermissionUsersRead},
+	RoleAdmin:   {authtypes.PermissionUsersRead, authtypes.PermissionUsersWrite},
+}
@@ -6,7 +6,11 @@ import (
 	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
-// Session represents an authentication user session with access and refresh tokens.
+// Session represents an authentication user session with access and refresh tokens. Sessions of
+// third-party apps a user has authorized have the ClientID of the app and the space separated Scope
+// their tokens are limited to; they have no device, and are signed out along with the user's own.
+// DeviceIDHash is the hash of the device ID of the client the session was created from, which
+// recognizes the device when the user signs in again.
 type Session struct {
 	ID                    bson.ObjectID `bson:"_id,omitempty"`
 	UserID                string        `bson:"user_id"`
@@ -16,6 +20,10 @@ type Session struct {
 	RefreshTokenExpiresAt time.Time     `bson:"refresh_token_expires_at"`
 	IPAddress             *string       `bson:"ip_address"`
 	UserAgent             *string       `bson:"user_agent"`
+	DeviceIDHash          string        `bson:"device_id_hash,omitempty"`
+	RevokedAt             *time.Time    `bson:"revoked_at"`
+	ClientID              string        `bson:"client_id,omitempty"`
+	Scope                 string        `bson:"scope,omitempty"`
 	CreatedAt             time.Time     `bson:"created_at"`
 	UpdatedAt             time.Time     `bson:"updated_at"`
 }
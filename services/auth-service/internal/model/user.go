@@ -7,6 +7,12 @@ import (
 )
 
 // User represents a user in the authentication system.
+// VerificationCode holds the SHA-256 hash of the code emailed to the user, never the code itself.
+// TOTPSecret is encrypted, and RecoveryCodes only holds the SHA-256 hashes of the unused codes.
+// DeletedAt and PurgeAt are only set while the account is scheduled for deletion; logging in
+// before PurgeAt restores it. LoginAlerts is one of the LoginAlerts rules, empty for the default,
+// and PasswordResetRequired blocks logging in with the password until it has been reset. Roles are
+// keys of RolePermissions, and Permissions are granted on top of the ones of the roles.
 type User struct {
 	ID                        bson.ObjectID `bson:"_id,omitempty"`
 	Email                     string        `bson:"email"`
@@ -14,6 +20,18 @@ type User struct {
 	Verified                  bool          `bson:"verified"`
 	VerificationCode          string        `bson:"verification_code"`
 	VerificationCodeExpiresAt time.Time     `bson:"verification_code_expires_at"`
+	VerificationCodeSentAt    time.Time     `bson:"verification_code_sent_at"`
+	VerificationAttempts      int           `bson:"verification_attempts"`
+	TOTPEnabled               bool          `bson:"totp_enabled"`
+	TOTPSecret                string        `bson:"totp_secret"`
+	TOTPLastUsedStep          int64         `bson:"totp_last_used_step"`
+	RecoveryCodes             []string      `bson:"recovery_codes"`
+	LoginAlerts               string        `bson:"login_alerts,omitempty"`
+	PasswordResetRequired     bool          `bson:"password_reset_required,omitempty"`
+	Roles                     []string      `bson:"roles,omitempty"`
+	Permissions               []string      `bson:"permissions,omitempty"`
+	DeletedAt                 *time.Time    `bson:"deleted_at,omitempty"`
+	PurgeAt                   *time.Time    `bson:"purge_at,omitempty"`
 	CreatedAt                 time.Time     `bson:"created_at"`
 	UpdatedAt                 time.Time     `bson:"updated_at"`
 }
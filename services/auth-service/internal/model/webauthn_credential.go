@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// WebAuthnCredential represents a passkey a user can log in with. Unlike an Identity it is not
+// backed by an external account but by a key pair held by the user's authenticator, of which only
+// the COSE encoded public key is stored. CredentialID is the base64url encoded credential ID.
+type WebAuthnCredential struct {
+	ID             bson.ObjectID `bson:"_id,omitempty"`
+	UserID         string        `bson:"user_id"`
+	CredentialID   string        `bson:"credential_id"`
+	PublicKey      []byte        `bson:"public_key"`
+	SignCount      uint32        `bson:"sign_count"`
+	Transports     []string      `bson:"transports"`
+	AAGUID         []byte        `bson:"aaguid"`
+	Name           string        `bson:"name"`
+	BackupEligible bool          `bson:"backup_eligible"`
+	BackedUp       bool          `bson:"backed_up"`
+	LastUsedAt     *time.Time    `bson:"last_used_at,omitempty"`
+	CreatedAt      time.Time     `bson:"created_at"`
+	UpdatedAt      time.Time     `bson:"updated_at"`
+}
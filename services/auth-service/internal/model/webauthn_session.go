@@ -0,0 +1,25 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// WebAuthn ceremonies a session can be started for.
+const (
+	WebAuthnCeremonyRegistration = "registration"
+	WebAuthnCeremonyLogin        = "login"
+)
+
+// WebAuthnSession holds the challenge of a pending passkey ceremony. It is looked up by the challenge
+// the authenticator signed and deleted as soon as it is used. UserID is only set for registrations,
+// since a login does not know the user until the passkey is presented.
+type WebAuthnSession struct {
+	ID        bson.ObjectID `bson:"_id,omitempty"`
+	Challenge string        `bson:"challenge"`
+	Ceremony  string        `bson:"ceremony"`
+	UserID    string        `bson:"user_id,omitempty"`
+	ExpiresAt time.Time     `bson:"expires_at"`
+	CreatedAt time.Time     `bson:"created_at"`
+}
@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/model"
+)
+
+// AccountRepository defines the database operations that span every collection of an account.
+type AccountRepository interface {
+	// PurgeAccount deletes the user and everything stored for them, including what other users gave
+	// the apps the user registered, and stores the event announcing it, in one transaction. Login
+	// attempts are keyed by email address or by purpose rather than by user, so their keys are passed
+	// in. It returns mongo.ErrNoDocuments if the user is no longer due for purging, such as after
+	// being restored or purged by another instance.
+	PurgeAccount(ctx context.Context, userID string, loginAttemptKeys []string, event *model.Event) error
+}
+
+const eventCollection = "events"
+
+// userDataCollections are the collections whose documents belong to a user through their user_id.
+var userDataCollections = []string{
+	identityCollection,
+	sessionCollection,
+	mfaChallengeCollection,
+	oauthStateCollection,
+	webAuthnCredentialCollection,
+	webAuthnSessionCollection,
+	emailChangeCollection,
+	dataExportCollection,
+	authEventCollection,
+	loginAlertCollection,
+	magicLinkTokenCollection,
+	personalAccessTokenCollection,
+	oauthClientCollection,
+	oauthConsentCollection,
+	oauthAuthorizationCodeCollection,
+}
+
+// oauthClientDataCollections are the collections whose documents belong to an app through its
+// client_id, such as the sessions and consents of the users who authorized it.
+var oauthClientDataCollections = []string{
+	sessionCollection,
+	oauthConsentCollection,
+	oauthAuthorizationCodeCollection,
+}
+
+type accountMongoRepository struct {
+	db *mongo.Database
+}
+
+// NewAccountMongoRepository creates a new MongoDB repository for account wide operations.
+func NewAccountMongoRepository(db *mongo.Database) AccountRepository {
+	return &accountMongoRepository{db: db}
+}
+
+func (r *accountMongoRepository) PurgeAccount(
+	ctx context.Context,
+	userID string,
+	loginAttemptKeys []string,
+	event *model.Event,
+) error {
+	objectID, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return err
+	}
+
+	session, err := r.db.Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(ctx context.Context) (any, error) {
+		now := time.Now()
+
+		result, err := r.db.Collection(userCollection).DeleteOne(
+			ctx,
+			bson.M{"_id": objectID, "purge_at": bson.M{"$lte": now}},
+		)
+		if err != nil {
+			return nil, err
+		}
+		if result.DeletedCount == 0 {
+			return nil, mongo.ErrNoDocuments
+		}
+
+		// The apps of the user are deleted with the other user data below, so what they were given
+		// has to be found first
+		var clients []*model.OAuthClient
+		cursor, err := r.db.Collection(oauthClientCollection).Find(
+			ctx,
+			bson.M{"user_id": userID},
+			options.Find().SetProjection(bson.M{"_id": 1}),
+		)
+		if err != nil {
+			return nil, err
+		}
+		if err := cursor.All(ctx, &clients); err != nil {
+			return nil, err
+		}
+
+		if len(clients) > 0 {
+			clientIDs := make([]string, 0, len(clients))
+			for _, client := range clients {
+				clientIDs = append(clientIDs, client.ID.Hex())
+			}
+
+			for _, collection := range oauthClientDataCollections {
+				if _, err := r.db.Collection(collection).DeleteMany(
+					ctx,
+					bson.M{"client_id": bson.M{"$in": clientIDs}},
+				); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		for _, collection := range userDataCollections {
+			if _, err := r.db.Collection(collection).DeleteMany(ctx, bson.M{"user_id": userID}); err != nil {
+				return nil, err
+			}
+		}
+
+		// Password reset tokens refer to the user by ObjectID
+		if _, err := r.db.Collection(passwordResetTokenCollection).DeleteMany(
+			ctx,
+			bson.M{"user_id": objectID},
+		); err != nil {
+			return nil, err
+		}
+
+		if _, err := r.db.Collection(loginAttemptCollection).DeleteMany(
+			ctx,
+			bson.M{"key": bson.M{"$in": loginAttemptKeys}},
+		); err != nil {
+			return nil, err
+		}
+
+		event.CreatedAt = now
+		if _, err := r.db.Collection(eventCollection).InsertOne(ctx, event); err != nil {
+			return nil, err
+		}
+
+		return nil, nil
+	})
+	return err
+}
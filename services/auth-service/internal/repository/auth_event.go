@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/model"
+)
+
+// AuthEventRepository defines the interface for the security audit log. Events can only be added
+// and listed; they are removed by a TTL index once they expire.
+type AuthEventRepository interface {
+	CreateAuthEvent(ctx context.Context, event *model.AuthEvent) error
+
+	// ListAuthEventsByUserID returns up to limit events of a user, most recent first. A non-empty
+	// beforeID only returns the events older than the event with that ID, to page through the log.
+	// The user_id condition always applies, so the ID of another user's event never returns theirs.
+	ListAuthEventsByUserID(ctx context.Context, userID, beforeID string, limit int64) ([]*model.AuthEvent, error)
+}
+
+const authEventCollection = "auth_events"
+
+type authEventMongoRepository struct {
+	db *mongo.Database
+}
+
+// NewAuthEventMongoRepository creates a new MongoDB repository for the security audit log.
+func NewAuthEventMongoRepository(
+	ctx context.Context,
+	logger *zerolog.Logger,
+	db *mongo.Database,
+) AuthEventRepository {
+	collection := db.Collection(authEventCollection)
+
+	indexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "_id", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "email", Value: 1}},
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0), // TTL index
+		},
+	}
+
+	_, err := collection.Indexes().CreateMany(ctx, indexes)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create auth event indexes")
+	}
+
+	return &authEventMongoRepository{
+		db: db,
+	}
+}
+
+func (r *authEventMongoRepository) CreateAuthEvent(ctx context.Context, event *model.AuthEvent) error {
+	event.CreatedAt = time.Now()
+
+	result, err := r.db.Collection(authEventCollection).InsertOne(ctx, event)
+	if err != nil {
+		return err
+	}
+
+	if objectID, ok := result.InsertedID.(bson.ObjectID); ok {
+		event.ID = objectID
+	}
+
+	return nil
+}
+
+func (r *authEventMongoRepository) ListAuthEventsByUserID(
+	ctx context.Context,
+	userID string,
+	beforeID string,
+	limit int64,
+) ([]*model.AuthEvent, error) {
+	filter := bson.M{"user_id": userID}
+	if beforeID != "" {
+		objectID, err := bson.ObjectIDFromHex(beforeID)
+		if err != nil {
+			return nil, err
+		}
+		filter["_id"] = bson.M{"$lt": objectID}
+	}
+
+	// ObjectIDs grow with their creation time, so sorting by ID is sorting by time without ties
+	findOptions := options.Find().SetSort(bson.D{{Key: "_id", Value: -1}}).SetLimit(limit)
+
+	cursor, err := r.db.Collection(authEventCollection).Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []*model.AuthEvent
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
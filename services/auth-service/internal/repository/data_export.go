@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/model"
+)
+
+// DataExportRepository defines the interface for personal data export archives.
+type DataExportRepository interface {
+	// CreateDataExport stores an export, replacing any earlier export of the user.
+	CreateDataExport(ctx context.Context, export *model.DataExport) error
+
+	// GetDataExportByUserID returns the unexpired export of a user.
+	GetDataExportByUserID(ctx context.Context, userID string) (*model.DataExport, error)
+
+	// GetDataExportByTokenHash returns the unexpired export of the user with the given download
+	// token hash.
+	GetDataExportByTokenHash(ctx context.Context, userID, tokenHash string) (*model.DataExport, error)
+}
+
+const dataExportCollection = "data_exports"
+
+type dataExportMongoRepository struct {
+	db *mongo.Database
+}
+
+// NewDataExportMongoRepository creates a new MongoDB repository for personal data exports.
+func NewDataExportMongoRepository(
+	ctx context.Context,
+	logger *zerolog.Logger,
+	db *mongo.Database,
+) DataExportRepository {
+	collection := db.Collection(dataExportCollection)
+
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "token_hash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0), // TTL index
+		},
+	}
+
+	_, err := collection.Indexes().CreateMany(ctx, indexes)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create data export indexes")
+	}
+
+	return &dataExportMongoRepository{
+		db: db,
+	}
+}
+
+func (r *dataExportMongoRepository) CreateDataExport(ctx context.Context, export *model.DataExport) error {
+	export.CreatedAt = time.Now()
+
+	_, err := r.db.Collection(dataExportCollection).ReplaceOne(
+		ctx,
+		bson.M{"user_id": export.UserID},
+		export,
+		options.Replace().SetUpsert(true),
+	)
+	return err
+}
+
+func (r *dataExportMongoRepository) GetDataExportByUserID(
+	ctx context.Context,
+	userID string,
+) (*model.DataExport, error) {
+	return r.findDataExport(ctx, bson.M{"user_id": userID})
+}
+
+func (r *dataExportMongoRepository) GetDataExportByTokenHash(
+	ctx context.Context,
+	userID, tokenHash string,
+) (*model.DataExport, error) {
+	return r.findDataExport(ctx, bson.M{"user_id": userID, "token_hash": tokenHash})
+}
+
+func (r *dataExportMongoRepository) findDataExport(ctx context.Context, filter bson.M) (*model.DataExport, error) {
+	// The TTL monitor only runs once a minute, so expired exports may still be stored
+	filter["expires_at"] = bson.M{"$gt": time.Now()}
+
+	var export model.DataExport
+	if err := r.db.Collection(dataExportCollection).FindOne(ctx, filter).Decode(&export); err != nil {
+		return nil, err
+	}
+
+	return &export, nil
+}
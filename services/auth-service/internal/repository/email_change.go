@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/model"
+)
+
+// EmailChangeRepository defines the interface for pending email address changes.
+type EmailChangeRepository interface {
+	// CreateEmailChange stores a pending change, replacing any other pending change of the user.
+	CreateEmailChange(ctx context.Context, change *model.EmailChange) error
+
+	// ConsumeEmailChangeByConfirmToken deletes and returns the unexpired change with the given
+	// confirmation token hash, so a change can only be confirmed once.
+	ConsumeEmailChangeByConfirmToken(ctx context.Context, tokenHash string) (*model.EmailChange, error)
+
+	// ConsumeEmailChangeByCancelToken deletes and returns the unexpired change with the given
+	// cancellation token hash. It returns mongo.ErrNoDocuments if there is no such change.
+	ConsumeEmailChangeByCancelToken(ctx context.Context, tokenHash string) (*model.EmailChange, error)
+}
+
+const emailChangeCollection = "email_changes"
+
+type emailChangeMongoRepository struct {
+	db *mongo.Database
+}
+
+// NewEmailChangeMongoRepository creates a new MongoDB repository for pending email changes.
+func NewEmailChangeMongoRepository(
+	ctx context.Context,
+	logger *zerolog.Logger,
+	db *mongo.Database,
+) EmailChangeRepository {
+	collection := db.Collection(emailChangeCollection)
+
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "confirm_token_hash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "cancel_token_hash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0), // TTL index
+		},
+	}
+
+	_, err := collection.Indexes().CreateMany(ctx, indexes)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create email change indexes")
+	}
+
+	return &emailChangeMongoRepository{
+		db: db,
+	}
+}
+
+func (r *emailChangeMongoRepository) CreateEmailChange(ctx context.Context, change *model.EmailChange) error {
+	change.CreatedAt = time.Now()
+
+	_, err := r.db.Collection(emailChangeCollection).ReplaceOne(
+		ctx,
+		bson.M{"user_id": change.UserID},
+		change,
+		options.Replace().SetUpsert(true),
+	)
+	return err
+}
+
+func (r *emailChangeMongoRepository) ConsumeEmailChangeByConfirmToken(
+	ctx context.Context,
+	tokenHash string,
+) (*model.EmailChange, error) {
+	result := r.db.Collection(emailChangeCollection).FindOneAndDelete(
+		ctx,
+		bson.M{"confirm_token_hash": tokenHash, "expires_at": bson.M{"$gt": time.Now()}},
+	)
+	if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	var change model.EmailChange
+	if err := result.Decode(&change); err != nil {
+		return nil, err
+	}
+
+	return &change, nil
+}
+
+func (r *emailChangeMongoRepository) ConsumeEmailChangeByCancelToken(
+	ctx context.Context,
+	tokenHash string,
+) (*model.EmailChange, error) {
+	result := r.db.Collection(emailChangeCollection).FindOneAndDelete(
+		ctx,
+		bson.M{"cancel_token_hash": tokenHash, "expires_at": bson.M{"$gt": time.Now()}},
+	)
+	if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	var change model.EmailChange
+	if err := result.Decode(&change); err != nil {
+		return nil, err
+	}
+
+	return &change, nil
+}
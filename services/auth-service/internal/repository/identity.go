@@ -5,8 +5,10 @@ import (
 	"errors"
 	"time"
 
+	"github.com/rs/zerolog"
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
 
 	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/model"
 )
@@ -17,6 +19,9 @@ type IdentityRepository interface {
 	GetIdentitiesByUserID(ctx context.Context, userID string) ([]model.Identity, error)
 	GetIdentityByProvider(ctx context.Context, providerID string, provider string) (*model.Identity, error)
 	UpdateLastLogin(ctx context.Context, userID string) error
+
+	// DeleteIdentity deletes the identity with the given ID if it belongs to the user.
+	DeleteIdentity(ctx context.Context, id string, userID string) error
 }
 
 const identityCollection = "identities"
@@ -25,7 +30,28 @@ type identityMongoRepository struct {
 	db *mongo.Database
 }
 
-func NewIdentityMongoRepository(db *mongo.Database) IdentityRepository {
+func NewIdentityMongoRepository(ctx context.Context, logger *zerolog.Logger, db *mongo.Database) IdentityRepository {
+	collection := db.Collection(identityCollection)
+
+	indexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}},
+		},
+		{
+			// An external account can only ever belong to one user. Email identities have
+			// no provider ID, so they are left out of the index.
+			Keys: bson.D{{Key: "provider", Value: 1}, {Key: "provider_id", Value: 1}},
+			Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"provider_id": bson.M{"$gt": ""}}),
+		},
+	}
+
+	_, err := collection.Indexes().CreateMany(ctx, indexes)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create identity indexes")
+	}
+
 	return &identityMongoRepository{db: db}
 }
 
@@ -94,3 +120,24 @@ func (r *identityMongoRepository) UpdateLastLogin(ctx context.Context, userID st
 	)
 	return err
 }
+
+func (r *identityMongoRepository) DeleteIdentity(ctx context.Context, id string, userID string) error {
+	objectID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.db.Collection(identityCollection).DeleteOne(ctx, bson.M{
+		"_id":     objectID,
+		"user_id": userID,
+	})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
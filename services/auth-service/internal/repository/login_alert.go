@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/model"
+)
+
+// LoginAlertRepository defines the interface for the links of sign-in alerts.
+type LoginAlertRepository interface {
+	CreateLoginAlert(ctx context.Context, alert *model.LoginAlert) error
+
+	// ConsumeLoginAlertByTokenHash deletes and returns the unexpired alert with the given token hash,
+	// so a link can only be used once. It returns mongo.ErrNoDocuments if there is no such alert.
+	ConsumeLoginAlertByTokenHash(ctx context.Context, tokenHash string) (*model.LoginAlert, error)
+}
+
+const loginAlertCollection = "login_alerts"
+
+type loginAlertMongoRepository struct {
+	db *mongo.Database
+}
+
+// NewLoginAlertMongoRepository creates a new MongoDB repository for sign-in alerts.
+func NewLoginAlertMongoRepository(
+	ctx context.Context,
+	logger *zerolog.Logger,
+	db *mongo.Database,
+) LoginAlertRepository {
+	collection := db.Collection(loginAlertCollection)
+
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "token_hash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}},
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0), // TTL index
+		},
+	}
+
+	_, err := collection.Indexes().CreateMany(ctx, indexes)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create login alert indexes")
+	}
+
+	return &loginAlertMongoRepository{
+		db: db,
+	}
+}
+
+func (r *loginAlertMongoRepository) CreateLoginAlert(ctx context.Context, alert *model.LoginAlert) error {
+	alert.CreatedAt = time.Now()
+
+	result, err := r.db.Collection(loginAlertCollection).InsertOne(ctx, alert)
+	if err != nil {
+		return err
+	}
+
+	if objectID, ok := result.InsertedID.(bson.ObjectID); ok {
+		alert.ID = objectID
+	}
+
+	return nil
+}
+
+func (r *loginAlertMongoRepository) ConsumeLoginAlertByTokenHash(
+	ctx context.Context,
+	tokenHash string,
+) (*model.LoginAlert, error) {
+	result := r.db.Collection(loginAlertCollection).FindOneAndDelete(
+		ctx,
+		bson.M{"token_hash": tokenHash, "expires_at": bson.M{"$gt": time.Now()}},
+	)
+	if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	var alert model.LoginAlert
+	if err := result.Decode(&alert); err != nil {
+		return nil, err
+	}
+
+	return &alert, nil
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/model"
+)
+
+// LoginAttemptRepository defines the interface for tracking failed logins.
+type LoginAttemptRepository interface {
+	// ReserveAttempt counts a login attempt for the key, starting a new record if it has none, and
+	// returns the record as it was before the attempt was counted, nil if there was none. Counting
+	// and reading happen in one update, so concurrent attempts each see the ones before them.
+	ReserveAttempt(ctx context.Context, key string, expiresAt time.Time) (*model.LoginAttempt, error)
+
+	// ReleaseAttempt takes back one attempt counted by ReserveAttempt.
+	ReleaseAttempt(ctx context.Context, key string) error
+
+	// LockUntil locks the key out until the given time.
+	LockUntil(ctx context.Context, key string, until time.Time) error
+
+	// ClearExpiredLock forgets the failed logins of a key whose lockout until lockedUntil has expired.
+	// A record locked until any other time is left alone, so a count that a concurrent request has
+	// started over already is not lost.
+	ClearExpiredLock(ctx context.Context, key string, lockedUntil time.Time) error
+
+	// ResetLoginAttempts forgets the failed logins of the key.
+	ResetLoginAttempts(ctx context.Context, key string) error
+}
+
+const loginAttemptCollection = "login_attempts"
+
+type loginAttemptMongoRepository struct {
+	db *mongo.Database
+}
+
+// NewLoginAttemptMongoRepository creates a new MongoDB repository for failed logins.
+func NewLoginAttemptMongoRepository(
+	ctx context.Context,
+	logger *zerolog.Logger,
+	db *mongo.Database,
+) LoginAttemptRepository {
+	collection := db.Collection(loginAttemptCollection)
+
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "key", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0), // TTL index
+		},
+	}
+
+	_, err := collection.Indexes().CreateMany(ctx, indexes)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create login attempt indexes")
+	}
+
+	return &loginAttemptMongoRepository{
+		db: db,
+	}
+}
+
+func (r *loginAttemptMongoRepository) ReserveAttempt(
+	ctx context.Context,
+	key string,
+	expiresAt time.Time,
+) (*model.LoginAttempt, error) {
+	now := time.Now()
+	collection := r.db.Collection(loginAttemptCollection)
+
+	// An expired record the TTL monitor has not removed yet must not carry its count over
+	if _, err := collection.DeleteOne(ctx, bson.M{
+		"key":        key,
+		"expires_at": bson.M{"$lte": now},
+	}); err != nil {
+		return nil, err
+	}
+
+	result := collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"key": key},
+		bson.M{
+			"$inc":         bson.M{"failures": 1},
+			"$set":         bson.M{"last_failure_at": now},
+			"$max":         bson.M{"expires_at": expiresAt},
+			"$setOnInsert": bson.M{"created_at": now},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.Before),
+	)
+	if errors.Is(result.Err(), mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	var attempt model.LoginAttempt
+	if err := result.Decode(&attempt); err != nil {
+		return nil, err
+	}
+
+	return &attempt, nil
+}
+
+func (r *loginAttemptMongoRepository) ReleaseAttempt(ctx context.Context, key string) error {
+	_, err := r.db.Collection(loginAttemptCollection).UpdateOne(
+		ctx,
+		bson.M{"key": key, "failures": bson.M{"$gt": 0}},
+		bson.M{"$inc": bson.M{"failures": -1}},
+	)
+	return err
+}
+
+func (r *loginAttemptMongoRepository) LockUntil(ctx context.Context, key string, until time.Time) error {
+	// The record must outlive the lockout, or the TTL monitor would lift it early
+	_, err := r.db.Collection(loginAttemptCollection).UpdateOne(
+		ctx,
+		bson.M{"key": key},
+		bson.M{
+			"$set": bson.M{"locked_until": until},
+			"$max": bson.M{"expires_at": until},
+		},
+	)
+	return err
+}
+
+func (r *loginAttemptMongoRepository) ClearExpiredLock(
+	ctx context.Context,
+	key string,
+	lockedUntil time.Time,
+) error {
+	_, err := r.db.Collection(loginAttemptCollection).DeleteOne(ctx, bson.M{
+		"key":          key,
+		"locked_until": bson.M{"$eq": lockedUntil, "$lte": time.Now()},
+	})
+	return err
+}
+
+func (r *loginAttemptMongoRepository) ResetLoginAttempts(ctx context.Context, key string) error {
+	_, err := r.db.Collection(loginAttemptCollection).DeleteOne(ctx, bson.M{"key": key})
+	return err
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/model"
+)
+
+// MagicLinkTokenRepository defines the interface for magic link token operations.
+type MagicLinkTokenRepository interface {
+	// CreateToken creates a new magic link token.
+	CreateToken(ctx context.Context, token *model.MagicLinkToken) error
+
+	// ConsumeToken marks the unused and unexpired token with the given JTI as used and returns it,
+	// so a link can only be redeemed once. It returns mongo.ErrNoDocuments if there is no such token.
+	ConsumeToken(ctx context.Context, jti string) (*model.MagicLinkToken, error)
+
+	// InvalidateUserTokens invalidates all unused tokens for a specific user.
+	InvalidateUserTokens(ctx context.Context, userID string) error
+}
+
+const magicLinkTokenCollection = "magic_link_tokens"
+
+type magicLinkTokenMongoRepository struct {
+	db *mongo.Database
+}
+
+// NewMagicLinkTokenMongoRepository creates a new MongoDB repository for magic link tokens.
+func NewMagicLinkTokenMongoRepository(
+	ctx context.Context,
+	logger *zerolog.Logger,
+	db *mongo.Database,
+) MagicLinkTokenRepository {
+	collection := db.Collection(magicLinkTokenCollection)
+
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "jti", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}},
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0), // TTL index
+		},
+	}
+
+	_, err := collection.Indexes().CreateMany(ctx, indexes)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create magic link token indexes")
+	}
+
+	return &magicLinkTokenMongoRepository{
+		db: db,
+	}
+}
+
+func (r *magicLinkTokenMongoRepository) CreateToken(ctx context.Context, token *model.MagicLinkToken) error {
+	now := time.Now()
+	token.CreatedAt = now
+	token.UpdatedAt = now
+	token.Used = false
+
+	result, err := r.db.Collection(magicLinkTokenCollection).InsertOne(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	if objectID, ok := result.InsertedID.(bson.ObjectID); ok {
+		token.ID = objectID
+	}
+
+	return nil
+}
+
+func (r *magicLinkTokenMongoRepository) ConsumeToken(ctx context.Context, jti string) (*model.MagicLinkToken, error) {
+	now := time.Now()
+	filter := bson.M{
+		"jti":        jti,
+		"used":       false,
+		"expires_at": bson.M{"$gt": now},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"used":       true,
+			"updated_at": now,
+		},
+	}
+
+	result := r.db.Collection(magicLinkTokenCollection).FindOneAndUpdate(ctx, filter, update)
+	if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	var token model.MagicLinkToken
+	if err := result.Decode(&token); err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+func (r *magicLinkTokenMongoRepository) InvalidateUserTokens(ctx context.Context, userID string) error {
+	filter := bson.M{
+		"user_id": userID,
+		"used":    false,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"used":       true,
+			"updated_at": time.Now(),
+		},
+	}
+
+	_, err := r.db.Collection(magicLinkTokenCollection).UpdateMany(ctx, filter, update)
+	return err
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/model"
+)
+
+// MFAChallengeRepository defines the interface for pending second factor challenges.
+type MFAChallengeRepository interface {
+	// CreateChallenge stores a new challenge.
+	CreateChallenge(ctx context.Context, challenge *model.MFAChallenge) (*model.MFAChallenge, error)
+
+	// IncrementAttempts counts a verification attempt and returns the challenge after the update.
+	// Expired challenges are reported as not found.
+	IncrementAttempts(ctx context.Context, jti string) (*model.MFAChallenge, error)
+
+	// DeleteChallenge deletes a challenge. It returns mongo.ErrNoDocuments if the challenge
+	// has already been deleted, so a challenge can only be completed once.
+	DeleteChallenge(ctx context.Context, jti string) error
+}
+
+const mfaChallengeCollection = "mfa_challenges"
+
+type mfaChallengeMongoRepository struct {
+	db *mongo.Database
+}
+
+// NewMFAChallengeMongoRepository creates a new MongoDB repository for MFA challenges.
+func NewMFAChallengeMongoRepository(
+	ctx context.Context,
+	logger *zerolog.Logger,
+	db *mongo.Database,
+) MFAChallengeRepository {
+	collection := db.Collection(mfaChallengeCollection)
+
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "jti", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0), // TTL index
+		},
+	}
+
+	_, err := collection.Indexes().CreateMany(ctx, indexes)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create MFA challenge indexes")
+	}
+
+	return &mfaChallengeMongoRepository{
+		db: db,
+	}
+}
+
+func (r *mfaChallengeMongoRepository) CreateChallenge(
+	ctx context.Context,
+	challenge *model.MFAChallenge,
+) (*model.MFAChallenge, error) {
+	challenge.CreatedAt = time.Now()
+
+	result, err := r.db.Collection(mfaChallengeCollection).InsertOne(ctx, challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	if objectID, ok := result.InsertedID.(bson.ObjectID); ok {
+		challenge.ID = objectID
+	} else {
+		return nil, errors.New("failed to convert inserted ID to ObjectID")
+	}
+
+	return challenge, nil
+}
+
+func (r *mfaChallengeMongoRepository) IncrementAttempts(ctx context.Context, jti string) (*model.MFAChallenge, error) {
+	result := r.db.Collection(mfaChallengeCollection).FindOneAndUpdate(
+		ctx,
+		bson.M{"jti": jti, "expires_at": bson.M{"$gt": time.Now()}},
+		bson.M{"$inc": bson.M{"attempts": 1}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	)
+	if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	var challenge model.MFAChallenge
+	if err := result.Decode(&challenge); err != nil {
+		return nil, err
+	}
+
+	return &challenge, nil
+}
+
+func (r *mfaChallengeMongoRepository) DeleteChallenge(ctx context.Context, jti string) error {
+	result, err := r.db.Collection(mfaChallengeCollection).DeleteOne(ctx, bson.M{"jti": jti})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/model"
+)
+
+// OAuthAuthorizationCodeRepository defines the interface for authorization code operations.
+type OAuthAuthorizationCodeRepository interface {
+	CreateAuthorizationCode(ctx context.Context, code *model.OAuthAuthorizationCode) error
+
+	// ConsumeAuthorizationCode deletes the unexpired code with the given hash and returns it, so a code
+	// can only be exchanged once. It returns mongo.ErrNoDocuments if there is no such code.
+	ConsumeAuthorizationCode(ctx context.Context, codeHash string) (*model.OAuthAuthorizationCode, error)
+}
+
+const oauthAuthorizationCodeCollection = "oauth_authorization_codes"
+
+type oauthAuthorizationCodeMongoRepository struct {
+	db *mongo.Database
+}
+
+// NewOAuthAuthorizationCodeMongoRepository creates a new MongoDB repository for authorization codes.
+func NewOAuthAuthorizationCodeMongoRepository(
+	ctx context.Context,
+	logger *zerolog.Logger,
+	db *mongo.Database,
+) OAuthAuthorizationCodeRepository {
+	collection := db.Collection(oauthAuthorizationCodeCollection)
+
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "code_hash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0), // TTL index
+		},
+	}
+
+	_, err := collection.Indexes().CreateMany(ctx, indexes)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create oauth authorization code indexes")
+	}
+
+	return &oauthAuthorizationCodeMongoRepository{
+		db: db,
+	}
+}
+
+func (r *oauthAuthorizationCodeMongoRepository) CreateAuthorizationCode(
+	ctx context.Context,
+	code *model.OAuthAuthorizationCode,
+) error {
+	code.CreatedAt = time.Now()
+
+	result, err := r.db.Collection(oauthAuthorizationCodeCollection).InsertOne(ctx, code)
+	if err != nil {
+		return err
+	}
+
+	if objectID, ok := result.InsertedID.(bson.ObjectID); ok {
+		code.ID = objectID
+	}
+
+	return nil
+}
+
+func (r *oauthAuthorizationCodeMongoRepository) ConsumeAuthorizationCode(
+	ctx context.Context,
+	codeHash string,
+) (*model.OAuthAuthorizationCode, error) {
+	var code model.OAuthAuthorizationCode
+	if err := r.db.Collection(oauthAuthorizationCodeCollection).FindOneAndDelete(
+		ctx,
+		bson.M{"code_hash": codeHash, "expires_at": bson.M{"$gt": time.Now()}},
+	).Decode(&code); err != nil {
+		return nil, err
+	}
+
+	return &code, nil
+}
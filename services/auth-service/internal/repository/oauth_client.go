@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/model"
+)
+
+// OAuthClientRepository defines the interface for operations on registered third-party apps.
+type OAuthClientRepository interface {
+	CreateOAuthClient(ctx context.Context, client *model.OAuthClient) error
+
+	// GetOAuthClient returns the client with the given ID. It returns mongo.ErrNoDocuments if there
+	// is no such client.
+	GetOAuthClient(ctx context.Context, id string) (*model.OAuthClient, error)
+
+	// ListOAuthClientsByUserID returns the clients a user registered, most recently created first.
+	ListOAuthClientsByUserID(ctx context.Context, userID string) ([]*model.OAuthClient, error)
+
+	// DeleteOAuthClient deletes a client the user registered. It returns mongo.ErrNoDocuments if the
+	// user has no such client.
+	DeleteOAuthClient(ctx context.Context, userID, id string) error
+}
+
+const oauthClientCollection = "oauth_clients"
+
+type oauthClientMongoRepository struct {
+	db *mongo.Database
+}
+
+// NewOAuthClientMongoRepository creates a new MongoDB repository for third-party apps.
+func NewOAuthClientMongoRepository(
+	ctx context.Context,
+	logger *zerolog.Logger,
+	db *mongo.Database,
+) OAuthClientRepository {
+	collection := db.Collection(oauthClientCollection)
+
+	indexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}},
+		},
+	}
+
+	_, err := collection.Indexes().CreateMany(ctx, indexes)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create oauth client indexes")
+	}
+
+	return &oauthClientMongoRepository{
+		db: db,
+	}
+}
+
+func (r *oauthClientMongoRepository) CreateOAuthClient(ctx context.Context, client *model.OAuthClient) error {
+	client.CreatedAt = time.Now()
+
+	result, err := r.db.Collection(oauthClientCollection).InsertOne(ctx, client)
+	if err != nil {
+		return err
+	}
+
+	if objectID, ok := result.InsertedID.(bson.ObjectID); ok {
+		client.ID = objectID
+	}
+
+	return nil
+}
+
+func (r *oauthClientMongoRepository) GetOAuthClient(ctx context.Context, id string) (*model.OAuthClient, error) {
+	objectID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, mongo.ErrNoDocuments
+	}
+
+	var client model.OAuthClient
+	if err := r.db.Collection(oauthClientCollection).FindOne(ctx, bson.M{"_id": objectID}).Decode(&client); err != nil {
+		return nil, err
+	}
+
+	return &client, nil
+}
+
+func (r *oauthClientMongoRepository) ListOAuthClientsByUserID(
+	ctx context.Context,
+	userID string,
+) ([]*model.OAuthClient, error) {
+	findOptions := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := r.db.Collection(oauthClientCollection).Find(ctx, bson.M{"user_id": userID}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	var clients []*model.OAuthClient
+	if err := cursor.All(ctx, &clients); err != nil {
+		return nil, err
+	}
+
+	return clients, nil
+}
+
+func (r *oauthClientMongoRepository) DeleteOAuthClient(ctx context.Context, userID, id string) error {
+	objectID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return mongo.ErrNoDocuments
+	}
+
+	result, err := r.db.Collection(oauthClientCollection).DeleteOne(ctx, bson.M{"_id": objectID, "user_id": userID})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/model"
+)
+
+// OAuthConsentRepository defines the interface for operations on the consents users give to third-party apps.
+type OAuthConsentRepository interface {
+	// GetOAuthConsent returns the consent of a user for a client. It returns mongo.ErrNoDocuments if
+	// the user has not authorized the client.
+	GetOAuthConsent(ctx context.Context, userID, clientID string) (*model.OAuthConsent, error)
+
+	// GrantOAuthConsent adds scopes to the consent of a user for a client, creating the consent if
+	// there is none yet.
+	GrantOAuthConsent(ctx context.Context, userID, clientID string, scopes []string) error
+
+	// ListOAuthConsentsByUserID returns the consents of a user, most recently updated first.
+	ListOAuthConsentsByUserID(ctx context.Context, userID string) ([]*model.OAuthConsent, error)
+
+	// DeleteOAuthConsent deletes the consent of a user for a client. It returns mongo.ErrNoDocuments
+	// if the user has not authorized the client.
+	DeleteOAuthConsent(ctx context.Context, userID, clientID string) error
+
+	// DeleteOAuthConsentsByClientID deletes the consents every user gave to a client.
+	DeleteOAuthConsentsByClientID(ctx context.Context, clientID string) error
+}
+
+const oauthConsentCollection = "oauth_consents"
+
+type oauthConsentMongoRepository struct {
+	db *mongo.Database
+}
+
+// NewOAuthConsentMongoRepository creates a new MongoDB repository for consents given to third-party apps.
+func NewOAuthConsentMongoRepository(
+	ctx context.Context,
+	logger *zerolog.Logger,
+	db *mongo.Database,
+) OAuthConsentRepository {
+	collection := db.Collection(oauthConsentCollection)
+
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "client_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "client_id", Value: 1}},
+		},
+	}
+
+	_, err := collection.Indexes().CreateMany(ctx, indexes)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create oauth consent indexes")
+	}
+
+	return &oauthConsentMongoRepository{
+		db: db,
+	}
+}
+
+func (r *oauthConsentMongoRepository) GetOAuthConsent(
+	ctx context.Context,
+	userID, clientID string,
+) (*model.OAuthConsent, error) {
+	var consent model.OAuthConsent
+	if err := r.db.Collection(oauthConsentCollection).FindOne(
+		ctx,
+		bson.M{"user_id": userID, "client_id": clientID},
+	).Decode(&consent); err != nil {
+		return nil, err
+	}
+
+	return &consent, nil
+}
+
+func (r *oauthConsentMongoRepository) GrantOAuthConsent(
+	ctx context.Context,
+	userID, clientID string,
+	scopes []string,
+) error {
+	now := time.Now()
+	_, err := r.db.Collection(oauthConsentCollection).UpdateOne(
+		ctx,
+		bson.M{"user_id": userID, "client_id": clientID},
+		bson.M{
+			"$addToSet":    bson.M{"scopes": bson.M{"$each": scopes}},
+			"$set":         bson.M{"updated_at": now},
+			"$setOnInsert": bson.M{"created_at": now},
+		},
+		options.UpdateOne().SetUpsert(true),
+	)
+	return err
+}
+
+func (r *oauthConsentMongoRepository) ListOAuthConsentsByUserID(
+	ctx context.Context,
+	userID string,
+) ([]*model.OAuthConsent, error) {
+	findOptions := options.Find().SetSort(bson.D{{Key: "updated_at", Value: -1}})
+
+	cursor, err := r.db.Collection(oauthConsentCollection).Find(ctx, bson.M{"user_id": userID}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	var consents []*model.OAuthConsent
+	if err := cursor.All(ctx, &consents); err != nil {
+		return nil, err
+	}
+
+	return consents, nil
+}
+
+func (r *oauthConsentMongoRepository) DeleteOAuthConsent(ctx context.Context, userID, clientID string) error {
+	result, err := r.db.Collection(oauthConsentCollection).DeleteOne(
+		ctx,
+		bson.M{"user_id": userID, "client_id": clientID},
+	)
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (r *oauthConsentMongoRepository) DeleteOAuthConsentsByClientID(ctx context.Context, clientID string) error {
+	_, err := r.db.Collection(oauthConsentCollection).DeleteMany(ctx, bson.M{"client_id": clientID})
+	return err
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/model"
+)
+
+// OAuthStateRepository defines the interface for storing the state of pending external logins.
+type OAuthStateRepository interface {
+	// CreateOAuthState stores the state of a login that has been sent to a provider.
+	CreateOAuthState(ctx context.Context, state *model.OAuthState) (*model.OAuthState, error)
+
+	// ConsumeOAuthState deletes and returns the unexpired state issued for the provider,
+	// so a state can only ever be used once.
+	ConsumeOAuthState(ctx context.Context, state, provider string) (*model.OAuthState, error)
+}
+
+const oauthStateCollection = "oauth_states"
+
+type oauthStateMongoRepository struct {
+	db *mongo.Database
+}
+
+// NewOAuthStateMongoRepository creates a new MongoDB repository for OAuth states.
+func NewOAuthStateMongoRepository(
+	ctx context.Context,
+	logger *zerolog.Logger,
+	db *mongo.Database,
+) OAuthStateRepository {
+	collection := db.Collection(oauthStateCollection)
+
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "state", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0), // TTL index
+		},
+	}
+
+	_, err := collection.Indexes().CreateMany(ctx, indexes)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create OAuth state indexes")
+	}
+
+	return &oauthStateMongoRepository{
+		db: db,
+	}
+}
+
+func (r *oauthStateMongoRepository) CreateOAuthState(
+	ctx context.Context,
+	state *model.OAuthState,
+) (*model.OAuthState, error) {
+	state.CreatedAt = time.Now()
+
+	result, err := r.db.Collection(oauthStateCollection).InsertOne(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+
+	if objectID, ok := result.InsertedID.(bson.ObjectID); ok {
+		state.ID = objectID
+	} else {
+		return nil, errors.New("failed to convert inserted ID to ObjectID")
+	}
+
+	return state, nil
+}
+
+func (r *oauthStateMongoRepository) ConsumeOAuthState(
+	ctx context.Context,
+	state string,
+	provider string,
+) (*model.OAuthState, error) {
+	// The TTL monitor only runs periodically, so expired states must be filtered out explicitly
+	result := r.db.Collection(oauthStateCollection).FindOneAndDelete(ctx, bson.M{
+		"state":      state,
+		"provider":   provider,
+		"expires_at": bson.M{"$gt": time.Now()},
+	})
+	if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	var oauthState model.OAuthState
+	if err := result.Decode(&oauthState); err != nil {
+		return nil, err
+	}
+
+	return &oauthState, nil
+}
@@ -28,6 +28,9 @@ type PasswordResetTokenRepository interface {
 
 	// InvalidateUserTokens invalidates all unused tokens for a specific user.
 	InvalidateUserTokens(ctx context.Context, userID string) error
+
+	// GetTokensByUserID returns every stored token of a user, most recent first.
+	GetTokensByUserID(ctx context.Context, userID string) ([]model.PasswordResetToken, error)
 }
 
 const passwordResetTokenCollection = "password_reset_tokens"
@@ -153,3 +156,29 @@ func (r *passwordResetTokenMongoRepository) InvalidateUserTokens(ctx context.Con
 	_, err = r.db.Collection(passwordResetTokenCollection).UpdateMany(ctx, filter, update)
 	return err
 }
+
+func (r *passwordResetTokenMongoRepository) GetTokensByUserID(
+	ctx context.Context,
+	userID string,
+) ([]model.PasswordResetToken, error) {
+	objectID, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := r.db.Collection(passwordResetTokenCollection).Find(
+		ctx,
+		bson.M{"user_id": objectID},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var tokens []model.PasswordResetToken
+	if err := cursor.All(ctx, &tokens); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/model"
+)
+
+// PersonalAccessTokenRepository defines the interface for personal access token operations.
+type PersonalAccessTokenRepository interface {
+	CreatePersonalAccessToken(ctx context.Context, token *model.PersonalAccessToken) error
+
+	// GetPersonalAccessTokenByHash returns the unexpired token with the given hash. It returns
+	// mongo.ErrNoDocuments if there is no such token.
+	GetPersonalAccessTokenByHash(ctx context.Context, tokenHash string) (*model.PersonalAccessToken, error)
+
+	// ListPersonalAccessTokensByUserID returns the tokens of a user, most recently created first.
+	ListPersonalAccessTokensByUserID(ctx context.Context, userID string) ([]*model.PersonalAccessToken, error)
+
+	CountPersonalAccessTokensByUserID(ctx context.Context, userID string) (int64, error)
+
+	// DeletePersonalAccessToken deletes a token of the user. It returns mongo.ErrNoDocuments if the
+	// user has no such token.
+	DeletePersonalAccessToken(ctx context.Context, userID, id string) error
+
+	// DeleteTokensByUserID deletes every token of a user.
+	DeleteTokensByUserID(ctx context.Context, userID string) error
+
+	// UpdateLastUsed records that a token was used at usedAt. Tokens used within the last minute
+	// are left alone, so a busy script does not write to the database on every request.
+	UpdateLastUsed(ctx context.Context, id string, usedAt time.Time) error
+}
+
+const personalAccessTokenCollection = "personal_access_tokens"
+
+// lastUsedResolution is how precisely the last use of a personal access token is tracked.
+const lastUsedResolution = time.Minute
+
+type personalAccessTokenMongoRepository struct {
+	db *mongo.Database
+}
+
+// NewPersonalAccessTokenMongoRepository creates a new MongoDB repository for personal access tokens.
+func NewPersonalAccessTokenMongoRepository(
+	ctx context.Context,
+	logger *zerolog.Logger,
+	db *mongo.Database,
+) PersonalAccessTokenRepository {
+	collection := db.Collection(personalAccessTokenCollection)
+
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "token_hash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}},
+		},
+		{
+			// Tokens without an expiry have no expires_at and are never removed
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0), // TTL index
+		},
+	}
+
+	_, err := collection.Indexes().CreateMany(ctx, indexes)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create personal access token indexes")
+	}
+
+	return &personalAccessTokenMongoRepository{
+		db: db,
+	}
+}
+
+func (r *personalAccessTokenMongoRepository) CreatePersonalAccessToken(
+	ctx context.Context,
+	token *model.PersonalAccessToken,
+) error {
+	token.CreatedAt = time.Now()
+
+	result, err := r.db.Collection(personalAccessTokenCollection).InsertOne(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	if objectID, ok := result.InsertedID.(bson.ObjectID); ok {
+		token.ID = objectID
+	}
+
+	return nil
+}
+
+func (r *personalAccessTokenMongoRepository) GetPersonalAccessTokenByHash(
+	ctx context.Context,
+	tokenHash string,
+) (*model.PersonalAccessToken, error) {
+	// The TTL monitor only runs every minute, so expired tokens are filtered out here as well
+	filter := bson.M{
+		"token_hash": tokenHash,
+		"$or": bson.A{
+			bson.M{"expires_at": bson.M{"$exists": false}},
+			bson.M{"expires_at": bson.M{"$gt": time.Now()}},
+		},
+	}
+
+	var token model.PersonalAccessToken
+	if err := r.db.Collection(personalAccessTokenCollection).FindOne(ctx, filter).Decode(&token); err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+func (r *personalAccessTokenMongoRepository) ListPersonalAccessTokensByUserID(
+	ctx context.Context,
+	userID string,
+) ([]*model.PersonalAccessToken, error) {
+	findOptions := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := r.db.Collection(personalAccessTokenCollection).Find(ctx, bson.M{"user_id": userID}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	var tokens []*model.PersonalAccessToken
+	if err := cursor.All(ctx, &tokens); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+func (r *personalAccessTokenMongoRepository) CountPersonalAccessTokensByUserID(
+	ctx context.Context,
+	userID string,
+) (int64, error) {
+	return r.db.Collection(personalAccessTokenCollection).CountDocuments(ctx, bson.M{"user_id": userID})
+}
+
+func (r *personalAccessTokenMongoRepository) DeletePersonalAccessToken(ctx context.Context, userID, id string) error {
+	objectID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return mongo.ErrNoDocuments
+	}
+
+	result, err := r.db.Collection(personalAccessTokenCollection).DeleteOne(
+		ctx,
+		bson.M{"_id": objectID, "user_id": userID},
+	)
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (r *personalAccessTokenMongoRepository) DeleteTokensByUserID(ctx context.Context, userID string) error {
+	_, err := r.db.Collection(personalAccessTokenCollection).DeleteMany(ctx, bson.M{"user_id": userID})
+	return err
+}
+
+func (r *personalAccessTokenMongoRepository) UpdateLastUsed(ctx context.Context, id string, usedAt time.Time) error {
+	objectID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{
+		"_id": objectID,
+		"$or": bson.A{
+			bson.M{"last_used_at": bson.M{"$exists": false}},
+			bson.M{"last_used_at": bson.M{"$lt": usedAt.Add(-lastUsedResolution)}},
+		},
+	}
+
+	_, err = r.db.Collection(personalAccessTokenCollection).UpdateOne(
+		ctx,
+		filter,
+		bson.M{"$set": bson.M{"last_used_at": usedAt}},
+	)
+	return err
+}
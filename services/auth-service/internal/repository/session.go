@@ -7,6 +7,7 @@ import (
 
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
 
 	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/model"
 )
@@ -14,8 +15,39 @@ import (
 // SessionRepository defines the interface for session-related database operations.
 type SessionRepository interface {
 	CreateSession(ctx context.Context, session *model.Session) (*model.Session, error)
+	GetSession(ctx context.Context, id string) (*model.Session, error)
 	GetSessionByUserID(ctx context.Context, userID string) (*model.Session, error)
+
+	// ListActiveSessionsByUserID returns the sessions of a user that are neither revoked nor expired,
+	// most recently used first. Sessions of third-party apps are left out.
+	ListActiveSessionsByUserID(ctx context.Context, userID string) ([]*model.Session, error)
+
+	// ListSessionsByUserID returns every stored session of a user, including revoked and expired
+	// ones, most recently created first.
+	ListSessionsByUserID(ctx context.Context, userID string) ([]*model.Session, error)
 	UpdateTokens(ctx context.Context, id string, params UpdateTokensParams) (*model.Session, error)
+
+	// RotateTokens replaces the session tokens only if the stored refresh token still equals
+	// currentRefreshToken. It returns mongo.ErrNoDocuments when the token has already been rotated.
+	RotateTokens(
+		ctx context.Context,
+		id string,
+		currentRefreshToken string,
+		params UpdateTokensParams,
+	) (*model.Session, error)
+
+	// RevokeSession marks a session as revoked so its tokens can no longer be used.
+	RevokeSession(ctx context.Context, id string) error
+
+	// RevokeSessionsByUserID marks every active session of a user as revoked.
+	RevokeSessionsByUserID(ctx context.Context, userID string) error
+
+	// RevokeOtherSessionsByUserID marks every active session of a user except keepSessionID as revoked.
+	RevokeOtherSessionsByUserID(ctx context.Context, userID, keepSessionID string) error
+
+	// RevokeSessionsByClientID marks every active session of a third-party app as revoked. When
+	// userID is not empty, only the sessions of that user are revoked.
+	RevokeSessionsByClientID(ctx context.Context, clientID, userID string) error
 }
 
 // UpdateTokensParams defines the parameters for updating session tokens.
@@ -55,6 +87,25 @@ func (r *sessionMongoRepository) CreateSession(ctx context.Context, session *mod
 	return session, nil
 }
 
+func (r *sessionMongoRepository) GetSession(ctx context.Context, id string) (*model.Session, error) {
+	objectID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	result := r.db.Collection(sessionCollection).FindOne(ctx, bson.M{"_id": objectID})
+	if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	var session model.Session
+	if err := result.Decode(&session); err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
 func (r *sessionMongoRepository) GetSessionByUserID(ctx context.Context, userID string) (*model.Session, error) {
 	result := r.db.Collection(sessionCollection).FindOne(ctx, bson.M{"user_id": userID})
 	if result.Err() != nil {
@@ -69,6 +120,59 @@ func (r *sessionMongoRepository) GetSessionByUserID(ctx context.Context, userID
 	return &session, nil
 }
 
+func (r *sessionMongoRepository) ListActiveSessionsByUserID(
+	ctx context.Context,
+	userID string,
+) ([]*model.Session, error) {
+	filter := bson.M{
+		"user_id":                  userID,
+		"client_id":                bson.M{"$exists": false},
+		"revoked_at":               nil,
+		"refresh_token_expires_at": bson.M{"$gt": time.Now()},
+	}
+	findOptions := options.Find().SetSort(bson.D{{Key: "updated_at", Value: -1}})
+
+	cursor, err := r.db.Collection(sessionCollection).Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var sessions []*model.Session
+	for cursor.Next(ctx) {
+		var session model.Session
+		if err := cursor.Decode(&session); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, &session)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+func (r *sessionMongoRepository) ListSessionsByUserID(
+	ctx context.Context,
+	userID string,
+) ([]*model.Session, error) {
+	findOptions := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := r.db.Collection(sessionCollection).Find(ctx, bson.M{"user_id": userID}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []*model.Session
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
 func (r *sessionMongoRepository) UpdateTokens(
 	ctx context.Context,
 	id string,
@@ -95,3 +199,113 @@ func (r *sessionMongoRepository) UpdateTokens(
 
 	return &session, nil
 }
+
+func (r *sessionMongoRepository) RotateTokens(
+	ctx context.Context,
+	id string,
+	currentRefreshToken string,
+	params UpdateTokensParams,
+) (*model.Session, error) {
+	objectID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	result := r.db.Collection(sessionCollection).FindOneAndUpdate(
+		ctx,
+		bson.M{
+			"_id":           objectID,
+			"refresh_token": currentRefreshToken,
+			"revoked_at":    nil,
+		},
+		bson.M{"$set": bson.M{
+			"access_token":             params.AccessToken,
+			"refresh_token":            params.RefreshToken,
+			"access_token_expires_at":  params.AccessTokenExpiresAt,
+			"refresh_token_expires_at": params.RefreshTokenExpiresAt,
+			"updated_at":               time.Now(),
+		}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	)
+	if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	var session model.Session
+	if err := result.Decode(&session); err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+func (r *sessionMongoRepository) RevokeSession(ctx context.Context, id string) error {
+	objectID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	_, err = r.db.Collection(sessionCollection).UpdateOne(
+		ctx,
+		bson.M{"_id": objectID, "revoked_at": nil},
+		bson.M{"$set": bson.M{
+			"revoked_at": now,
+			"updated_at": now,
+		}},
+	)
+	return err
+}
+
+func (r *sessionMongoRepository) RevokeSessionsByUserID(ctx context.Context, userID string) error {
+	now := time.Now()
+	_, err := r.db.Collection(sessionCollection).UpdateMany(
+		ctx,
+		bson.M{"user_id": userID, "revoked_at": nil},
+		bson.M{"$set": bson.M{
+			"revoked_at": now,
+			"updated_at": now,
+		}},
+	)
+	return err
+}
+
+func (r *sessionMongoRepository) RevokeOtherSessionsByUserID(
+	ctx context.Context,
+	userID string,
+	keepSessionID string,
+) error {
+	keepObjectID, err := bson.ObjectIDFromHex(keepSessionID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	_, err = r.db.Collection(sessionCollection).UpdateMany(
+		ctx,
+		bson.M{"user_id": userID, "_id": bson.M{"$ne": keepObjectID}, "revoked_at": nil},
+		bson.M{"$set": bson.M{
+			"revoked_at": now,
+			"updated_at": now,
+		}},
+	)
+	return err
+}
+
+func (r *sessionMongoRepository) RevokeSessionsByClientID(ctx context.Context, clientID, userID string) error {
+	filter := bson.M{"client_id": clientID, "revoked_at": nil}
+	if userID != "" {
+		filter["user_id"] = userID
+	}
+
+	now := time.Now()
+	_, err := r.db.Collection(sessionCollection).UpdateMany(
+		ctx,
+		filter,
+		bson.M{"$set": bson.M{
+			"revoked_at": now,
+			"updated_at": now,
+		}},
+	)
+	return err
+}
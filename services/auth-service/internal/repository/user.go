@@ -21,13 +21,51 @@ type UserRepository interface {
 	UpdateUser(ctx context.Context, id string, params UpdateUserParams) (*model.User, error)
 	DeleteUser(ctx context.Context, id string) (*model.User, error)
 	ListUsers(ctx context.Context, params FilterUsersParams) ([]*model.User, error)
+	IncrementVerificationAttempts(ctx context.Context, id string) error
+
+	// UseTOTPStep records the time step of an accepted TOTP code. It returns false if a code
+	// of the same or a later step has already been used.
+	UseTOTPStep(ctx context.Context, id string, step int64) (bool, error)
+
+	// ConsumeRecoveryCode removes the recovery code with the given hash. It returns false
+	// if the user has no such code.
+	ConsumeRecoveryCode(ctx context.Context, id string, codeHash string) (bool, error)
+
+	// ChangeEmail changes the email address of the user and of their email identity in one
+	// transaction, as long as the user still has oldEmail. It returns mongo.ErrNoDocuments if the
+	// address has changed in the meantime, and a duplicate key error if newEmail is taken.
+	ChangeEmail(ctx context.Context, id string, oldEmail, newEmail string) error
+
+	// ScheduleUserDeletion soft-deletes the user until purgeAt.
+	ScheduleUserDeletion(ctx context.Context, id string, deletedAt, purgeAt time.Time) error
+
+	// RestoreUser cancels the scheduled deletion of the user. It returns false if the user was not
+	// scheduled for deletion.
+	RestoreUser(ctx context.Context, id string) (bool, error)
+
+	// ListUsersDueForPurge returns up to limit users whose deletion was scheduled for before the
+	// given time.
+	ListUsersDueForPurge(ctx context.Context, before time.Time, limit int64) ([]*model.User, error)
 }
 
 // UpdateUserParams defines the optional parameters for updating a user.
 // Only the fields that are not nil will be updated.
 type UpdateUserParams struct {
-	Email        *string
-	PasswordHash *string
+	Email                     *string
+	PasswordHash              *string
+	Verified                  *bool
+	VerificationCode          *string
+	VerificationCodeExpiresAt *time.Time
+	VerificationCodeSentAt    *time.Time
+	VerificationAttempts      *int
+	TOTPEnabled               *bool
+	TOTPSecret                *string
+	TOTPLastUsedStep          *int64
+	RecoveryCodes             *[]string
+	LoginAlerts               *string
+	PasswordResetRequired     *bool
+	Roles                     *[]string
+	Permissions               *[]string
 }
 
 // FilterUsersParams defines the parameters for filtering and paginating users.
@@ -54,6 +92,10 @@ func NewUserMongoRepository(ctx context.Context, logger *zerolog.Logger, db *mon
 			Keys:    bson.D{{Key: "email", Value: 1}},
 			Options: options.Index().SetUnique(true),
 		},
+		{
+			Keys:    bson.D{{Key: "purge_at", Value: 1}},
+			Options: options.Index().SetSparse(true),
+		},
 	}
 
 	_, err := collection.Indexes().CreateMany(ctx, indexes)
@@ -134,6 +176,45 @@ func (r *userMongoRepository) UpdateUser(
 	if params.PasswordHash != nil {
 		updateMap["password_hash"] = params.PasswordHash
 	}
+	if params.Verified != nil {
+		updateMap["verified"] = params.Verified
+	}
+	if params.VerificationCode != nil {
+		updateMap["verification_code"] = params.VerificationCode
+	}
+	if params.VerificationCodeExpiresAt != nil {
+		updateMap["verification_code_expires_at"] = params.VerificationCodeExpiresAt
+	}
+	if params.VerificationCodeSentAt != nil {
+		updateMap["verification_code_sent_at"] = params.VerificationCodeSentAt
+	}
+	if params.VerificationAttempts != nil {
+		updateMap["verification_attempts"] = params.VerificationAttempts
+	}
+	if params.TOTPEnabled != nil {
+		updateMap["totp_enabled"] = params.TOTPEnabled
+	}
+	if params.TOTPSecret != nil {
+		updateMap["totp_secret"] = params.TOTPSecret
+	}
+	if params.TOTPLastUsedStep != nil {
+		updateMap["totp_last_used_step"] = params.TOTPLastUsedStep
+	}
+	if params.RecoveryCodes != nil {
+		updateMap["recovery_codes"] = params.RecoveryCodes
+	}
+	if params.LoginAlerts != nil {
+		updateMap["login_alerts"] = params.LoginAlerts
+	}
+	if params.PasswordResetRequired != nil {
+		updateMap["password_reset_required"] = params.PasswordResetRequired
+	}
+	if params.Roles != nil {
+		updateMap["roles"] = params.Roles
+	}
+	if params.Permissions != nil {
+		updateMap["permissions"] = params.Permissions
+	}
 
 	if len(updateMap) == 0 {
 		return nil, errors.New("no user fields to update")
@@ -232,3 +313,173 @@ func (r *userMongoRepository) ListUsers(ctx context.Context, params FilterUsersP
 
 	return users, nil
 }
+
+func (r *userMongoRepository) IncrementVerificationAttempts(ctx context.Context, id string) error {
+	objectID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Collection(userCollection).UpdateOne(
+		ctx,
+		bson.M{"_id": objectID},
+		bson.M{
+			"$inc": bson.M{"verification_attempts": 1},
+			"$set": bson.M{"updated_at": time.Now()},
+		},
+	)
+	return err
+}
+
+func (r *userMongoRepository) UseTOTPStep(ctx context.Context, id string, step int64) (bool, error) {
+	objectID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return false, err
+	}
+
+	// Only advance the step, so concurrent requests with the same code cannot both succeed
+	result, err := r.db.Collection(userCollection).UpdateOne(
+		ctx,
+		bson.M{
+			"_id": objectID,
+			"$or": bson.A{
+				bson.M{"totp_last_used_step": bson.M{"$lt": step}},
+				bson.M{"totp_last_used_step": bson.M{"$exists": false}},
+			},
+		},
+		bson.M{"$set": bson.M{"totp_last_used_step": step, "updated_at": time.Now()}},
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return result.ModifiedCount == 1, nil
+}
+
+func (r *userMongoRepository) ConsumeRecoveryCode(ctx context.Context, id string, codeHash string) (bool, error) {
+	objectID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := r.db.Collection(userCollection).UpdateOne(
+		ctx,
+		bson.M{"_id": objectID, "recovery_codes": codeHash},
+		bson.M{
+			"$pull": bson.M{"recovery_codes": codeHash},
+			"$set":  bson.M{"updated_at": time.Now()},
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return result.ModifiedCount == 1, nil
+}
+
+func (r *userMongoRepository) ChangeEmail(ctx context.Context, id string, oldEmail, newEmail string) error {
+	objectID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	session, err := r.db.Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(ctx context.Context) (any, error) {
+		now := time.Now()
+
+		// The new address was proven to be the user's by following the link sent to it
+		result, err := r.db.Collection(userCollection).UpdateOne(
+			ctx,
+			bson.M{"_id": objectID, "email": oldEmail},
+			bson.M{"$set": bson.M{"email": newEmail, "verified": true, "updated_at": now}},
+		)
+		if err != nil {
+			return nil, err
+		}
+		if result.MatchedCount == 0 {
+			return nil, mongo.ErrNoDocuments
+		}
+
+		_, err = r.db.Collection(identityCollection).UpdateOne(
+			ctx,
+			bson.M{"user_id": id, "provider": model.IdentityProviderEmail},
+			bson.M{"$set": bson.M{"email": newEmail, "updated_at": now}},
+		)
+		return nil, err
+	})
+	return err
+}
+
+func (r *userMongoRepository) ScheduleUserDeletion(
+	ctx context.Context,
+	id string,
+	deletedAt, purgeAt time.Time,
+) error {
+	objectID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.db.Collection(userCollection).UpdateOne(
+		ctx,
+		bson.M{"_id": objectID},
+		bson.M{"$set": bson.M{"deleted_at": deletedAt, "purge_at": purgeAt, "updated_at": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (r *userMongoRepository) RestoreUser(ctx context.Context, id string) (bool, error) {
+	objectID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := r.db.Collection(userCollection).UpdateOne(
+		ctx,
+		bson.M{"_id": objectID, "deleted_at": bson.M{"$exists": true}},
+		bson.M{
+			"$unset": bson.M{"deleted_at": "", "purge_at": ""},
+			"$set":   bson.M{"updated_at": time.Now()},
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return result.ModifiedCount == 1, nil
+}
+
+func (r *userMongoRepository) ListUsersDueForPurge(
+	ctx context.Context,
+	before time.Time,
+	limit int64,
+) ([]*model.User, error) {
+	cursor, err := r.db.Collection(userCollection).Find(
+		ctx,
+		bson.M{"purge_at": bson.M{"$lte": before}},
+		options.Find().SetSort(bson.D{{Key: "purge_at", Value: 1}}).SetLimit(limit),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var users []*model.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/model"
+)
+
+// WebAuthnCredentialRepository defines the interface for passkey-related database operations.
+type WebAuthnCredentialRepository interface {
+	// CreateCredential stores a newly registered passkey.
+	CreateCredential(ctx context.Context, credential *model.WebAuthnCredential) (*model.WebAuthnCredential, error)
+
+	// GetCredentialByCredentialID returns the passkey with the given base64url encoded credential ID.
+	GetCredentialByCredentialID(ctx context.Context, credentialID string) (*model.WebAuthnCredential, error)
+
+	// GetCredentialsByUserID returns all passkeys of a user.
+	GetCredentialsByUserID(ctx context.Context, userID string) ([]model.WebAuthnCredential, error)
+
+	// UpdateSignCount records a login with the passkey. It only succeeds while the stored counter
+	// still equals oldSignCount and returns mongo.ErrNoDocuments otherwise, so two logins can never
+	// both be accepted with the same counter value.
+	UpdateSignCount(ctx context.Context, id string, oldSignCount, newSignCount uint32, backedUp bool) error
+}
+
+const webAuthnCredentialCollection = "webauthn_credentials"
+
+type webAuthnCredentialMongoRepository struct {
+	db *mongo.Database
+}
+
+// NewWebAuthnCredentialMongoRepository creates a new MongoDB repository for passkeys.
+func NewWebAuthnCredentialMongoRepository(
+	ctx context.Context,
+	logger *zerolog.Logger,
+	db *mongo.Database,
+) WebAuthnCredentialRepository {
+	collection := db.Collection(webAuthnCredentialCollection)
+
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "credential_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}},
+		},
+	}
+
+	_, err := collection.Indexes().CreateMany(ctx, indexes)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create WebAuthn credential indexes")
+	}
+
+	return &webAuthnCredentialMongoRepository{
+		db: db,
+	}
+}
+
+func (r *webAuthnCredentialMongoRepository) CreateCredential(
+	ctx context.Context,
+	credential *model.WebAuthnCredential,
+) (*model.WebAuthnCredential, error) {
+	now := time.Now()
+	credential.CreatedAt = now
+	credential.UpdatedAt = now
+
+	result, err := r.db.Collection(webAuthnCredentialCollection).InsertOne(ctx, credential)
+	if err != nil {
+		return nil, err
+	}
+
+	if objectID, ok := result.InsertedID.(bson.ObjectID); ok {
+		credential.ID = objectID
+	} else {
+		return nil, errors.New("failed to convert inserted ID to ObjectID")
+	}
+
+	return credential, nil
+}
+
+func (r *webAuthnCredentialMongoRepository) GetCredentialByCredentialID(
+	ctx context.Context,
+	credentialID string,
+) (*model.WebAuthnCredential, error) {
+	result := r.db.Collection(webAuthnCredentialCollection).FindOne(ctx, bson.M{"credential_id": credentialID})
+	if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	var credential model.WebAuthnCredential
+	if err := result.Decode(&credential); err != nil {
+		return nil, err
+	}
+
+	return &credential, nil
+}
+
+func (r *webAuthnCredentialMongoRepository) GetCredentialsByUserID(
+	ctx context.Context,
+	userID string,
+) ([]model.WebAuthnCredential, error) {
+	cursor, err := r.db.Collection(webAuthnCredentialCollection).Find(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+
+	var credentials []model.WebAuthnCredential
+	if err := cursor.All(ctx, &credentials); err != nil {
+		return nil, err
+	}
+
+	return credentials, nil
+}
+
+func (r *webAuthnCredentialMongoRepository) UpdateSignCount(
+	ctx context.Context,
+	id string,
+	oldSignCount uint32,
+	newSignCount uint32,
+	backedUp bool,
+) error {
+	objectID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	result, err := r.db.Collection(webAuthnCredentialCollection).UpdateOne(
+		ctx,
+		bson.M{"_id": objectID, "sign_count": oldSignCount},
+		bson.M{"$set": bson.M{
+			"sign_count":   newSignCount,
+			"backed_up":    backedUp,
+			"last_used_at": now,
+			"updated_at":   now,
+		}},
+	)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
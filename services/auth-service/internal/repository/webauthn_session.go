@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/model"
+)
+
+// WebAuthnSessionRepository defines the interface for storing the challenges of pending passkey ceremonies.
+type WebAuthnSessionRepository interface {
+	// CreateWebAuthnSession stores the challenge of a ceremony that has been sent to the client.
+	CreateWebAuthnSession(ctx context.Context, session *model.WebAuthnSession) (*model.WebAuthnSession, error)
+
+	// ConsumeWebAuthnSession deletes and returns the unexpired session of the ceremony with the given
+	// challenge, so a challenge can only ever be used once.
+	ConsumeWebAuthnSession(ctx context.Context, challenge, ceremony string) (*model.WebAuthnSession, error)
+}
+
+const webAuthnSessionCollection = "webauthn_sessions"
+
+type webAuthnSessionMongoRepository struct {
+	db *mongo.Database
+}
+
+// NewWebAuthnSessionMongoRepository creates a new MongoDB repository for WebAuthn sessions.
+func NewWebAuthnSessionMongoRepository(
+	ctx context.Context,
+	logger *zerolog.Logger,
+	db *mongo.Database,
+) WebAuthnSessionRepository {
+	collection := db.Collection(webAuthnSessionCollection)
+
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "challenge", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0), // TTL index
+		},
+	}
+
+	_, err := collection.Indexes().CreateMany(ctx, indexes)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create WebAuthn session indexes")
+	}
+
+	return &webAuthnSessionMongoRepository{
+		db: db,
+	}
+}
+
+func (r *webAuthnSessionMongoRepository) CreateWebAuthnSession(
+	ctx context.Context,
+	session *model.WebAuthnSession,
+) (*model.WebAuthnSession, error) {
+	session.CreatedAt = time.Now()
+
+	result, err := r.db.Collection(webAuthnSessionCollection).InsertOne(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+
+	if objectID, ok := result.InsertedID.(bson.ObjectID); ok {
+		session.ID = objectID
+	} else {
+		return nil, errors.New("failed to convert inserted ID to ObjectID")
+	}
+
+	return session, nil
+}
+
+func (r *webAuthnSessionMongoRepository) ConsumeWebAuthnSession(
+	ctx context.Context,
+	challenge string,
+	ceremony string,
+) (*model.WebAuthnSession, error) {
+	// The TTL monitor only runs periodically, so expired sessions must be filtered out explicitly
+	result := r.db.Collection(webAuthnSessionCollection).FindOneAndDelete(ctx, bson.M{
+		"challenge":  challenge,
+		"ceremony":   ceremony,
+		"expires_at": bson.M{"$gt": time.Now()},
+	})
+	if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	var session model.WebAuthnSession
+	if err := result.Decode(&session); err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
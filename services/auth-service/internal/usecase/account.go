@@ -0,0 +1,160 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/config"
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/model"
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/repository"
+	"github.com/vasapolrittideah/money-tracker-api/shared/contract"
+	"github.com/vasapolrittideah/money-tracker-api/shared/mailer"
+)
+
+// AccountUsecase defines the business logic for deleting accounts.
+type AccountUsecase interface {
+	// DeleteAccount re-authenticates the user, signs out every session and schedules the account to
+	// be purged once the grace period has passed. Logging in before then restores the account.
+	// It returns when the account will be purged.
+	DeleteAccount(ctx context.Context, params DeleteAccountParams) (time.Time, error)
+
+	// PurgeDeletedAccounts erases one batch of accounts whose grace period has passed, together with
+	// everything stored for them, and emits an account deleted event for each. It returns the number
+	// of accounts purged.
+	PurgeDeletedAccounts(ctx context.Context) (int, error)
+}
+
+// DeleteAccountParams defines the parameters for deleting the account of a logged in user.
+type DeleteAccountParams struct {
+	UserID    string
+	SessionID string
+	Password  string
+	Client    ClientInfo
+}
+
+type accountUsecase struct {
+	logger          *zerolog.Logger
+	userRepo        repository.UserRepository
+	sessionRepo     repository.SessionRepository
+	accountRepo     repository.AccountRepository
+	reauthenticator *Reauthenticator
+	authEvents      *AuthEventRecorder
+	mailer          *mailer.Mailer
+	authServiceCfg  *config.AuthServiceConfig
+}
+
+// NewAccountUsecase creates a new instance of AccountUsecase.
+func NewAccountUsecase(
+	logger *zerolog.Logger,
+	userRepo repository.UserRepository,
+	sessionRepo repository.SessionRepository,
+	accountRepo repository.AccountRepository,
+	reauthenticator *Reauthenticator,
+	authEvents *AuthEventRecorder,
+	mailer *mailer.Mailer,
+	authServiceCfg *config.AuthServiceConfig,
+) AccountUsecase {
+	return &accountUsecase{
+		logger:          logger,
+		userRepo:        userRepo,
+		sessionRepo:     sessionRepo,
+		accountRepo:     accountRepo,
+		reauthenticator: reauthenticator,
+		authEvents:      authEvents,
+		mailer:          mailer,
+		authServiceCfg:  authServiceCfg,
+	}
+}
+
+func (u *accountUsecase) DeleteAccount(ctx context.Context, params DeleteAccountParams) (_ time.Time, err error) {
+	defer func() {
+		u.authEvents.Record(ctx, &model.AuthEvent{
+			Type:   model.AuthEventAccountDeletion,
+			UserID: params.UserID,
+		}, params.Client, err)
+	}()
+
+	user, err := u.userRepo.GetUser(ctx, params.UserID)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if err := u.reauthenticator.reauthenticate(ctx, user, params.SessionID, params.Password); err != nil {
+		return time.Time{}, err
+	}
+
+	now := time.Now()
+	purgeAt := now.Add(u.authServiceCfg.AccountDeletion.GracePeriod)
+	if err := u.userRepo.ScheduleUserDeletion(ctx, user.ID.Hex(), now, purgeAt); err != nil {
+		return time.Time{}, err
+	}
+
+	if err := u.sessionRepo.RevokeSessionsByUserID(ctx, user.ID.Hex()); err != nil {
+		return time.Time{}, err
+	}
+
+	// The deletion has been scheduled already, so a failed email must not fail the request
+	if err := u.sendAccountDeletionNotice(user, purgeAt); err != nil {
+		u.logger.Error().Err(err).Str("userID", user.ID.Hex()).Msg("failed to send account deletion notice")
+	}
+
+	return purgeAt, nil
+}
+
+func (u *accountUsecase) PurgeDeletedAccounts(ctx context.Context) (int, error) {
+	now := time.Now()
+	users, err := u.userRepo.ListUsersDueForPurge(ctx, now, u.authServiceCfg.AccountDeletion.PurgeBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, user := range users {
+		event := &model.Event{
+			Type: contract.EventTypeAccountDeleted,
+			Payload: contract.AccountDeletedEvent{
+				UserID:    user.ID.Hex(),
+				DeletedAt: *user.DeletedAt,
+				PurgedAt:  now,
+			},
+		}
+
+		accountKey, _ := loginAttemptKeys(user.Email, ClientInfo{})
+		attemptKeys := []string{accountKey, secondFactorAttemptKey(user.ID.Hex())}
+		if err := u.accountRepo.PurgeAccount(ctx, user.ID.Hex(), attemptKeys, event); err != nil {
+			// Restored in the meantime, or purged by another instance
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				continue
+			}
+
+			// Left for the next run, so one failing account does not hold up the others
+			u.logger.Error().Err(err).Str("userID", user.ID.Hex()).Msg("failed to purge account")
+			continue
+		}
+
+		purged++
+	}
+
+	return purged, nil
+}
+
+func (u *accountUsecase) sendAccountDeletionNotice(user *model.User, purgeAt time.Time) error {
+	htmlBody := fmt.Sprintf(`
+		<p>Hi,</p>
+		<p>Your account has been scheduled for deletion and every device signed in to it has been
+		signed out. Your account and all of its data will be permanently deleted on %s.</p>
+
+		<p>If you change your mind, simply log in before then to keep your account.</p>
+		<p>If you did not request this, log in right away to keep your account, and change your password.</p>
+
+		<p>Thank you,</p>
+		<p>Money Tracker Team</p>
+	`, purgeAt.UTC().Format("January 2, 2006 15:04 MST"))
+
+	return u.mailer.SendHTML([]string{user.Email}, "Your Account Will Be Deleted", htmlBody)
+}
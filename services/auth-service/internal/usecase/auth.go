@@ -3,9 +3,8 @@ package usecase
 import (
 	"context"
 	"errors"
-	"time"
 
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/rs/zerolog"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 
 	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/config"
@@ -13,86 +12,301 @@ import (
 	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/repository"
 	authtypes "github.com/vasapolrittideah/money-tracker-api/services/auth-service/pkg/types"
 	"github.com/vasapolrittideah/money-tracker-api/shared/auth"
+	"github.com/vasapolrittideah/money-tracker-api/shared/mailer"
+	"github.com/vasapolrittideah/money-tracker-api/shared/provider"
 	"github.com/vasapolrittideah/money-tracker-api/shared/security"
+	"github.com/vasapolrittideah/money-tracker-api/shared/webauthn"
 )
 
 // AuthUsecase defines the interface for authentication-related use cases.
 type AuthUsecase interface {
-	Login(ctx context.Context, params LoginParams) (*authtypes.Tokens, error)
+	// Login authenticates a user with their password. Users with TOTP enabled get an MFA
+	// challenge token instead of the token pair. Repeated failures for an account or IP address
+	// are slowed down and eventually locked out.
+	Login(ctx context.Context, params LoginParams) (*LoginResult, error)
+
+	// Register creates a new user and emails them a verification code. It returns no tokens
+	// when the unverified login policy does not let the new user log in yet.
 	Register(ctx context.Context, params RegisterParams) (*authtypes.Tokens, error)
+
+	// LoginWithGoogle authenticates a user with a Google ID token, creating the user on first sign-in.
+	LoginWithGoogle(ctx context.Context, params LoginWithGoogleParams) (*LoginResult, error)
+
+	// StartOAuthLogin starts a login with an external provider and returns the URL to send the user to.
+	StartOAuthLogin(ctx context.Context, providerName string) (string, error)
+
+	// CompleteOAuthLogin finishes a flow started with StartOAuthLogin or IdentityUsecase.LinkIdentity
+	// once the provider redirects back. It either logs the user in or links the external account.
+	CompleteOAuthLogin(ctx context.Context, params CompleteOAuthLoginParams) (*CompleteOAuthLoginResult, error)
+
+	// BeginPasskeyLogin starts a login with a passkey and returns the options to ask the authenticator with.
+	BeginPasskeyLogin(ctx context.Context) (*PasskeyLoginOptions, error)
+
+	// FinishPasskeyLogin verifies the authenticator's response to BeginPasskeyLogin and logs the user in.
+	FinishPasskeyLogin(ctx context.Context, params FinishPasskeyLoginParams) (*authtypes.Tokens, error)
+
+	// VerifyMFA completes a login that returned an MFA challenge, using a TOTP or recovery code.
+	VerifyMFA(ctx context.Context, params VerifyMFAParams) (*authtypes.Tokens, error)
+
+	// ChangePassword replaces the password of a logged in user after checking their current one,
+	// signs out every other session, deletes their personal access tokens and emails the user a
+	// notice. Wrong current passwords count towards the login throttle.
+	ChangePassword(ctx context.Context, params ChangePasswordParams) error
+
+	// RefreshTokens exchanges a refresh token for a new token pair and rotates both tokens on the session.
+	RefreshTokens(ctx context.Context, refreshToken string) (*authtypes.Tokens, error)
+
+	// JSONWebKeySet returns the public keys access tokens can be verified with.
+	JSONWebKeySet() auth.JSONWebKeySet
+
+	// Introspect reports whether an access token is still valid, including whether its session has
+	// been revoked, and describes it. Invalid tokens are reported as inactive rather than as an error.
+	Introspect(ctx context.Context, token string) (*TokenIntrospection, error)
 }
 
 // LoginParams defines the parameters for user login.
 type LoginParams struct {
 	Email    string
 	Password string
+	Client   ClientInfo
 }
 
 // RegisterParams defines the parameters for user registration.
 type RegisterParams struct {
 	Email    string
 	Password string
+	Client   ClientInfo
+}
+
+// LoginWithGoogleParams defines the parameters for signing in with Google.
+type LoginWithGoogleParams struct {
+	IDToken string
+	Client  ClientInfo
+}
+
+// CompleteOAuthLoginParams defines the parameters of an external provider's callback.
+type CompleteOAuthLoginParams struct {
+	Provider string
+	State    string
+	Code     string
+	Client   ClientInfo
+}
+
+// VerifyMFAParams defines the parameters for completing a login with a second factor.
+type VerifyMFAParams struct {
+	MFAToken string
+	Code     string
+	Client   ClientInfo
+}
+
+// LoginResult holds either the token pair of a login or, when a second factor is required,
+// the MFA challenge token to pass to VerifyMFA.
+type LoginResult struct {
+	Tokens   *authtypes.Tokens
+	MFAToken string
+}
+
+// CompleteOAuthLoginResult holds the result of a login, or the identity of a linked external account.
+type CompleteOAuthLoginResult struct {
+	Login          *LoginResult
+	LinkedIdentity *model.Identity
+}
+
+// ClientInfo describes the device a session is created from.
+type ClientInfo struct {
+	IPAddress string
+	UserAgent string
+	// DeviceID is the secret the device was given by the API gateway, empty if it has none.
+	DeviceID string
 }
 
 var (
 	ErrUserAlreadyExists  = errors.New("user already exists")
 	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrEmailNotVerified   = errors.New("email has not been verified")
+	ErrInvalidIDToken     = errors.New("invalid id token")
+
+	ErrUnknownProvider          = errors.New("unknown provider")
+	ErrInvalidOAuthState        = errors.New("invalid or expired oauth state")
+	ErrInvalidAuthorizationCode = errors.New("invalid authorization code")
+	ErrProviderEmailRequired    = errors.New("provider did not share an email address")
+
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token has already been used")
 )
 
 type authUsecase struct {
-	identityRepo   repository.IdentityRepository
-	sessionRepo    repository.SessionRepository
-	userRepo       repository.UserRepository
-	jwtAuth        auth.JWTAuthenticator
-	authServiceCfg *config.AuthServiceConfig
+	logger                   *zerolog.Logger
+	identityRepo             repository.IdentityRepository
+	sessionRepo              repository.SessionRepository
+	userRepo                 repository.UserRepository
+	oauthStateRepo           repository.OAuthStateRepository
+	mfaChallengeRepo         repository.MFAChallengeRepository
+	webAuthnCredentialRepo   repository.WebAuthnCredentialRepository
+	webAuthnSessionRepo      repository.WebAuthnSessionRepository
+	personalAccessTokenRepo  repository.PersonalAccessTokenRepository
+	loginThrottle            *LoginThrottle
+	sessionIssuer            *SessionIssuer
+	emailVerificationUsecase EmailVerificationUsecase
+	authEvents               *AuthEventRecorder
+	googleProvider           *provider.GoogleOAuthProvider
+	providerRegistry         *provider.Registry
+	encryptor                *security.Encryptor
+	relyingParty             *webauthn.RelyingParty
+	mailer                   *mailer.Mailer
+	passwordPolicy           *security.PasswordPolicy
+	passwordHasher           *security.PasswordHasher
+	jwtAuth                  auth.JWTAuthenticator
+	accessTokenKeys          *auth.KeySet
+	authServiceCfg           *config.AuthServiceConfig
 }
 
 func NewAuthUsecase(
+	logger *zerolog.Logger,
 	identityRepo repository.IdentityRepository,
 	sessionRepo repository.SessionRepository,
 	userRepo repository.UserRepository,
+	oauthStateRepo repository.OAuthStateRepository,
+	mfaChallengeRepo repository.MFAChallengeRepository,
+	webAuthnCredentialRepo repository.WebAuthnCredentialRepository,
+	webAuthnSessionRepo repository.WebAuthnSessionRepository,
+	personalAccessTokenRepo repository.PersonalAccessTokenRepository,
+	loginThrottle *LoginThrottle,
+	sessionIssuer *SessionIssuer,
+	emailVerificationUsecase EmailVerificationUsecase,
+	authEvents *AuthEventRecorder,
+	googleProvider *provider.GoogleOAuthProvider,
+	providerRegistry *provider.Registry,
+	encryptor *security.Encryptor,
+	relyingParty *webauthn.RelyingParty,
+	mailer *mailer.Mailer,
+	passwordPolicy *security.PasswordPolicy,
+	passwordHasher *security.PasswordHasher,
 	jwtAuth auth.JWTAuthenticator,
+	accessTokenKeys *auth.KeySet,
 	authServiceCfg *config.AuthServiceConfig,
 ) AuthUsecase {
 	return &authUsecase{
-		identityRepo:   identityRepo,
-		sessionRepo:    sessionRepo,
-		userRepo:       userRepo,
-		jwtAuth:        jwtAuth,
-		authServiceCfg: authServiceCfg,
+		logger:                   logger,
+		identityRepo:             identityRepo,
+		sessionRepo:              sessionRepo,
+		userRepo:                 userRepo,
+		oauthStateRepo:           oauthStateRepo,
+		mfaChallengeRepo:         mfaChallengeRepo,
+		webAuthnCredentialRepo:   webAuthnCredentialRepo,
+		webAuthnSessionRepo:      webAuthnSessionRepo,
+		personalAccessTokenRepo:  personalAccessTokenRepo,
+		loginThrottle:            loginThrottle,
+		sessionIssuer:            sessionIssuer,
+		emailVerificationUsecase: emailVerificationUsecase,
+		authEvents:               authEvents,
+		googleProvider:           googleProvider,
+		providerRegistry:         providerRegistry,
+		encryptor:                encryptor,
+		relyingParty:             relyingParty,
+		mailer:                   mailer,
+		passwordPolicy:           passwordPolicy,
+		passwordHasher:           passwordHasher,
+		jwtAuth:                  jwtAuth,
+		accessTokenKeys:          accessTokenKeys,
+		authServiceCfg:           authServiceCfg,
 	}
 }
 
-func (u *authUsecase) Login(ctx context.Context, params LoginParams) (*authtypes.Tokens, error) {
-	user, err := u.userRepo.GetUserByEmail(ctx, params.Email)
-	if err != nil {
-		if errors.Is(err, mongo.ErrNoDocuments) {
-			return nil, ErrInvalidCredentials
-		}
+func (u *authUsecase) Login(ctx context.Context, params LoginParams) (result *LoginResult, err error) {
+	var user *model.User
+	defer func() {
+		u.sessionIssuer.recordLogin(ctx, model.AuthMethodPassword, params.Email, user, result, params.Client, err)
+	}()
 
+	existingUser, err := u.userRepo.GetUserByEmail(ctx, params.Email)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
 		return nil, err
 	}
 
-	if ok, err := security.VerifyPassword(params.Password, user.PasswordHash); err != nil {
+	// Unknown emails are counted like wrong passwords, so lockouts do not reveal which are registered
+	accountKey, ipKey := loginAttemptKeys(params.Email, params.Client)
+	if err := u.loginThrottle.reserve(ctx, existingUser, accountKey, ipKey); err != nil {
+		return nil, err
+	}
+
+	if existingUser == nil {
+		return nil, ErrInvalidCredentials
+	}
+	user = existingUser
+
+	// Users who signed up with an external provider have no password to log in with
+	if user.PasswordHash == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	ok, needsRehash, err := u.passwordHasher.Verify(params.Password, user.PasswordHash)
+	if err != nil {
 		return nil, err
-	} else if !ok {
+	}
+	if !ok {
 		return nil, ErrInvalidCredentials
 	}
 
-	if err := u.identityRepo.UpdateLastLogin(ctx, user.ID.Hex()); err != nil {
+	if err := u.loginThrottle.release(ctx, accountKey, ipKey); err != nil {
 		return nil, err
 	}
 
-	return u.createAuthSession(ctx, user.ID.Hex())
+	// Set when a sign-in was reported, as whoever made it knows the password
+	if user.PasswordResetRequired {
+		return nil, ErrPasswordResetRequired
+	}
+
+	if needsRehash {
+		u.rehashPassword(ctx, user, params.Password)
+	}
+
+	if !user.Verified && u.authServiceCfg.EmailVerification.LoginPolicy == config.UnverifiedLoginDeny {
+		return nil, ErrEmailNotVerified
+	}
+
+	return u.sessionIssuer.completeLogin(ctx, user, params.Client)
 }
 
-func (u *authUsecase) Register(ctx context.Context, params RegisterParams) (*authtypes.Tokens, error) {
-	passwordHash, err := security.HashPassword(params.Password)
+// rehashPassword upgrades the hash of a password that was just verified to the current hashing
+// parameters. The login has succeeded already, so failures are only logged and the upgrade is
+// retried on the next login.
+func (u *authUsecase) rehashPassword(ctx context.Context, user *model.User, password string) {
+	passwordHash, err := u.passwordHasher.Hash(password)
 	if err != nil {
+		u.logger.Error().Err(err).Str("userID", user.ID.Hex()).Msg("failed to rehash password")
+		return
+	}
+
+	if _, err := u.userRepo.UpdateUser(ctx, user.ID.Hex(), repository.UpdateUserParams{
+		PasswordHash: &passwordHash,
+	}); err != nil {
+		u.logger.Error().Err(err).Str("userID", user.ID.Hex()).Msg("failed to store rehashed password")
+	}
+}
+
+func (u *authUsecase) Register(ctx context.Context, params RegisterParams) (tokens *authtypes.Tokens, err error) {
+	var user *model.User
+	defer func() {
+		event := &model.AuthEvent{Type: model.AuthEventRegister, Method: model.AuthMethodPassword}
+		if user != nil {
+			event.UserID = user.ID.Hex()
+		} else {
+			event.Email = params.Email
+		}
+		u.authEvents.Record(ctx, event, params.Client, err)
+	}()
+
+	if err := validateNewPassword(u.passwordPolicy, params.Password, params.Email); err != nil {
 		return nil, err
 	}
 
-	user, err := u.userRepo.CreateUser(ctx, &model.User{
+	passwordHash, err := u.passwordHasher.Hash(params.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err = u.userRepo.CreateUser(ctx, &model.User{
 		Email:        params.Email,
 		PasswordHash: passwordHash,
 	})
@@ -106,75 +320,40 @@ func (u *authUsecase) Register(ctx context.Context, params RegisterParams) (*aut
 
 	if _, err := u.identityRepo.CreateIdentity(ctx, &model.Identity{
 		UserID:     user.ID.Hex(),
-		Provider:   "email",
+		Provider:   model.IdentityProviderEmail,
 		ProviderID: "",
 		Email:      user.Email,
 	}); err != nil {
 		return nil, err
 	}
 
-	return u.createAuthSession(ctx, user.ID.Hex())
-}
-
-func (u *authUsecase) createAuthSession(ctx context.Context, userID string) (*authtypes.Tokens, error) {
-	session, err := u.sessionRepo.CreateSession(ctx, &model.Session{UserID: userID})
-	if err != nil {
-		return nil, err
+	// The account already exists at this point, so a failed email must not fail the registration;
+	// the user can ask for the code to be resent.
+	if err := u.emailVerificationUsecase.SendVerificationCode(ctx, user); err != nil {
+		u.logger.Error().Err(err).Str("userID", user.ID.Hex()).Msg("failed to send verification code")
 	}
 
-	accessToken, err := u.generateToken(
-		userID,
-		session.ID.Hex(),
-		u.authServiceCfg.Token.AccessTokenSecret,
-		u.authServiceCfg.Token.AccessTokenExpiresIn,
-	)
-	if err != nil {
-		return nil, err
+	if u.authServiceCfg.EmailVerification.LoginPolicy == config.UnverifiedLoginDeny {
+		return nil, nil
 	}
 
-	refreshToken, err := u.generateToken(
-		userID,
-		session.ID.Hex(),
-		u.authServiceCfg.Token.RefreshTokenSecret,
-		u.authServiceCfg.Token.RefreshTokenExpiresIn,
-	)
+	return u.sessionIssuer.createAuthSession(ctx, user, params.Client)
+}
+
+func (u *authUsecase) RefreshTokens(ctx context.Context, refreshToken string) (*authtypes.Tokens, error) {
+	user, session, err := u.sessionIssuer.sessionFromRefreshToken(ctx, refreshToken)
 	if err != nil {
 		return nil, err
 	}
 
-	now := time.Now()
-	if _, err := u.sessionRepo.UpdateTokens(ctx, session.ID.Hex(), repository.UpdateTokensParams{
-		AccessToken:           accessToken,
-		RefreshToken:          refreshToken,
-		AccessTokenExpiresAt:  now.Add(u.authServiceCfg.Token.AccessTokenExpiresIn),
-		RefreshTokenExpiresAt: now.Add(u.authServiceCfg.Token.RefreshTokenExpiresIn),
-	}); err != nil {
-		return nil, err
+	// Tokens of third-party apps are refreshed by the app, which has to authenticate to do so
+	if session.ClientID != "" {
+		return nil, ErrInvalidRefreshToken
 	}
 
-	return &authtypes.Tokens{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-	}, nil
+	return u.sessionIssuer.rotateTokens(ctx, user, session, refreshToken)
 }
 
-func (u *authUsecase) generateToken(userID, sessionID, secret string, expiresIn time.Duration) (string, error) {
-	now := time.Now()
-	claims := authtypes.JWTClaims{
-		UserID:    userID,
-		SessionID: sessionID,
-		RegisteredClaims: jwt.RegisteredClaims{
-			IssuedAt:  jwt.NewNumericDate(now),
-			ExpiresAt: jwt.NewNumericDate(now.Add(expiresIn)),
-			NotBefore: jwt.NewNumericDate(now),
-			Issuer:    u.authServiceCfg.Token.Issuer,
-			Audience:  jwt.ClaimStrings{u.authServiceCfg.Token.Issuer},
-		},
-	}
-	token, err := u.jwtAuth.GenerateToken(claims, secret)
-	if err != nil {
-		return "", err
-	}
-
-	return token, nil
+func (u *authUsecase) JSONWebKeySet() auth.JSONWebKeySet {
+	return u.accessTokenKeys.JSONWebKeySet()
 }
@@ -0,0 +1,183 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/config"
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/model"
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/repository"
+)
+
+// SecurityEventUsecase defines the business logic for reviewing the security audit log.
+type SecurityEventUsecase interface {
+	// ListSecurityEvents returns a page of the authentication events of a user, most recent first,
+	// and the token of the next page, which is empty on the last page.
+	ListSecurityEvents(ctx context.Context, params ListSecurityEventsParams) ([]*model.AuthEvent, string, error)
+}
+
+// ListSecurityEventsParams defines the parameters for listing the authentication events of a user.
+type ListSecurityEventsParams struct {
+	UserID    string
+	PageSize  int64
+	PageToken string
+}
+
+const (
+	defaultSecurityEventPageSize = 20
+	maxSecurityEventPageSize     = 100
+)
+
+var ErrInvalidPageToken = errors.New("invalid page token")
+
+type securityEventUsecase struct {
+	authEventRepo repository.AuthEventRepository
+}
+
+// NewSecurityEventUsecase creates a new instance of SecurityEventUsecase.
+func NewSecurityEventUsecase(authEventRepo repository.AuthEventRepository) SecurityEventUsecase {
+	return &securityEventUsecase{
+		authEventRepo: authEventRepo,
+	}
+}
+
+func (u *securityEventUsecase) ListSecurityEvents(
+	ctx context.Context,
+	params ListSecurityEventsParams,
+) ([]*model.AuthEvent, string, error) {
+	pageSize := params.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultSecurityEventPageSize
+	}
+	pageSize = min(pageSize, maxSecurityEventPageSize)
+
+	// The page token is the ID of the last event of the previous page. It is not checked to be an
+	// event of the user: the repository only uses it as a bound on the events of params.UserID, so a
+	// token from another user's log reveals nothing but where to start in this one.
+	if params.PageToken != "" {
+		if _, err := bson.ObjectIDFromHex(params.PageToken); err != nil {
+			return nil, "", ErrInvalidPageToken
+		}
+	}
+
+	// One more event than requested tells whether there is another page
+	events, err := u.authEventRepo.ListAuthEventsByUserID(ctx, params.UserID, params.PageToken, pageSize+1)
+	if err != nil {
+		return nil, "", err
+	}
+
+	nextPageToken := ""
+	if int64(len(events)) > pageSize {
+		events = events[:pageSize]
+		nextPageToken = events[pageSize-1].ID.Hex()
+	}
+
+	return events, nextPageToken, nil
+}
+
+// authEventReasons are the errors that explain why a request was refused. Any other error is a
+// failure of the service itself, whose details do not belong in a log users can read.
+var authEventReasons = []error{
+	ErrInvalidCredentials,
+	ErrTooManyLoginAttempts,
+	ErrEmailNotVerified,
+	ErrUserAlreadyExists,
+	ErrInvalidIDToken,
+	ErrUnknownProvider,
+	ErrInvalidOAuthState,
+	ErrInvalidAuthorizationCode,
+	ErrProviderEmailRequired,
+	ErrIdentityAlreadyLinked,
+	ErrProviderAlreadyLinked,
+	ErrInvalidRefreshToken,
+	ErrRefreshTokenReused,
+	ErrSessionRevoked,
+	ErrInvalidMFAToken,
+	ErrInvalidMFACode,
+	ErrTooManyMFAAttempts,
+	ErrInvalidPasskeyChallenge,
+	ErrInvalidPasskey,
+	ErrPasswordPolicyViolation,
+	ErrPasswordNotSet,
+	ErrReauthenticationRequired,
+	ErrTokenNotFound,
+	ErrTokenAlreadyUsed,
+	ErrTokenExpired,
+	ErrEmailAlreadyInUse,
+	ErrEmailUnchanged,
+	ErrInvalidEmailChangeToken,
+	ErrInvalidLoginAlertToken,
+	ErrPasswordResetRequired,
+	ErrInvalidMagicLink,
+	ErrInvalidScope,
+	ErrInvalidExpiry,
+	ErrTooManyPersonalAccessTokens,
+	ErrPersonalAccessTokenNotFound,
+	ErrInvalidOAuthClient,
+	ErrInvalidRedirectURI,
+	ErrUnsupportedResponseType,
+	ErrInvalidCodeChallenge,
+	ErrUnsupportedGrantType,
+	ErrInvalidOAuthGrant,
+	ErrOAuthClientNotFound,
+	ErrOAuthConsentNotFound,
+	ErrUserNotFound,
+	ErrUnknownRole,
+	ErrUnknownPermission,
+}
+
+// AuthEventRecorder adds events to the security audit log.
+type AuthEventRecorder struct {
+	logger         *zerolog.Logger
+	authEventRepo  repository.AuthEventRepository
+	authServiceCfg *config.AuthServiceConfig
+}
+
+// NewAuthEventRecorder creates a new AuthEventRecorder.
+func NewAuthEventRecorder(
+	logger *zerolog.Logger,
+	authEventRepo repository.AuthEventRepository,
+	authServiceCfg *config.AuthServiceConfig,
+) *AuthEventRecorder {
+	return &AuthEventRecorder{
+		logger:         logger,
+		authEventRepo:  authEventRepo,
+		authServiceCfg: authServiceCfg,
+	}
+}
+
+// Record adds an event made by client to the audit log. Unless the event already has an outcome, a
+// nil err records a success and any other error a failure. The log must not make authentication
+// unavailable, so a failure to write it is only logged.
+func (r *AuthEventRecorder) Record(ctx context.Context, event *model.AuthEvent, client ClientInfo, err error) {
+	event.IPAddress = client.IPAddress
+	event.UserAgent = client.UserAgent
+	event.ExpiresAt = time.Now().Add(r.authServiceCfg.AuditLog.Retention)
+
+	switch {
+	case err != nil:
+		event.Outcome = model.AuthEventOutcomeFailure
+		event.Reason = authEventReason(err)
+	case event.Outcome == "":
+		event.Outcome = model.AuthEventOutcomeSuccess
+	}
+
+	// The event is recorded even if the client has gone away in the meantime
+	if err := r.authEventRepo.CreateAuthEvent(context.WithoutCancel(ctx), event); err != nil {
+		r.logger.Error().Err(err).Str("type", event.Type).Str("userID", event.UserID).Msg("failed to record auth event")
+	}
+}
+
+func authEventReason(err error) string {
+	for _, reason := range authEventReasons {
+		if errors.Is(err, reason) {
+			return reason.Error()
+		}
+	}
+
+	return "internal error"
+}
@@ -0,0 +1,164 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/config"
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/model"
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/repository"
+	"github.com/vasapolrittideah/money-tracker-api/shared/contract"
+	"github.com/vasapolrittideah/money-tracker-api/shared/mailer"
+)
+
+// DataExportUsecase defines the business logic for exporting the personal data of a user.
+type DataExportUsecase interface {
+	// RequestDataExport assembles the data of every export source into an archive, stores it and
+	// emails the user a link to download it. It returns when the archive expires.
+	RequestDataExport(ctx context.Context, userID string) (time.Time, error)
+
+	// DownloadDataExport returns the JSON archive the token was emailed for. The token only works
+	// for the user who requested the export.
+	DownloadDataExport(ctx context.Context, userID, token string) ([]byte, error)
+}
+
+// DataExportSource contributes one section to the data export of a user. Other services can take
+// part by registering a source that fetches their section from them.
+type DataExportSource interface {
+	// Section returns the name of the section, such as "auth.profile".
+	Section() string
+
+	// Export returns the data stored about the user, encoded as JSON in the archive.
+	Export(ctx context.Context, userID string) (any, error)
+}
+
+var (
+	ErrDataExportRequestedRecently = errors.New("a data export has been requested recently")
+	ErrInvalidDataExportToken      = errors.New("invalid or expired data export token")
+)
+
+type dataExportUsecase struct {
+	userRepo       repository.UserRepository
+	dataExportRepo repository.DataExportRepository
+	sources        []DataExportSource
+	mailer         *mailer.Mailer
+	authServiceCfg *config.AuthServiceConfig
+}
+
+// NewDataExportUsecase creates a new instance of DataExportUsecase.
+func NewDataExportUsecase(
+	userRepo repository.UserRepository,
+	dataExportRepo repository.DataExportRepository,
+	sources []DataExportSource,
+	mailer *mailer.Mailer,
+	authServiceCfg *config.AuthServiceConfig,
+) DataExportUsecase {
+	return &dataExportUsecase{
+		userRepo:       userRepo,
+		dataExportRepo: dataExportRepo,
+		sources:        sources,
+		mailer:         mailer,
+		authServiceCfg: authServiceCfg,
+	}
+}
+
+func (u *dataExportUsecase) RequestDataExport(ctx context.Context, userID string) (time.Time, error) {
+	user, err := u.userRepo.GetUser(ctx, userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// Assembling an archive reads everything stored about the user, so it is not done on every click
+	previous, err := u.dataExportRepo.GetDataExportByUserID(ctx, userID)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return time.Time{}, err
+	}
+	if previous != nil && time.Since(previous.CreatedAt) < u.authServiceCfg.DataExport.RequestInterval {
+		return time.Time{}, ErrDataExportRequestedRecently
+	}
+
+	now := time.Now()
+	archive := contract.DataExport{
+		FormatVersion: contract.DataExportFormatVersion,
+		UserID:        userID,
+		GeneratedAt:   now,
+		Sections:      make(map[string]json.RawMessage, len(u.sources)),
+	}
+
+	for _, source := range u.sources {
+		data, err := source.Export(ctx, userID)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to export section %s: %w", source.Section(), err)
+		}
+
+		section, err := json.Marshal(data)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to encode section %s: %w", source.Section(), err)
+		}
+
+		archive.Sections[source.Section()] = section
+	}
+
+	archiveJSON, err := json.MarshalIndent(archive, "", "  ")
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	token, err := generateJTI()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	expiresAt := now.Add(u.authServiceCfg.DataExport.ExpiresIn)
+	if err := u.dataExportRepo.CreateDataExport(ctx, &model.DataExport{
+		UserID:    userID,
+		TokenHash: hashToken(token),
+		Archive:   archiveJSON,
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		return time.Time{}, err
+	}
+
+	if err := u.sendDataExportLink(user, token, expiresAt); err != nil {
+		return time.Time{}, err
+	}
+
+	return expiresAt, nil
+}
+
+func (u *dataExportUsecase) DownloadDataExport(ctx context.Context, userID, token string) ([]byte, error) {
+	export, err := u.dataExportRepo.GetDataExportByTokenHash(ctx, userID, hashToken(token))
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrInvalidDataExportToken
+		}
+
+		return nil, err
+	}
+
+	return export.Archive, nil
+}
+
+func (u *dataExportUsecase) sendDataExportLink(user *model.User, token string, expiresAt time.Time) error {
+	downloadLink := fmt.Sprintf("%s?token=%s", u.authServiceCfg.DataExport.URL, token)
+	htmlBody := fmt.Sprintf(`
+		<p>Hi,</p>
+		<p>The copy of your personal data you requested is ready.</p>
+		<p>You can download it while logged in to your account using the link below:</p>
+
+		<p><a href="%s">%s</a></p>
+
+		<p>The download will be available until %s.</p>
+		<p>If you did not request a copy of your data, change your password right away.</p>
+
+		<p>Thank you,</p>
+		<p>Money Tracker Team</p>
+	`, downloadLink, downloadLink, expiresAt.UTC().Format("January 2, 2006 15:04 MST"))
+
+	return u.mailer.SendHTML([]string{user.Email}, "Your Data Export Is Ready", htmlBody)
+}
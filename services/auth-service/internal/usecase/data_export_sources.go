@@ -0,0 +1,184 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/repository"
+)
+
+// Secrets, such as password hashes, TOTP secrets and tokens, are left out of every section: they are
+// not personal data the user can make use of, and an archive should not be enough to take over the
+// account.
+
+type exportedProfile struct {
+	ID          string     `json:"id"`
+	Email       string     `json:"email"`
+	Verified    bool       `json:"email_verified"`
+	HasPassword bool       `json:"has_password"`
+	TOTPEnabled bool       `json:"totp_enabled"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+type exportedIdentity struct {
+	Provider    string    `json:"provider"`
+	Email       string    `json:"email,omitempty"`
+	LastLoginAt time.Time `json:"last_login_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type exportedSession struct {
+	ID           string     `json:"id"`
+	IPAddress    *string    `json:"ip_address,omitempty"`
+	UserAgent    *string    `json:"user_agent,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	LastActiveAt time.Time  `json:"last_active_at"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+}
+
+type exportedPasswordReset struct {
+	Email       string    `json:"email"`
+	Used        bool      `json:"used"`
+	RequestedAt time.Time `json:"requested_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+type exportedPasskey struct {
+	Name       string     `json:"name"`
+	Transports []string   `json:"transports,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+type dataExportSource struct {
+	section string
+	export  func(ctx context.Context, userID string) (any, error)
+}
+
+func (s dataExportSource) Section() string {
+	return s.section
+}
+
+func (s dataExportSource) Export(ctx context.Context, userID string) (any, error) {
+	return s.export(ctx, userID)
+}
+
+// NewAuthDataExportSources returns the sections of the data the auth service stores about a user.
+func NewAuthDataExportSources(
+	userRepo repository.UserRepository,
+	identityRepo repository.IdentityRepository,
+	sessionRepo repository.SessionRepository,
+	passwordResetTokenRepo repository.PasswordResetTokenRepository,
+	webAuthnCredentialRepo repository.WebAuthnCredentialRepository,
+) []DataExportSource {
+	return []DataExportSource{
+		dataExportSource{
+			section: "auth.profile",
+			export: func(ctx context.Context, userID string) (any, error) {
+				user, err := userRepo.GetUser(ctx, userID)
+				if err != nil {
+					return nil, err
+				}
+
+				return exportedProfile{
+					ID:          user.ID.Hex(),
+					Email:       user.Email,
+					Verified:    user.Verified,
+					HasPassword: user.PasswordHash != "",
+					TOTPEnabled: user.TOTPEnabled,
+					DeletedAt:   user.DeletedAt,
+					CreatedAt:   user.CreatedAt,
+					UpdatedAt:   user.UpdatedAt,
+				}, nil
+			},
+		},
+		dataExportSource{
+			section: "auth.identities",
+			export: func(ctx context.Context, userID string) (any, error) {
+				identities, err := identityRepo.GetIdentitiesByUserID(ctx, userID)
+				if err != nil {
+					return nil, err
+				}
+
+				exported := make([]exportedIdentity, 0, len(identities))
+				for _, identity := range identities {
+					exported = append(exported, exportedIdentity{
+						Provider:    identity.Provider,
+						Email:       identity.Email,
+						LastLoginAt: identity.LastLoginAt,
+						CreatedAt:   identity.CreatedAt,
+					})
+				}
+
+				return exported, nil
+			},
+		},
+		dataExportSource{
+			section: "auth.sessions",
+			export: func(ctx context.Context, userID string) (any, error) {
+				sessions, err := sessionRepo.ListSessionsByUserID(ctx, userID)
+				if err != nil {
+					return nil, err
+				}
+
+				exported := make([]exportedSession, 0, len(sessions))
+				for _, session := range sessions {
+					exported = append(exported, exportedSession{
+						ID:           session.ID.Hex(),
+						IPAddress:    session.IPAddress,
+						UserAgent:    session.UserAgent,
+						CreatedAt:    session.CreatedAt,
+						LastActiveAt: session.UpdatedAt,
+						RevokedAt:    session.RevokedAt,
+					})
+				}
+
+				return exported, nil
+			},
+		},
+		dataExportSource{
+			section: "auth.password_resets",
+			export: func(ctx context.Context, userID string) (any, error) {
+				tokens, err := passwordResetTokenRepo.GetTokensByUserID(ctx, userID)
+				if err != nil {
+					return nil, err
+				}
+
+				exported := make([]exportedPasswordReset, 0, len(tokens))
+				for _, token := range tokens {
+					exported = append(exported, exportedPasswordReset{
+						Email:       token.Email,
+						Used:        token.Used,
+						RequestedAt: token.CreatedAt,
+						ExpiresAt:   token.ExpiresAt,
+					})
+				}
+
+				return exported, nil
+			},
+		},
+		dataExportSource{
+			section: "auth.passkeys",
+			export: func(ctx context.Context, userID string) (any, error) {
+				credentials, err := webAuthnCredentialRepo.GetCredentialsByUserID(ctx, userID)
+				if err != nil {
+					return nil, err
+				}
+
+				exported := make([]exportedPasskey, 0, len(credentials))
+				for _, credential := range credentials {
+					exported = append(exported, exportedPasskey{
+						Name:       credential.Name,
+						Transports: credential.Transports,
+						CreatedAt:  credential.CreatedAt,
+						LastUsedAt: credential.LastUsedAt,
+					})
+				}
+
+				return exported, nil
+			},
+		},
+	}
+}
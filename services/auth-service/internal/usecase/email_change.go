@@ -0,0 +1,229 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/config"
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/model"
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/repository"
+	"github.com/vasapolrittideah/money-tracker-api/shared/mailer"
+)
+
+// EmailChangeUsecase defines the business logic for changing the email address of an account.
+type EmailChangeUsecase interface {
+	// RequestEmailChange re-authenticates the user and emails a confirmation link to the new address
+	// and a cancellation link to the current one. The address is not changed until the change is
+	// confirmed.
+	RequestEmailChange(ctx context.Context, params RequestEmailChangeParams) error
+
+	// ConfirmEmailChange changes the email address of the user to the one the token was sent to.
+	ConfirmEmailChange(ctx context.Context, token string, client ClientInfo) error
+
+	// CancelEmailChange discards the pending change the token was sent for.
+	CancelEmailChange(ctx context.Context, token string, client ClientInfo) error
+}
+
+// RequestEmailChangeParams defines the parameters for requesting an email address change.
+type RequestEmailChangeParams struct {
+	UserID    string
+	SessionID string
+	Password  string
+	NewEmail  string
+	Client    ClientInfo
+}
+
+var (
+	ErrEmailAlreadyInUse       = errors.New("email address is already in use")
+	ErrEmailUnchanged          = errors.New("new email address is the same as the current one")
+	ErrInvalidEmailChangeToken = errors.New("invalid or expired email change token")
+)
+
+type emailChangeUsecase struct {
+	userRepo        repository.UserRepository
+	emailChangeRepo repository.EmailChangeRepository
+	reauthenticator *Reauthenticator
+	authEvents      *AuthEventRecorder
+	mailer          *mailer.Mailer
+	authServiceCfg  *config.AuthServiceConfig
+}
+
+// NewEmailChangeUsecase creates a new instance of EmailChangeUsecase.
+func NewEmailChangeUsecase(
+	userRepo repository.UserRepository,
+	emailChangeRepo repository.EmailChangeRepository,
+	reauthenticator *Reauthenticator,
+	authEvents *AuthEventRecorder,
+	mailer *mailer.Mailer,
+	authServiceCfg *config.AuthServiceConfig,
+) EmailChangeUsecase {
+	return &emailChangeUsecase{
+		userRepo:        userRepo,
+		emailChangeRepo: emailChangeRepo,
+		reauthenticator: reauthenticator,
+		authEvents:      authEvents,
+		mailer:          mailer,
+		authServiceCfg:  authServiceCfg,
+	}
+}
+
+func (u *emailChangeUsecase) RequestEmailChange(ctx context.Context, params RequestEmailChangeParams) (err error) {
+	defer func() {
+		u.authEvents.Record(ctx, &model.AuthEvent{
+			Type:   model.AuthEventEmailChangeRequest,
+			UserID: params.UserID,
+		}, params.Client, err)
+	}()
+
+	user, err := u.userRepo.GetUser(ctx, params.UserID)
+	if err != nil {
+		return err
+	}
+
+	if err := u.reauthenticator.reauthenticate(ctx, user, params.SessionID, params.Password); err != nil {
+		return err
+	}
+
+	if strings.EqualFold(params.NewEmail, user.Email) {
+		return ErrEmailUnchanged
+	}
+
+	// The unique index on the email address decides in the end, this only saves sending a link
+	// that could never be confirmed
+	if _, err := u.userRepo.GetUserByEmail(ctx, params.NewEmail); err == nil {
+		return ErrEmailAlreadyInUse
+	} else if !errors.Is(err, mongo.ErrNoDocuments) {
+		return err
+	}
+
+	confirmToken, err := generateJTI()
+	if err != nil {
+		return err
+	}
+	cancelToken, err := generateJTI()
+	if err != nil {
+		return err
+	}
+
+	change := &model.EmailChange{
+		UserID:           user.ID.Hex(),
+		OldEmail:         user.Email,
+		NewEmail:         params.NewEmail,
+		ConfirmTokenHash: hashToken(confirmToken),
+		CancelTokenHash:  hashToken(cancelToken),
+		ExpiresAt:        time.Now().Add(u.authServiceCfg.EmailChange.TokenExpiresIn),
+	}
+
+	if err := u.emailChangeRepo.CreateEmailChange(ctx, change); err != nil {
+		return err
+	}
+
+	// The current owner is told first, so a change they did not ask for never goes unnoticed
+	if err := u.sendEmailChangeCancelLink(change, cancelToken); err != nil {
+		return err
+	}
+
+	return u.sendEmailChangeConfirmLink(change, confirmToken)
+}
+
+func (u *emailChangeUsecase) ConfirmEmailChange(ctx context.Context, token string, client ClientInfo) (err error) {
+	var change *model.EmailChange
+	defer func() {
+		event := &model.AuthEvent{Type: model.AuthEventEmailChange}
+		if change != nil {
+			event.UserID = change.UserID
+		}
+		u.authEvents.Record(ctx, event, client, err)
+	}()
+
+	change, err = u.emailChangeRepo.ConsumeEmailChangeByConfirmToken(ctx, hashToken(token))
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrInvalidEmailChangeToken
+		}
+
+		return err
+	}
+
+	if err := u.userRepo.ChangeEmail(ctx, change.UserID, change.OldEmail, change.NewEmail); err != nil {
+		switch {
+		case mongo.IsDuplicateKeyError(err):
+			// Someone registered with the address after the change was requested
+			return ErrEmailAlreadyInUse
+		case errors.Is(err, mongo.ErrNoDocuments):
+			// The address changed in the meantime, so the change no longer applies
+			return ErrInvalidEmailChangeToken
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (u *emailChangeUsecase) CancelEmailChange(ctx context.Context, token string, client ClientInfo) (err error) {
+	var change *model.EmailChange
+	defer func() {
+		event := &model.AuthEvent{Type: model.AuthEventEmailChangeCancel}
+		if change != nil {
+			event.UserID = change.UserID
+		}
+		u.authEvents.Record(ctx, event, client, err)
+	}()
+
+	change, err = u.emailChangeRepo.ConsumeEmailChangeByCancelToken(ctx, hashToken(token))
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrInvalidEmailChangeToken
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (u *emailChangeUsecase) sendEmailChangeConfirmLink(change *model.EmailChange, token string) error {
+	confirmLink := fmt.Sprintf("%s?token=%s", u.authServiceCfg.EmailChange.ConfirmURL, token)
+	htmlBody := fmt.Sprintf(`
+		<p>Hi,</p>
+		<p>We received a request to change the email address of your account to this address.</p>
+		<p>To confirm the change, please click the link below:</p>
+
+		<p><a href="%s">%s</a></p>
+
+		<p>This link will expire in %s.</p>
+		<p>If you did not request this change, you can safely ignore this email.</p>
+
+		<p>Thank you,</p>
+		<p>Money Tracker Team</p>
+	`, confirmLink, confirmLink, u.authServiceCfg.EmailChange.TokenExpiresIn)
+
+	return u.mailer.SendHTML([]string{change.NewEmail}, "Confirm Your New Email Address", htmlBody)
+}
+
+func (u *emailChangeUsecase) sendEmailChangeCancelLink(change *model.EmailChange, token string) error {
+	cancelLink := fmt.Sprintf("%s?token=%s", u.authServiceCfg.EmailChange.CancelURL, token)
+	htmlBody := fmt.Sprintf(`
+		<p>Hi,</p>
+		<p>We received a request to change the email address of your account to %s.
+		The change will only take effect once it is confirmed from the new address.</p>
+
+		<p>If this was you, no further action is needed.</p>
+		<p>If this was not you, cancel the change by clicking the link below, and change your password
+		right away:</p>
+
+		<p><a href="%s">%s</a></p>
+
+		<p>Thank you,</p>
+		<p>Money Tracker Team</p>
+	`, html.EscapeString(change.NewEmail), cancelLink, cancelLink)
+
+	return u.mailer.SendHTML([]string{change.OldEmail}, "Email Address Change Requested", htmlBody)
+}
@@ -0,0 +1,182 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/config"
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/model"
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/repository"
+	"github.com/vasapolrittideah/money-tracker-api/shared/mailer"
+)
+
+// EmailVerificationUsecase defines the business logic for verifying user email addresses.
+type EmailVerificationUsecase interface {
+	// SendVerificationCode generates a new verification code for the user and emails it.
+	SendVerificationCode(ctx context.Context, user *model.User) error
+
+	// VerifyEmail marks the user's email address as verified if the code matches.
+	VerifyEmail(ctx context.Context, email, code string) error
+
+	// ResendVerificationEmail sends a new verification code, at most once per resend interval.
+	ResendVerificationEmail(ctx context.Context, email string) error
+}
+
+type emailVerificationUsecase struct {
+	userRepo       repository.UserRepository
+	mailer         *mailer.Mailer
+	authServiceCfg *config.AuthServiceConfig
+}
+
+var (
+	ErrInvalidVerificationCode     = errors.New("invalid verification code")
+	ErrVerificationCodeExpired     = errors.New("verification code has expired")
+	ErrTooManyVerificationAttempts = errors.New("too many verification attempts")
+	ErrVerificationResendTooSoon   = errors.New("verification email was sent too recently")
+	ErrEmailAlreadyVerified        = errors.New("email has already been verified")
+)
+
+const verificationCodeDigits = 6
+
+// NewEmailVerificationUsecase creates a new instance of EmailVerificationUsecase.
+func NewEmailVerificationUsecase(
+	userRepo repository.UserRepository,
+	mailer *mailer.Mailer,
+	authServiceCfg *config.AuthServiceConfig,
+) EmailVerificationUsecase {
+	return &emailVerificationUsecase{
+		userRepo:       userRepo,
+		mailer:         mailer,
+		authServiceCfg: authServiceCfg,
+	}
+}
+
+func (u *emailVerificationUsecase) SendVerificationCode(ctx context.Context, user *model.User) error {
+	code, err := generateVerificationCode()
+	if err != nil {
+		return err
+	}
+
+	// Store only the hash of the code and reset the attempt counter
+	now := time.Now()
+	codeHash := hashVerificationCode(code)
+	expiresAt := now.Add(u.authServiceCfg.EmailVerification.CodeExpiresIn)
+	attempts := 0
+	if _, err := u.userRepo.UpdateUser(ctx, user.ID.Hex(), repository.UpdateUserParams{
+		VerificationCode:          &codeHash,
+		VerificationCodeExpiresAt: &expiresAt,
+		VerificationCodeSentAt:    &now,
+		VerificationAttempts:      &attempts,
+	}); err != nil {
+		return err
+	}
+
+	htmlBody := fmt.Sprintf(`
+		<p>Hi,</p>
+		<p>Thank you for signing up. Please use the code below to verify your email address:</p>
+
+		<p><strong>%s</strong></p>
+
+		<p>This code will expire in %s.</p>
+		<p>If you did not create an account, you can safely ignore this email.</p>
+
+		<p>Thank you,</p>
+		<p>Money Tracker Team</p>
+	`, code, u.authServiceCfg.EmailVerification.CodeExpiresIn)
+
+	return u.mailer.SendHTML([]string{user.Email}, "Verify Your Email Address", htmlBody)
+}
+
+func (u *emailVerificationUsecase) VerifyEmail(ctx context.Context, email, code string) error {
+	user, err := u.userRepo.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			// To prevent email enumeration, report an unknown email as a wrong code.
+			return ErrInvalidVerificationCode
+		}
+		return err
+	}
+
+	if user.Verified {
+		return ErrEmailAlreadyVerified
+	}
+
+	if user.VerificationAttempts >= u.authServiceCfg.EmailVerification.MaxAttempts {
+		return ErrTooManyVerificationAttempts
+	}
+
+	if time.Now().After(user.VerificationCodeExpiresAt) {
+		return ErrVerificationCodeExpired
+	}
+
+	codeHash := hashVerificationCode(code)
+	if subtle.ConstantTimeCompare([]byte(codeHash), []byte(user.VerificationCode)) != 1 {
+		if err := u.userRepo.IncrementVerificationAttempts(ctx, user.ID.Hex()); err != nil {
+			return err
+		}
+		return ErrInvalidVerificationCode
+	}
+
+	// Mark the user as verified and clear the code so it cannot be reused
+	verified := true
+	emptyCode := ""
+	if _, err := u.userRepo.UpdateUser(ctx, user.ID.Hex(), repository.UpdateUserParams{
+		Verified:         &verified,
+		VerificationCode: &emptyCode,
+	}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (u *emailVerificationUsecase) ResendVerificationEmail(ctx context.Context, email string) error {
+	user, err := u.userRepo.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			// To prevent email enumeration, do not reveal that the email does not exist.
+			return nil
+		}
+		return err
+	}
+
+	if user.Verified {
+		return nil
+	}
+
+	if time.Since(user.VerificationCodeSentAt) < u.authServiceCfg.EmailVerification.ResendInterval {
+		return ErrVerificationResendTooSoon
+	}
+
+	return u.SendVerificationCode(ctx, user)
+}
+
+// generateVerificationCode generates a random numeric verification code.
+func generateVerificationCode() (string, error) {
+	upperBound := big.NewInt(1)
+	for range verificationCodeDigits {
+		upperBound.Mul(upperBound, big.NewInt(10))
+	}
+
+	n, err := rand.Int(rand.Reader, upperBound)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%0*d", verificationCodeDigits, n), nil
+}
+
+// hashVerificationCode returns the hex encoded SHA-256 hash of a verification code.
+func hashVerificationCode(code string) string {
+	hash := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,46 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/config"
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/model"
+	"github.com/vasapolrittideah/money-tracker-api/shared/provider"
+)
+
+func (u *authUsecase) LoginWithGoogle(
+	ctx context.Context,
+	params LoginWithGoogleParams,
+) (result *LoginResult, err error) {
+	var user *model.User
+	defer func() {
+		u.sessionIssuer.recordLogin(ctx, model.IdentityProviderGoogle, "", user, result, params.Client, err)
+	}()
+
+	tokenInfo, err := u.googleProvider.ValidateIDToken(ctx, params.IDToken)
+	if err != nil {
+		if errors.Is(err, provider.ErrInvalidGoogleIDToken) || errors.Is(err, provider.ErrInvalidGoogleAudience) {
+			return nil, ErrInvalidIDToken
+		}
+
+		return nil, err
+	}
+
+	user, err = u.findOrCreateExternalUser(
+		ctx,
+		model.IdentityProviderGoogle,
+		tokenInfo.UserId,
+		tokenInfo.Email,
+		tokenInfo.VerifiedEmail,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.Verified && u.authServiceCfg.EmailVerification.LoginPolicy == config.UnverifiedLoginDeny {
+		return nil, ErrEmailNotVerified
+	}
+
+	return u.sessionIssuer.completeLogin(ctx, user, params.Client)
+}
@@ -0,0 +1,259 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/config"
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/model"
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/repository"
+	"github.com/vasapolrittideah/money-tracker-api/shared/provider"
+)
+
+// IdentityUsecase defines the business logic for managing the login methods of a user.
+type IdentityUsecase interface {
+	// ListIdentities returns every identity the user can log in with.
+	ListIdentities(ctx context.Context, userID string) ([]model.Identity, error)
+
+	// LinkIdentity attaches an external account to the user after re-authenticating them.
+	// A Google account is linked right away when an ID token is given; otherwise the result
+	// holds the URL to send the user to, and the account is linked by CompleteOAuthLogin.
+	LinkIdentity(ctx context.Context, params LinkIdentityParams) (*LinkIdentityResult, error)
+
+	// UnlinkIdentity removes a login method from the user, unless it is the last one they have.
+	// Passkeys count as login methods too.
+	UnlinkIdentity(ctx context.Context, userID, identityID string) error
+}
+
+// LinkIdentityParams defines the parameters for linking an external account.
+type LinkIdentityParams struct {
+	UserID    string
+	SessionID string
+	Provider  string
+	Password  string
+	IDToken   string
+}
+
+// LinkIdentityResult holds either the linked identity or the URL to continue linking at.
+type LinkIdentityResult struct {
+	Identity         *model.Identity
+	AuthorizationURL string
+}
+
+var (
+	ErrIdentityNotFound      = errors.New("identity not found")
+	ErrIdentityAlreadyLinked = errors.New("identity is already linked to another account")
+	ErrProviderAlreadyLinked = errors.New("an account of this provider is already linked")
+	ErrLastLoginMethod       = errors.New("cannot unlink the last login method")
+)
+
+type identityUsecase struct {
+	identityRepo           repository.IdentityRepository
+	userRepo               repository.UserRepository
+	oauthStateRepo         repository.OAuthStateRepository
+	webAuthnCredentialRepo repository.WebAuthnCredentialRepository
+	googleProvider         *provider.GoogleOAuthProvider
+	providerRegistry       *provider.Registry
+	reauthenticator        *Reauthenticator
+	authServiceCfg         *config.AuthServiceConfig
+}
+
+// NewIdentityUsecase creates a new instance of IdentityUsecase.
+func NewIdentityUsecase(
+	identityRepo repository.IdentityRepository,
+	userRepo repository.UserRepository,
+	oauthStateRepo repository.OAuthStateRepository,
+	webAuthnCredentialRepo repository.WebAuthnCredentialRepository,
+	googleProvider *provider.GoogleOAuthProvider,
+	providerRegistry *provider.Registry,
+	reauthenticator *Reauthenticator,
+	authServiceCfg *config.AuthServiceConfig,
+) IdentityUsecase {
+	return &identityUsecase{
+		identityRepo:           identityRepo,
+		userRepo:               userRepo,
+		oauthStateRepo:         oauthStateRepo,
+		webAuthnCredentialRepo: webAuthnCredentialRepo,
+		googleProvider:         googleProvider,
+		providerRegistry:       providerRegistry,
+		reauthenticator:        reauthenticator,
+		authServiceCfg:         authServiceCfg,
+	}
+}
+
+func (u *identityUsecase) ListIdentities(ctx context.Context, userID string) ([]model.Identity, error) {
+	return u.identityRepo.GetIdentitiesByUserID(ctx, userID)
+}
+
+func (u *identityUsecase) LinkIdentity(ctx context.Context, params LinkIdentityParams) (*LinkIdentityResult, error) {
+	user, err := u.userRepo.GetUser(ctx, params.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.reauthenticator.reauthenticate(ctx, user, params.SessionID, params.Password); err != nil {
+		return nil, err
+	}
+
+	if params.Provider == model.IdentityProviderGoogle && params.IDToken != "" {
+		tokenInfo, err := u.googleProvider.ValidateIDToken(ctx, params.IDToken)
+		if err != nil {
+			if errors.Is(err, provider.ErrInvalidGoogleIDToken) || errors.Is(err, provider.ErrInvalidGoogleAudience) {
+				return nil, ErrInvalidIDToken
+			}
+
+			return nil, err
+		}
+
+		identity, err := linkExternalIdentity(
+			ctx,
+			u.identityRepo,
+			params.UserID,
+			model.IdentityProviderGoogle,
+			tokenInfo.UserId,
+			tokenInfo.Email,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		return &LinkIdentityResult{Identity: identity}, nil
+	}
+
+	p, err := u.providerRegistry.Get(params.Provider)
+	if err != nil {
+		if errors.Is(err, provider.ErrProviderNotFound) {
+			return nil, ErrUnknownProvider
+		}
+
+		return nil, err
+	}
+
+	authorizationURL, err := startOAuthFlow(
+		ctx,
+		u.oauthStateRepo,
+		p,
+		params.UserID,
+		u.authServiceCfg.OAuth.StateExpiresIn,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LinkIdentityResult{AuthorizationURL: authorizationURL}, nil
+}
+
+func (u *identityUsecase) UnlinkIdentity(ctx context.Context, userID, identityID string) error {
+	identities, err := u.identityRepo.GetIdentitiesByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	var target *model.Identity
+	externalIdentities := 0
+	for i := range identities {
+		if identities[i].ID.Hex() == identityID {
+			target = &identities[i]
+		}
+		if identities[i].Provider != model.IdentityProviderEmail {
+			externalIdentities++
+		}
+	}
+	if target == nil {
+		return ErrIdentityNotFound
+	}
+
+	user, err := u.userRepo.GetUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	passkeys, err := u.webAuthnCredentialRepo.GetCredentialsByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	// The password is a login method of its own, represented by the email identity
+	remainingLoginMethods := externalIdentities + len(passkeys)
+	if target.Provider != model.IdentityProviderEmail {
+		remainingLoginMethods--
+		if user.PasswordHash != "" {
+			remainingLoginMethods++
+		}
+	}
+	if remainingLoginMethods == 0 {
+		return ErrLastLoginMethod
+	}
+
+	if err := u.identityRepo.DeleteIdentity(ctx, identityID, userID); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrIdentityNotFound
+		}
+
+		return err
+	}
+
+	// Unlinking the email identity turns off password login
+	if target.Provider == model.IdentityProviderEmail && user.PasswordHash != "" {
+		emptyPasswordHash := ""
+		if _, err := u.userRepo.UpdateUser(ctx, userID, repository.UpdateUserParams{
+			PasswordHash: &emptyPasswordHash,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// linkExternalIdentity attaches the external account to the user. Linking an account that is
+// already linked to the same user is a no-op.
+func linkExternalIdentity(
+	ctx context.Context,
+	identityRepo repository.IdentityRepository,
+	userID string,
+	providerName string,
+	subject string,
+	email string,
+) (*model.Identity, error) {
+	existing, err := identityRepo.GetIdentityByProvider(ctx, subject, providerName)
+	if err == nil {
+		if existing.UserID != userID {
+			return nil, ErrIdentityAlreadyLinked
+		}
+
+		return existing, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	identities, err := identityRepo.GetIdentitiesByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, identity := range identities {
+		if identity.Provider == providerName {
+			return nil, ErrProviderAlreadyLinked
+		}
+	}
+
+	identity, err := identityRepo.CreateIdentity(ctx, &model.Identity{
+		UserID:     userID,
+		Provider:   providerName,
+		ProviderID: subject,
+		Email:      email,
+	})
+	if err != nil {
+		// Another user linked the same account concurrently
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrIdentityAlreadyLinked
+		}
+
+		return nil, err
+	}
+
+	return identity, nil
+}
@@ -0,0 +1,73 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	authtypes "github.com/vasapolrittideah/money-tracker-api/services/auth-service/pkg/types"
+)
+
+// Types of tokens introspection describes.
+const (
+	TokenTypeAccessToken         = "access_token"
+	TokenTypePersonalAccessToken = "personal_access_token"
+)
+
+// TokenIntrospection describes an access token. Only Active is set for inactive tokens, so callers
+// learn nothing about tokens they may not use. Personal access tokens have no SessionID, and
+// ExpiresAt is zero for those that do not expire. ClientID is only set for tokens issued to
+// third-party apps, and Roles and Permissions only for tokens the user got by logging in.
+type TokenIntrospection struct {
+	Active      bool
+	TokenType   string
+	UserID      string
+	SessionID   string
+	ClientID    string
+	Scopes      []string
+	Roles       []string
+	Permissions []string
+	ExpiresAt   time.Time
+}
+
+func (u *authUsecase) Introspect(ctx context.Context, token string) (*TokenIntrospection, error) {
+	claims := &authtypes.JWTClaims{}
+	if _, err := u.jwtAuth.ValidateTokenWithKeys(token, u.accessTokenKeys, claims); err != nil {
+		return &TokenIntrospection{Active: false}, nil
+	}
+
+	if _, err := bson.ObjectIDFromHex(claims.SessionID); err != nil {
+		return &TokenIntrospection{Active: false}, nil
+	}
+
+	// A valid signature only shows the token was issued; the session it belongs to may have been
+	// revoked since
+	session, err := u.sessionRepo.GetSession(ctx, claims.SessionID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return &TokenIntrospection{Active: false}, nil
+		}
+
+		return nil, err
+	}
+
+	if session.RevokedAt != nil || session.UserID != claims.UserID {
+		return &TokenIntrospection{Active: false}, nil
+	}
+
+	return &TokenIntrospection{
+		Active:      true,
+		TokenType:   TokenTypeAccessToken,
+		UserID:      claims.UserID,
+		SessionID:   claims.SessionID,
+		ClientID:    claims.ClientID,
+		Scopes:      strings.Fields(claims.Scope),
+		Roles:       claims.Roles,
+		Permissions: claims.Permissions,
+		ExpiresAt:   claims.ExpiresAt.Time,
+	}, nil
+}
@@ -0,0 +1,224 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/model"
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/repository"
+)
+
+// LoginAlertUsecase defines the business logic for the emails users get about sign-ins to their account.
+type LoginAlertUsecase interface {
+	// GetLoginAlerts returns the rule for when the user is emailed about a sign-in.
+	GetLoginAlerts(ctx context.Context, userID string) (string, error)
+
+	// UpdateLoginAlerts changes the rule for when the user is emailed about a sign-in.
+	UpdateLoginAlerts(ctx context.Context, userID, rule string) error
+
+	// ReportLogin handles the "this wasn't me" link of a sign-in alert. It signs out every session
+	// of the user, deletes their personal access tokens, blocks logging in with the password until it
+	// has been reset and emails a password reset link.
+	ReportLogin(ctx context.Context, token string, client ClientInfo) error
+}
+
+var (
+	ErrInvalidLoginAlertRule  = errors.New("invalid login alert rule")
+	ErrInvalidLoginAlertToken = errors.New("invalid or expired login alert token")
+	ErrPasswordResetRequired  = errors.New("password has to be reset before logging in with it")
+)
+
+type loginAlertUsecase struct {
+	userRepo                repository.UserRepository
+	sessionRepo             repository.SessionRepository
+	personalAccessTokenRepo repository.PersonalAccessTokenRepository
+	loginAlertRepo          repository.LoginAlertRepository
+	passwordResetUsecase    PasswordResetUsecase
+	authEvents              *AuthEventRecorder
+}
+
+// NewLoginAlertUsecase creates a new instance of LoginAlertUsecase.
+func NewLoginAlertUsecase(
+	userRepo repository.UserRepository,
+	sessionRepo repository.SessionRepository,
+	personalAccessTokenRepo repository.PersonalAccessTokenRepository,
+	loginAlertRepo repository.LoginAlertRepository,
+	passwordResetUsecase PasswordResetUsecase,
+	authEvents *AuthEventRecorder,
+) LoginAlertUsecase {
+	return &loginAlertUsecase{
+		userRepo:                userRepo,
+		sessionRepo:             sessionRepo,
+		personalAccessTokenRepo: personalAccessTokenRepo,
+		loginAlertRepo:          loginAlertRepo,
+		passwordResetUsecase:    passwordResetUsecase,
+		authEvents:              authEvents,
+	}
+}
+
+func (u *loginAlertUsecase) GetLoginAlerts(ctx context.Context, userID string) (string, error) {
+	user, err := u.userRepo.GetUser(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+
+	return loginAlertRule(user), nil
+}
+
+func (u *loginAlertUsecase) UpdateLoginAlerts(ctx context.Context, userID, rule string) error {
+	switch rule {
+	case model.LoginAlertsNewDevice, model.LoginAlertsAlways, model.LoginAlertsOff:
+	default:
+		return ErrInvalidLoginAlertRule
+	}
+
+	_, err := u.userRepo.UpdateUser(ctx, userID, repository.UpdateUserParams{
+		LoginAlerts: &rule,
+	})
+	return err
+}
+
+func (u *loginAlertUsecase) ReportLogin(ctx context.Context, token string, client ClientInfo) (err error) {
+	var alert *model.LoginAlert
+	defer func() {
+		event := &model.AuthEvent{Type: model.AuthEventLoginReport}
+		if alert != nil {
+			event.UserID = alert.UserID
+		}
+		u.authEvents.Record(ctx, event, client, err)
+	}()
+
+	alert, err = u.loginAlertRepo.ConsumeLoginAlertByTokenHash(ctx, hashToken(token))
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrInvalidLoginAlertToken
+		}
+
+		return err
+	}
+
+	// The password is blocked first, so whoever has it cannot sign back in once the sessions are revoked
+	resetRequired := true
+	user, err := u.userRepo.UpdateUser(ctx, alert.UserID, repository.UpdateUserParams{
+		PasswordResetRequired: &resetRequired,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Every session goes rather than only the reported one: whoever signed in may have signed in again
+	// from a device the user already knows, which was not alerted about
+	if err := u.sessionRepo.RevokeSessionsByUserID(ctx, alert.UserID); err != nil {
+		return err
+	}
+
+	// Tokens outlive every session, so whoever signed in may have created one to keep access
+	if err := u.personalAccessTokenRepo.DeleteTokensByUserID(ctx, alert.UserID); err != nil {
+		return err
+	}
+
+	return u.passwordResetUsecase.RequestPasswordReset(ctx, user.Email, client)
+}
+
+func loginAlertRule(user *model.User) string {
+	if user.LoginAlerts == "" {
+		return model.LoginAlertsNewDevice
+	}
+
+	return user.LoginAlerts
+}
+
+// shouldAlertOnLogin reports whether the user is emailed about a new session for client. It has to be
+// called before the session is stored, as the new session would always match the device.
+func (s *SessionIssuer) shouldAlertOnLogin(ctx context.Context, user *model.User, client ClientInfo) (bool, error) {
+	switch loginAlertRule(user) {
+	case model.LoginAlertsOff:
+		return false, nil
+	case model.LoginAlertsAlways:
+		return true, nil
+	}
+
+	sessions, err := s.sessionRepo.ListSessionsByUserID(ctx, user.ID.Hex())
+	if err != nil {
+		return false, err
+	}
+
+	// The first sign-in after registering is not news to anyone
+	if len(sessions) == 0 {
+		return false, nil
+	}
+
+	// Devices are only recognized by their device ID: anyone can send the IP address and user agent of
+	// the user's device, but not the ID only that device holds
+	if client.DeviceID == "" {
+		return true, nil
+	}
+
+	// Revoked and expired sessions are kept, so a device is recognized for as long as the account exists
+	deviceIDHash := hashToken(client.DeviceID)
+	for _, session := range sessions {
+		if session.DeviceIDHash == deviceIDHash {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// alertOnLogin emails the user about a new session with a link to report it. The user is signed in
+// already, so failures are only logged.
+func (s *SessionIssuer) alertOnLogin(ctx context.Context, user *model.User, sessionID string, client ClientInfo) {
+	token, err := generateJTI()
+	if err != nil {
+		s.logger.Error().Err(err).Str("userID", user.ID.Hex()).Msg("failed to generate login alert token")
+		return
+	}
+
+	if err := s.loginAlertRepo.CreateLoginAlert(ctx, &model.LoginAlert{
+		UserID:    user.ID.Hex(),
+		SessionID: sessionID,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(s.authServiceCfg.LoginAlert.TokenExpiresIn),
+	}); err != nil {
+		s.logger.Error().Err(err).Str("userID", user.ID.Hex()).Msg("failed to create login alert")
+		return
+	}
+
+	if err := s.sendLoginAlert(user, token, client); err != nil {
+		s.logger.Error().Err(err).Str("userID", user.ID.Hex()).Msg("failed to send login alert")
+	}
+}
+
+func (s *SessionIssuer) sendLoginAlert(user *model.User, token string, client ClientInfo) error {
+	ipAddress := "unknown"
+	if client.IPAddress != "" {
+		ipAddress = html.EscapeString(client.IPAddress)
+	}
+	device := "unknown"
+	if client.UserAgent != "" {
+		device = html.EscapeString(client.UserAgent)
+	}
+
+	reportLink := fmt.Sprintf("%s?token=%s", s.authServiceCfg.LoginAlert.ReportURL, token)
+	htmlBody := fmt.Sprintf(`
+		<p>Hi,</p>
+		<p>There was a new sign-in to your account on %s.</p>
+
+		<p>Device: %s<br>IP address: %s</p>
+
+		<p>If this was you, no further action is needed.</p>
+		<p>If this was not you, use the link below to sign out every device and reset your password:</p>
+
+		<p><a href="%s">This wasn't me</a></p>
+
+		<p>Thank you,</p>
+		<p>Money Tracker Team</p>
+	`, time.Now().UTC().Format(time.RFC1123), device, ipAddress, reportLink)
+
+	return s.mailer.SendHTML([]string{user.Email}, "New Sign-in to Your Account", htmlBody)
+}
@@ -0,0 +1,232 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/config"
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/model"
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/repository"
+	"github.com/vasapolrittideah/money-tracker-api/shared/mailer"
+)
+
+var ErrTooManyLoginAttempts = errors.New("too many login attempts")
+
+// loginAttemptKeys returns the keys failed logins are counted under: one for the account and, if the
+// client's IP address is known, one for the address. The account key does not depend on whether
+// the account exists, so lockouts do not reveal which emails are registered.
+func loginAttemptKeys(email string, client ClientInfo) (string, string) {
+	accountKey := "account:" + strings.ToLower(strings.TrimSpace(email))
+
+	ipKey := ""
+	if client.IPAddress != "" {
+		ipKey = "ip:" + client.IPAddress
+	}
+
+	return accountKey, ipKey
+}
+
+// LoginThrottle slows down password guessing. Failed logins are counted per account and per client
+// IP address, both for logins and for every other request that checks a password.
+type LoginThrottle struct {
+	logger           *zerolog.Logger
+	loginAttemptRepo repository.LoginAttemptRepository
+	mailer           *mailer.Mailer
+	authServiceCfg   *config.AuthServiceConfig
+}
+
+// NewLoginThrottle creates a new LoginThrottle.
+func NewLoginThrottle(
+	logger *zerolog.Logger,
+	loginAttemptRepo repository.LoginAttemptRepository,
+	mailer *mailer.Mailer,
+	authServiceCfg *config.AuthServiceConfig,
+) *LoginThrottle {
+	return &LoginThrottle{
+		logger:           logger,
+		loginAttemptRepo: loginAttemptRepo,
+		mailer:           mailer,
+		authServiceCfg:   authServiceCfg,
+	}
+}
+
+// reserve counts a login against the account and, if known, the IP address, and rejects
+// it while either is locked out or has to wait after recent failures. It runs before the password
+// is verified, so throttled requests cost no password hashing, and counting and checking happen in
+// one update, so concurrent requests cannot all pass the check before any of them is counted. The
+// attempt stays counted as a failure unless release is called once it has succeeded;
+// attempts rejected here count too, so retrying during a wait only makes it longer.
+//
+// An account is locked once AccountLockoutThreshold failures are counted against it, which anyone
+// who knows the email can bring about. See config.LoginThrottleConfig for that tradeoff. The owner
+// of a locked account is notified by email; user is nil when no account exists for the email.
+func (t *LoginThrottle) reserve(ctx context.Context, user *model.User, accountKey, ipKey string) error {
+	throttleCfg := &t.authServiceCfg.LoginThrottle
+
+	throttled, err := t.reserveKey(ctx, accountKey, throttleCfg.AccountLockoutThreshold, func(
+		failures int,
+		lockedUntil time.Time,
+	) {
+		if user == nil {
+			return
+		}
+
+		// The lockout is in place already, so a failed email must not fail the request
+		if err := t.sendLockoutNotification(user, failures, lockedUntil); err != nil {
+			t.logger.Error().Err(err).Str("userID", user.ID.Hex()).Msg("failed to send lockout notification")
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	if ipKey != "" {
+		ipThrottled, err := t.reserveKey(ctx, ipKey, throttleCfg.IPLockoutThreshold, nil)
+		if err != nil {
+			return err
+		}
+		throttled = throttled || ipThrottled
+	}
+
+	if throttled {
+		return ErrTooManyLoginAttempts
+	}
+
+	return nil
+}
+
+// reserveKey counts a login against key and reports whether the failures before it lock
+// the key out or make it wait. A key reaching lockoutThreshold is locked, and onLock is called if
+// it is not nil. Once a lockout expires the count starts over, so the next attempt is checked like
+// the first one rather than locking the key again.
+func (t *LoginThrottle) reserveKey(
+	ctx context.Context,
+	key string,
+	lockoutThreshold int,
+	onLock func(failures int, lockedUntil time.Time),
+) (bool, error) {
+	throttleCfg := &t.authServiceCfg.LoginThrottle
+	now := time.Now()
+
+	attempt, err := t.loginAttemptRepo.ReserveAttempt(ctx, key, now.Add(throttleCfg.FailureWindow))
+	if err != nil {
+		return false, err
+	}
+
+	// The attempt was counted on top of the failures that caused an expired lockout, so it is
+	// counted again on a fresh record
+	if attempt != nil && attempt.LockedUntil != nil && !isLocked(attempt, now) {
+		if err := t.loginAttemptRepo.ClearExpiredLock(ctx, key, *attempt.LockedUntil); err != nil {
+			return false, err
+		}
+
+		attempt, err = t.loginAttemptRepo.ReserveAttempt(ctx, key, now.Add(throttleCfg.FailureWindow))
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if attempt == nil {
+		return false, nil
+	}
+
+	if isLocked(attempt, now) {
+		return true, nil
+	}
+
+	if lockoutThreshold > 0 && attempt.Failures >= lockoutThreshold {
+		lockedUntil := now.Add(throttleCfg.LockoutDuration)
+		if err := t.loginAttemptRepo.LockUntil(ctx, key, lockedUntil); err != nil {
+			return false, err
+		}
+
+		if onLock != nil {
+			onLock(attempt.Failures, lockedUntil)
+		}
+
+		return true, nil
+	}
+
+	backoff := loginBackoff(throttleCfg, attempt.Failures)
+	return now.Before(attempt.LastFailureAt.Add(backoff)), nil
+}
+
+// reserveSecondFactor counts a second factor check of a signed in user and rejects it while the user
+// has to wait after recent failures or has failed MFA.MaxAttempts times. Unlike the challenge of a
+// login, a signed in user can check codes any number of times, so without this a stolen access token
+// would be a way to guess them.
+func (t *LoginThrottle) reserveSecondFactor(ctx context.Context, userID string) error {
+	throttled, err := t.reserveKey(ctx, secondFactorAttemptKey(userID), t.authServiceCfg.MFA.MaxAttempts, nil)
+	if err != nil {
+		return err
+	}
+	if throttled {
+		return ErrTooManyMFAAttempts
+	}
+
+	return nil
+}
+
+// releaseSecondFactor forgets the failed second factor checks of a user who has passed one.
+func (t *LoginThrottle) releaseSecondFactor(ctx context.Context, userID string) error {
+	return t.loginAttemptRepo.ResetLoginAttempts(ctx, secondFactorAttemptKey(userID))
+}
+
+func secondFactorAttemptKey(userID string) string {
+	return "mfa:" + userID
+}
+
+// release takes back the attempt reserve counted for a successful login.
+// The account's failures are forgotten, but only this attempt is taken back from the IP address: a
+// valid login must not clear the failures of other accounts tried from the same address.
+func (t *LoginThrottle) release(ctx context.Context, accountKey, ipKey string) error {
+	if err := t.loginAttemptRepo.ResetLoginAttempts(ctx, accountKey); err != nil {
+		return err
+	}
+
+	if ipKey == "" {
+		return nil
+	}
+
+	return t.loginAttemptRepo.ReleaseAttempt(ctx, ipKey)
+}
+
+func (t *LoginThrottle) sendLockoutNotification(user *model.User, failures int, lockedUntil time.Time) error {
+	htmlBody := fmt.Sprintf(`
+		<p>Hi,</p>
+		<p>There have been %d unsuccessful attempts to log in to your account with a password,
+		so password login has been locked until %s.</p>
+
+		<p>If this was you, you can try again once the lock has expired or reset your password.</p>
+		<p>If this was not you, someone may be trying to guess your password. We recommend
+		choosing a strong password that you do not use anywhere else.</p>
+
+		<p>Thank you,</p>
+		<p>Money Tracker Team</p>
+	`, failures, lockedUntil.UTC().Format(time.RFC1123))
+
+	return t.mailer.SendHTML([]string{user.Email}, "Your Account Has Been Temporarily Locked", htmlBody)
+}
+
+// loginBackoff returns how long a client has to wait after the given number of consecutive failures.
+func loginBackoff(throttleCfg *config.LoginThrottleConfig, failures int) time.Duration {
+	if failures <= throttleCfg.FreeAttempts {
+		return 0
+	}
+
+	backoff := throttleCfg.BackoffBase
+	for i := throttleCfg.FreeAttempts + 1; i < failures && backoff < throttleCfg.BackoffMax; i++ {
+		backoff *= 2
+	}
+
+	return min(backoff, throttleCfg.BackoffMax)
+}
+
+func isLocked(attempt *model.LoginAttempt, now time.Time) bool {
+	return attempt.LockedUntil != nil && now.Before(*attempt.LockedUntil)
+}
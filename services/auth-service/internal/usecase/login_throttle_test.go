@@ -0,0 +1,178 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/config"
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/model"
+	"github.com/vasapolrittideah/money-tracker-api/shared/auth"
+	"github.com/vasapolrittideah/money-tracker-api/shared/mailer"
+	"github.com/vasapolrittideah/money-tracker-api/shared/security"
+)
+
+const (
+	testEmail    = "user@example.com"
+	testPassword = "correct horse battery staple"
+)
+
+// newTestMailer returns a mailer whose SMTP server refuses every connection, so emails fail fast
+// without leaving the machine.
+func newTestMailer(t *testing.T, logger *zerolog.Logger) *mailer.Mailer {
+	t.Helper()
+
+	t.Setenv("SMTP_HOST", "127.0.0.1")
+	t.Setenv("SMTP_PORT", "1")
+	t.Setenv("SMTP_USERNAME", "test")
+	t.Setenv("SMTP_PASSWORD", "test")
+	t.Setenv("SMTP_FROM", "test@example.com")
+
+	return mailer.NewMailer(logger)
+}
+
+func newTestPasswordHasher(t *testing.T) *security.PasswordHasher {
+	t.Helper()
+
+	passwordHasher, err := security.NewPasswordHasher(security.PasswordHashingParams{
+		MemoryCost:  64,
+		TimeCost:    1,
+		Parallelism: 1,
+		SaltLength:  16,
+		HashLength:  32,
+	}, "")
+	if err != nil {
+		t.Fatalf("NewPasswordHasher() error = %v", err)
+	}
+
+	return passwordHasher
+}
+
+// newTestAuthUsecase returns an auth usecase for a verified user with a password and TOTP, so a
+// successful login ends with an MFA challenge and needs no session storage.
+func newTestAuthUsecase(t *testing.T, throttleCfg config.LoginThrottleConfig) *authUsecase {
+	t.Helper()
+
+	logger := zerolog.Nop()
+	passwordHasher := newTestPasswordHasher(t)
+	passwordHash, err := passwordHasher.Hash(testPassword)
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+
+	authServiceCfg := &config.AuthServiceConfig{
+		Token: config.TokenConfig{
+			MFATokenSecret:    "mfa-token-secret",
+			MFATokenExpiresIn: 5 * time.Minute,
+			Issuer:            "money-tracker",
+		},
+		EmailVerification: config.EmailVerificationConfig{LoginPolicy: config.UnverifiedLoginAllow},
+		LoginThrottle:     throttleCfg,
+	}
+
+	userRepo := &fakeUserRepository{users: []*model.User{{
+		ID:           bson.NewObjectID(),
+		Email:        testEmail,
+		PasswordHash: passwordHash,
+		Verified:     true,
+		TOTPEnabled:  true,
+	}}}
+	authEvents := NewAuthEventRecorder(&logger, &fakeAuthEventRepository{}, authServiceCfg)
+	jwtAuth := auth.NewJWTAuthenticator(authServiceCfg.Token.Issuer, authServiceCfg.Token.Issuer)
+
+	return &authUsecase{
+		logger:   &logger,
+		userRepo: userRepo,
+		loginThrottle: NewLoginThrottle(
+			&logger,
+			newFakeLoginAttemptRepository(),
+			newTestMailer(t, &logger),
+			authServiceCfg,
+		),
+		sessionIssuer: &SessionIssuer{
+			logger:           &logger,
+			userRepo:         userRepo,
+			mfaChallengeRepo: &fakeMFAChallengeRepository{},
+			authEvents:       authEvents,
+			jwtAuth:          jwtAuth,
+			authServiceCfg:   authServiceCfg,
+		},
+		authEvents:     authEvents,
+		passwordHasher: passwordHasher,
+		jwtAuth:        jwtAuth,
+		authServiceCfg: authServiceCfg,
+	}
+}
+
+func TestLoginSucceedsAfterLockoutExpires(t *testing.T) {
+	const lockoutDuration = 50 * time.Millisecond
+
+	u := newTestAuthUsecase(t, config.LoginThrottleConfig{
+		FreeAttempts:            10,
+		AccountLockoutThreshold: 3,
+		LockoutDuration:         lockoutDuration,
+		FailureWindow:           24 * time.Hour,
+	})
+	ctx := context.Background()
+	client := ClientInfo{IPAddress: "203.0.113.7"}
+
+	for i := range 3 {
+		_, err := u.Login(ctx, LoginParams{Email: testEmail, Password: "wrong password", Client: client})
+		if !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("failed login %d: error = %v, want %v", i+1, err, ErrInvalidCredentials)
+		}
+	}
+
+	_, err := u.Login(ctx, LoginParams{Email: testEmail, Password: testPassword, Client: client})
+	if !errors.Is(err, ErrTooManyLoginAttempts) {
+		t.Fatalf("login during lockout: error = %v, want %v", err, ErrTooManyLoginAttempts)
+	}
+
+	time.Sleep(lockoutDuration + 20*time.Millisecond)
+
+	result, err := u.Login(ctx, LoginParams{Email: testEmail, Password: testPassword, Client: client})
+	if err != nil {
+		t.Fatalf("login after lockout: error = %v, want nil", err)
+	}
+	if result.MFAToken == "" {
+		t.Fatal("login after lockout: no MFA challenge was returned")
+	}
+}
+
+func TestLoginIsLockedAgainOnlyAfterNewFailures(t *testing.T) {
+	const lockoutDuration = 50 * time.Millisecond
+
+	u := newTestAuthUsecase(t, config.LoginThrottleConfig{
+		FreeAttempts:            10,
+		AccountLockoutThreshold: 2,
+		LockoutDuration:         lockoutDuration,
+		FailureWindow:           24 * time.Hour,
+	})
+	ctx := context.Background()
+	wrongLogin := LoginParams{Email: testEmail, Password: "wrong password"}
+
+	for range 2 {
+		if _, err := u.Login(ctx, wrongLogin); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("failed login: error = %v, want %v", err, ErrInvalidCredentials)
+		}
+	}
+	if _, err := u.Login(ctx, wrongLogin); !errors.Is(err, ErrTooManyLoginAttempts) {
+		t.Fatalf("login during lockout: error = %v, want %v", err, ErrTooManyLoginAttempts)
+	}
+
+	time.Sleep(lockoutDuration + 20*time.Millisecond)
+
+	// The count starts over, so the threshold has to be reached again before the next lockout
+	for range 2 {
+		if _, err := u.Login(ctx, wrongLogin); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("failed login after lockout: error = %v, want %v", err, ErrInvalidCredentials)
+		}
+	}
+	if _, err := u.Login(ctx, wrongLogin); !errors.Is(err, ErrTooManyLoginAttempts) {
+		t.Fatalf("login after new failures: error = %v, want %v", err, ErrTooManyLoginAttempts)
+	}
+}
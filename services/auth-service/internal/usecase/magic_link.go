@@ -0,0 +1,225 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/config"
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/model"
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/repository"
+	authtypes "github.com/vasapolrittideah/money-tracker-api/services/auth-service/pkg/types"
+	"github.com/vasapolrittideah/money-tracker-api/shared/auth"
+	"github.com/vasapolrittideah/money-tracker-api/shared/mailer"
+)
+
+// RequestMagicLinkParams defines the parameters for requesting a sign-in link.
+type RequestMagicLinkParams struct {
+	Email  string
+	Client ClientInfo
+}
+
+// LoginWithMagicLinkParams defines the parameters for signing in with an emailed link.
+type LoginWithMagicLinkParams struct {
+	Token  string
+	Client ClientInfo
+}
+
+var ErrInvalidMagicLink = errors.New("invalid or expired magic link")
+
+// MagicLinkUsecase defines the business logic for signing in with a link emailed to the user.
+type MagicLinkUsecase interface {
+	// RequestMagicLink emails the user a short-lived, single-use sign-in link. Unknown addresses get
+	// the same response, so the request does not reveal which addresses have an account.
+	RequestMagicLink(ctx context.Context, params RequestMagicLinkParams) error
+
+	// LoginWithMagicLink logs a user in with the token of a link sent by RequestMagicLink. Users with
+	// TOTP enabled get an MFA challenge token instead of the token pair.
+	LoginWithMagicLink(ctx context.Context, params LoginWithMagicLinkParams) (*LoginResult, error)
+}
+
+type magicLinkUsecase struct {
+	userRepo           repository.UserRepository
+	magicLinkTokenRepo repository.MagicLinkTokenRepository
+	sessionIssuer      *SessionIssuer
+	authEvents         *AuthEventRecorder
+	jwtAuth            auth.JWTAuthenticator
+	mailer             *mailer.Mailer
+	authServiceCfg     *config.AuthServiceConfig
+}
+
+// NewMagicLinkUsecase creates a new instance of MagicLinkUsecase.
+func NewMagicLinkUsecase(
+	userRepo repository.UserRepository,
+	magicLinkTokenRepo repository.MagicLinkTokenRepository,
+	sessionIssuer *SessionIssuer,
+	authEvents *AuthEventRecorder,
+	jwtAuth auth.JWTAuthenticator,
+	mailer *mailer.Mailer,
+	authServiceCfg *config.AuthServiceConfig,
+) MagicLinkUsecase {
+	return &magicLinkUsecase{
+		userRepo:           userRepo,
+		magicLinkTokenRepo: magicLinkTokenRepo,
+		sessionIssuer:      sessionIssuer,
+		authEvents:         authEvents,
+		jwtAuth:            jwtAuth,
+		mailer:             mailer,
+		authServiceCfg:     authServiceCfg,
+	}
+}
+
+func (u *magicLinkUsecase) RequestMagicLink(ctx context.Context, params RequestMagicLinkParams) (err error) {
+	var user *model.User
+	defer func() {
+		event := &model.AuthEvent{Type: model.AuthEventMagicLinkRequest}
+		if user != nil {
+			event.UserID = user.ID.Hex()
+		} else {
+			event.Email = params.Email
+		}
+		u.authEvents.Record(ctx, event, params.Client, err)
+	}()
+
+	user, err = u.userRepo.GetUserByEmail(ctx, params.Email)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			// To prevent email enumeration, do not reveal that the email does not exist.
+			return nil
+		}
+		return err
+	}
+
+	// Only the most recent link works, so a mailbox full of old links is not a mailbox full of logins
+	if err := u.magicLinkTokenRepo.InvalidateUserTokens(ctx, user.ID.Hex()); err != nil {
+		return err
+	}
+
+	tokenStr, jti, err := u.generateMagicLinkToken(user.ID.Hex(), user.Email)
+	if err != nil {
+		return err
+	}
+
+	if err := u.magicLinkTokenRepo.CreateToken(ctx, &model.MagicLinkToken{
+		UserID:    user.ID.Hex(),
+		JTI:       jti,
+		Email:     user.Email,
+		ExpiresAt: time.Now().Add(u.authServiceCfg.Token.MagicLinkTokenExpiresIn),
+	}); err != nil {
+		return err
+	}
+
+	return u.sendMagicLink(user, tokenStr)
+}
+
+func (u *magicLinkUsecase) LoginWithMagicLink(
+	ctx context.Context,
+	params LoginWithMagicLinkParams,
+) (result *LoginResult, err error) {
+	var user *model.User
+	defer func() {
+		u.sessionIssuer.recordLogin(ctx, model.AuthMethodMagicLink, "", user, result, params.Client, err)
+	}()
+
+	claims := &authtypes.MagicLinkClaims{}
+	if _, err := u.jwtAuth.ValidateTokenWithClaims(
+		params.Token,
+		u.authServiceCfg.Token.MagicLinkTokenSecret,
+		claims,
+	); err != nil {
+		return nil, ErrInvalidMagicLink
+	}
+
+	token, err := u.magicLinkTokenRepo.ConsumeToken(ctx, claims.JTI)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrInvalidMagicLink
+		}
+
+		return nil, err
+	}
+
+	if token.UserID != claims.UserID {
+		return nil, ErrInvalidMagicLink
+	}
+
+	user, err = u.userRepo.GetUser(ctx, token.UserID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrInvalidMagicLink
+		}
+
+		return nil, err
+	}
+
+	// A link sent to an address the account no longer has must not sign in to it
+	if user.Email != token.Email {
+		return nil, ErrInvalidMagicLink
+	}
+
+	// Opening the link proves the user owns the address just like a verification code does
+	if !user.Verified {
+		verified := true
+		user, err = u.userRepo.UpdateUser(ctx, user.ID.Hex(), repository.UpdateUserParams{
+			Verified: &verified,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return u.sessionIssuer.completeLogin(ctx, user, params.Client)
+}
+
+// generateMagicLinkToken creates a magic link JWT token with a unique JTI.
+func (u *magicLinkUsecase) generateMagicLinkToken(userID, email string) (string, string, error) {
+	jti, err := generateJTI()
+	if err != nil {
+		return "", "", err
+	}
+
+	now := time.Now()
+	claims := authtypes.MagicLinkClaims{
+		UserID: userID,
+		Email:  email,
+		JTI:    jti,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    u.authServiceCfg.Token.Issuer,
+			Audience:  jwt.ClaimStrings{u.authServiceCfg.Token.Issuer},
+			Subject:   userID,
+			ExpiresAt: jwt.NewNumericDate(now.Add(u.authServiceCfg.Token.MagicLinkTokenExpiresIn)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+		},
+	}
+
+	tokenStr, err := u.jwtAuth.GenerateToken(claims, u.authServiceCfg.Token.MagicLinkTokenSecret)
+	if err != nil {
+		return "", "", err
+	}
+
+	return tokenStr, jti, nil
+}
+
+func (u *magicLinkUsecase) sendMagicLink(user *model.User, token string) error {
+	loginLink := fmt.Sprintf("%s?token=%s", u.authServiceCfg.AppMagicLinkURL, token)
+	htmlBody := fmt.Sprintf(`
+		<p>Hi,</p>
+		<p>We received a request to sign in to your account without a password.</p>
+		<p>If you made this request, click the link below to sign in:</p>
+
+		<p><a href="%s">%s</a></p>
+
+		<p>This link can only be used once and will expire in %s for your security.</p>
+		<p>If you did not request this link, you can safely ignore this email—your account will remain secure.</p>
+
+		<p>Thank you,</p>
+		<p>Money Tracker Team</p>
+	`, loginLink, loginLink, u.authServiceCfg.Token.MagicLinkTokenExpiresIn)
+
+	return u.mailer.SendHTML([]string{user.Email}, "Your Sign-in Link", htmlBody)
+}
@@ -0,0 +1,438 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/config"
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/model"
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/repository"
+	authtypes "github.com/vasapolrittideah/money-tracker-api/services/auth-service/pkg/types"
+	"github.com/vasapolrittideah/money-tracker-api/shared/security"
+)
+
+// MFAUsecase defines the business logic for managing two-factor authentication.
+type MFAUsecase interface {
+	// EnrollTOTP generates a new TOTP secret for the user. It only takes effect once it is
+	// confirmed with a code from the authenticator app.
+	EnrollTOTP(ctx context.Context, params EnrollTOTPParams) (*TOTPEnrollment, error)
+
+	// ConfirmTOTP enables TOTP for the user and returns their recovery codes.
+	ConfirmTOTP(ctx context.Context, userID, code string) ([]string, error)
+
+	// DisableTOTP turns off TOTP for the user and discards their recovery codes.
+	DisableTOTP(ctx context.Context, params DisableTOTPParams) error
+
+	// RegenerateRecoveryCodes replaces the user's recovery codes with new ones after
+	// re-authenticating them.
+	RegenerateRecoveryCodes(ctx context.Context, params RegenerateRecoveryCodesParams) ([]string, error)
+}
+
+// EnrollTOTPParams defines the parameters for enrolling in TOTP.
+type EnrollTOTPParams struct {
+	UserID    string
+	SessionID string
+	Password  string
+}
+
+// DisableTOTPParams defines the parameters for disabling TOTP.
+type DisableTOTPParams struct {
+	UserID    string
+	SessionID string
+	Password  string
+	Code      string
+}
+
+// RegenerateRecoveryCodesParams defines the parameters for regenerating recovery codes.
+type RegenerateRecoveryCodesParams struct {
+	UserID    string
+	SessionID string
+	Password  string
+	Code      string
+}
+
+// TOTPEnrollment holds what the user needs to add the secret to their authenticator app.
+type TOTPEnrollment struct {
+	Secret          string
+	ProvisioningURI string
+}
+
+var (
+	ErrTOTPAlreadyEnabled = errors.New("TOTP is already enabled")
+	ErrTOTPNotEnabled     = errors.New("TOTP is not enabled")
+	ErrTOTPNotEnrolled    = errors.New("TOTP enrollment has not been started")
+	ErrInvalidMFACode     = errors.New("invalid MFA code")
+	ErrInvalidMFAToken    = errors.New("invalid MFA token")
+	ErrTooManyMFAAttempts = errors.New("too many MFA attempts")
+)
+
+type mfaUsecase struct {
+	userRepo        repository.UserRepository
+	encryptor       *security.Encryptor
+	reauthenticator *Reauthenticator
+	loginThrottle   *LoginThrottle
+	authServiceCfg  *config.AuthServiceConfig
+}
+
+// NewMFAUsecase creates a new instance of MFAUsecase.
+func NewMFAUsecase(
+	userRepo repository.UserRepository,
+	encryptor *security.Encryptor,
+	reauthenticator *Reauthenticator,
+	loginThrottle *LoginThrottle,
+	authServiceCfg *config.AuthServiceConfig,
+) MFAUsecase {
+	return &mfaUsecase{
+		userRepo:        userRepo,
+		encryptor:       encryptor,
+		reauthenticator: reauthenticator,
+		loginThrottle:   loginThrottle,
+		authServiceCfg:  authServiceCfg,
+	}
+}
+
+func (u *mfaUsecase) EnrollTOTP(ctx context.Context, params EnrollTOTPParams) (*TOTPEnrollment, error) {
+	user, err := u.userRepo.GetUser(ctx, params.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TOTPEnabled {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+
+	if err := u.reauthenticator.reauthenticate(ctx, user, params.SessionID, params.Password); err != nil {
+		return nil, err
+	}
+
+	secret, err := security.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	encryptedSecret, err := u.encryptor.Encrypt(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	// Store the secret as pending until the user proves their app generates valid codes
+	if _, err := u.userRepo.UpdateUser(ctx, params.UserID, repository.UpdateUserParams{
+		TOTPSecret: &encryptedSecret,
+	}); err != nil {
+		return nil, err
+	}
+
+	return &TOTPEnrollment{
+		Secret:          secret,
+		ProvisioningURI: security.TOTPProvisioningURI(u.authServiceCfg.MFA.TOTPIssuer, user.Email, secret),
+	}, nil
+}
+
+func (u *mfaUsecase) ConfirmTOTP(ctx context.Context, userID, code string) ([]string, error) {
+	user, err := u.userRepo.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TOTPEnabled {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrTOTPNotEnrolled
+	}
+
+	secret, err := u.encryptor.Decrypt(user.TOTPSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.loginThrottle.reserveSecondFactor(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	step, ok := security.ValidateTOTP(secret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	if err := u.loginThrottle.releaseSecondFactor(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	codes, codeHashes, err := generateRecoveryCodes(u.authServiceCfg.MFA.RecoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+
+	enabled := true
+	if _, err := u.userRepo.UpdateUser(ctx, userID, repository.UpdateUserParams{
+		TOTPEnabled:      &enabled,
+		TOTPLastUsedStep: &step,
+		RecoveryCodes:    &codeHashes,
+	}); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+func (u *mfaUsecase) DisableTOTP(ctx context.Context, params DisableTOTPParams) error {
+	user, err := u.userRepo.GetUser(ctx, params.UserID)
+	if err != nil {
+		return err
+	}
+
+	if !user.TOTPEnabled {
+		return ErrTOTPNotEnabled
+	}
+
+	if err := u.reauthenticator.reauthenticate(ctx, user, params.SessionID, params.Password); err != nil {
+		return err
+	}
+
+	if err := u.reauthenticator.verifySecondFactor(ctx, user, params.Code); err != nil {
+		return err
+	}
+
+	enabled := false
+	emptySecret := ""
+	noCodes := []string{}
+	if _, err := u.userRepo.UpdateUser(ctx, params.UserID, repository.UpdateUserParams{
+		TOTPEnabled:   &enabled,
+		TOTPSecret:    &emptySecret,
+		RecoveryCodes: &noCodes,
+	}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (u *mfaUsecase) RegenerateRecoveryCodes(
+	ctx context.Context,
+	params RegenerateRecoveryCodesParams,
+) ([]string, error) {
+	user, err := u.userRepo.GetUser(ctx, params.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.TOTPEnabled {
+		return nil, ErrTOTPNotEnabled
+	}
+
+	// The new codes are as good as a second factor, so getting them takes the password as well
+	if err := u.reauthenticator.reauthenticate(ctx, user, params.SessionID, params.Password); err != nil {
+		return nil, err
+	}
+
+	if err := u.reauthenticator.verifySecondFactor(ctx, user, params.Code); err != nil {
+		return nil, err
+	}
+
+	codes, codeHashes, err := generateRecoveryCodes(u.authServiceCfg.MFA.RecoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := u.userRepo.UpdateUser(ctx, params.UserID, repository.UpdateUserParams{
+		RecoveryCodes: &codeHashes,
+	}); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+func (u *authUsecase) VerifyMFA(ctx context.Context, params VerifyMFAParams) (tokens *authtypes.Tokens, err error) {
+	// The user is only known once the challenge token has been validated
+	var userID string
+	defer func() {
+		u.authEvents.Record(ctx, &model.AuthEvent{
+			Type:   model.AuthEventMFAVerification,
+			UserID: userID,
+		}, params.Client, err)
+	}()
+
+	claims := &authtypes.MFAChallengeClaims{}
+	if _, err := u.jwtAuth.ValidateTokenWithClaims(
+		params.MFAToken,
+		u.authServiceCfg.Token.MFATokenSecret,
+		claims,
+	); err != nil {
+		return nil, ErrInvalidMFAToken
+	}
+
+	challenge, err := u.mfaChallengeRepo.IncrementAttempts(ctx, claims.ID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrInvalidMFAToken
+		}
+
+		return nil, err
+	}
+
+	if challenge.UserID != claims.UserID {
+		return nil, ErrInvalidMFAToken
+	}
+	userID = challenge.UserID
+
+	// The challenge is dropped once the limit is reached, so the user has to start over with their password
+	if challenge.Attempts > u.authServiceCfg.MFA.MaxAttempts {
+		if err := u.mfaChallengeRepo.DeleteChallenge(ctx, challenge.JTI); err != nil &&
+			!errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, err
+		}
+
+		return nil, ErrTooManyMFAAttempts
+	}
+
+	user, err := u.userRepo.GetUser(ctx, challenge.UserID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrInvalidMFAToken
+		}
+
+		return nil, err
+	}
+
+	if err := verifySecondFactor(ctx, u.userRepo, u.encryptor, user, params.Code); err != nil {
+		return nil, err
+	}
+
+	// Deleting the challenge makes it single use even if two requests get this far concurrently
+	if err := u.mfaChallengeRepo.DeleteChallenge(ctx, challenge.JTI); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrInvalidMFAToken
+		}
+
+		return nil, err
+	}
+
+	if err := u.identityRepo.UpdateLastLogin(ctx, user.ID.Hex()); err != nil {
+		return nil, err
+	}
+
+	return u.sessionIssuer.createAuthSession(ctx, user, params.Client)
+}
+
+// createMFAChallenge starts the second step of a login and returns the challenge token
+// that VerifyMFA has to be called with.
+func (s *SessionIssuer) createMFAChallenge(ctx context.Context, user *model.User) (string, error) {
+	jti, err := generateJTI()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(s.authServiceCfg.Token.MFATokenExpiresIn)
+	claims := authtypes.MFAChallengeClaims{
+		UserID: user.ID.Hex(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    s.authServiceCfg.Token.Issuer,
+			Audience:  jwt.ClaimStrings{s.authServiceCfg.Token.Issuer},
+		},
+	}
+	token, err := s.jwtAuth.GenerateToken(claims, s.authServiceCfg.Token.MFATokenSecret)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := s.mfaChallengeRepo.CreateChallenge(ctx, &model.MFAChallenge{
+		JTI:       jti,
+		UserID:    user.ID.Hex(),
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// verifySecondFactor accepts either a current TOTP code or one of the user's unused recovery codes.
+func verifySecondFactor(
+	ctx context.Context,
+	userRepo repository.UserRepository,
+	encryptor *security.Encryptor,
+	user *model.User,
+	code string,
+) error {
+	code = strings.TrimSpace(code)
+
+	if len(code) == 6 {
+		secret, err := encryptor.Decrypt(user.TOTPSecret)
+		if err != nil {
+			return err
+		}
+
+		step, ok := security.ValidateTOTP(secret, code, time.Now())
+		if !ok {
+			return ErrInvalidMFACode
+		}
+
+		// A code that has already been used is rejected, so an observed code cannot be replayed
+		used, err := userRepo.UseTOTPStep(ctx, user.ID.Hex(), step)
+		if err != nil {
+			return err
+		}
+		if !used {
+			return ErrInvalidMFACode
+		}
+
+		return nil
+	}
+
+	consumed, err := userRepo.ConsumeRecoveryCode(ctx, user.ID.Hex(), hashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return ErrInvalidMFACode
+	}
+
+	return nil
+}
+
+// recoveryCodeEncoding leaves out padding so every code has the same length.
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// generateRecoveryCodes returns count recovery codes formatted as XXXXX-XXXXX and their hashes.
+func generateRecoveryCodes(count int) ([]string, []string, error) {
+	codes := make([]string, 0, count)
+	codeHashes := make([]string, 0, count)
+	for range count {
+		bytes := make([]byte, 10)
+		if _, err := rand.Read(bytes); err != nil {
+			return nil, nil, err
+		}
+
+		encoded := recoveryCodeEncoding.EncodeToString(bytes)[:10]
+		code := encoded[:5] + "-" + encoded[5:]
+
+		codes = append(codes, code)
+		codeHashes = append(codeHashes, hashRecoveryCode(code))
+	}
+
+	return codes, codeHashes, nil
+}
+
+// hashRecoveryCode returns the hex encoded SHA-256 hash of a recovery code. Case and
+// separators are ignored, so codes can be typed the way they are easiest to read.
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+	hash := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,222 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/config"
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/model"
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/repository"
+	"github.com/vasapolrittideah/money-tracker-api/shared/provider"
+)
+
+func (u *authUsecase) StartOAuthLogin(ctx context.Context, providerName string) (string, error) {
+	p, err := u.providerRegistry.Get(providerName)
+	if err != nil {
+		if errors.Is(err, provider.ErrProviderNotFound) {
+			return "", ErrUnknownProvider
+		}
+
+		return "", err
+	}
+
+	return startOAuthFlow(ctx, u.oauthStateRepo, p, "", u.authServiceCfg.OAuth.StateExpiresIn)
+}
+
+func (u *authUsecase) CompleteOAuthLogin(
+	ctx context.Context,
+	params CompleteOAuthLoginParams,
+) (result *CompleteOAuthLoginResult, err error) {
+	var (
+		oauthState *model.OAuthState
+		user       *model.User
+	)
+	defer func() {
+		// Linking is recorded for the logged in user who started the flow rather than as a login
+		if oauthState != nil && oauthState.UserID != "" {
+			u.authEvents.Record(ctx, &model.AuthEvent{
+				Type:   model.AuthEventIdentityLink,
+				UserID: oauthState.UserID,
+				Method: oauthState.Provider,
+			}, params.Client, err)
+			return
+		}
+
+		var loginResult *LoginResult
+		if result != nil {
+			loginResult = result.Login
+		}
+		u.sessionIssuer.recordLogin(ctx, params.Provider, "", user, loginResult, params.Client, err)
+	}()
+
+	oauthState, err = u.oauthStateRepo.ConsumeOAuthState(ctx, params.State, params.Provider)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrInvalidOAuthState
+		}
+
+		return nil, err
+	}
+
+	p, err := u.providerRegistry.Get(oauthState.Provider)
+	if err != nil {
+		if errors.Is(err, provider.ErrProviderNotFound) {
+			return nil, ErrUnknownProvider
+		}
+
+		return nil, err
+	}
+
+	token, err := p.Exchange(ctx, params.Code, oauthState.CodeVerifier)
+	if err != nil {
+		if errors.Is(err, provider.ErrInvalidAuthorizationCode) {
+			return nil, ErrInvalidAuthorizationCode
+		}
+
+		return nil, err
+	}
+
+	userInfo, err := p.UserInfo(ctx, token, oauthState.Nonce)
+	if err != nil {
+		switch {
+		case errors.Is(err, provider.ErrInvalidIDToken), errors.Is(err, provider.ErrNonceMismatch):
+			return nil, ErrInvalidIDToken
+		case errors.Is(err, provider.ErrEmailNotProvided):
+			return nil, ErrProviderEmailRequired
+		default:
+			return nil, err
+		}
+	}
+
+	// The flow was started by a logged in user to link the external account
+	if oauthState.UserID != "" {
+		identity, err := linkExternalIdentity(
+			ctx,
+			u.identityRepo,
+			oauthState.UserID,
+			p.Name(),
+			userInfo.Subject,
+			userInfo.Email,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		return &CompleteOAuthLoginResult{LinkedIdentity: identity}, nil
+	}
+
+	user, err = u.findOrCreateExternalUser(
+		ctx,
+		p.Name(),
+		userInfo.Subject,
+		userInfo.Email,
+		userInfo.EmailVerified,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.Verified && u.authServiceCfg.EmailVerification.LoginPolicy == config.UnverifiedLoginDeny {
+		return nil, ErrEmailNotVerified
+	}
+
+	loginResult, err := u.sessionIssuer.completeLogin(ctx, user, params.Client)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CompleteOAuthLoginResult{Login: loginResult}, nil
+}
+
+// findOrCreateExternalUser returns the user an external account is linked to, creating both
+// the user and the identity on first sign-in.
+func (u *authUsecase) findOrCreateExternalUser(
+	ctx context.Context,
+	providerName string,
+	subject string,
+	email string,
+	emailVerified bool,
+) (*model.User, error) {
+	identity, err := u.identityRepo.GetIdentityByProvider(ctx, subject, providerName)
+	if err == nil {
+		return u.userRepo.GetUser(ctx, identity.UserID)
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	// An existing account with the same email is not linked automatically, since that would let
+	// whoever controls the external account take over an account they have not proven they own.
+	user, err := u.userRepo.CreateUser(ctx, &model.User{
+		Email:    email,
+		Verified: emailVerified,
+	})
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrUserAlreadyExists
+		}
+
+		return nil, err
+	}
+
+	if _, err := u.identityRepo.CreateIdentity(ctx, &model.Identity{
+		UserID:     user.ID.Hex(),
+		Provider:   providerName,
+		ProviderID: subject,
+		Email:      email,
+	}); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// startOAuthFlow stores the state of a new authorization code flow with the provider and returns
+// the URL to send the user to. A non-empty userID makes the flow link the account to that user.
+func startOAuthFlow(
+	ctx context.Context,
+	oauthStateRepo repository.OAuthStateRepository,
+	p provider.Provider,
+	userID string,
+	expiresIn time.Duration,
+) (string, error) {
+	state, err := generateOAuthSecret()
+	if err != nil {
+		return "", err
+	}
+	nonce, err := generateOAuthSecret()
+	if err != nil {
+		return "", err
+	}
+	codeVerifier, err := generateOAuthSecret()
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := oauthStateRepo.CreateOAuthState(ctx, &model.OAuthState{
+		State:        state,
+		Provider:     p.Name(),
+		UserID:       userID,
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		ExpiresAt:    time.Now().Add(expiresIn),
+	}); err != nil {
+		return "", err
+	}
+
+	return p.AuthCodeURL(ctx, state, nonce, codeVerifier)
+}
+
+// generateOAuthSecret generates a random URL safe string for use as state, nonce or PKCE code verifier.
+func generateOAuthSecret() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
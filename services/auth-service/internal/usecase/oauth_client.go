@@ -0,0 +1,218 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"slices"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/config"
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/model"
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/repository"
+)
+
+// OAuthClientUsecase defines the business logic for registering third-party apps and for the access
+// users have allowed them.
+type OAuthClientUsecase interface {
+	// CreateOAuthClient registers a third-party app of the user. The client secret of confidential
+	// clients is only returned here; afterwards only its hash is known.
+	CreateOAuthClient(ctx context.Context, params CreateOAuthClientParams) (*model.OAuthClient, string, error)
+
+	// ListOAuthClients returns the apps the user registered, most recently created first.
+	ListOAuthClients(ctx context.Context, userID string) ([]*model.OAuthClient, error)
+
+	// DeleteOAuthClient deletes an app of the user and signs it out of every account that authorized it.
+	DeleteOAuthClient(ctx context.Context, userID, clientID string) error
+
+	// ListOAuthConsents returns the apps the user has allowed access to their account, most recently
+	// authorized first.
+	ListOAuthConsents(ctx context.Context, userID string) ([]*OAuthConsentDetails, error)
+
+	// RevokeOAuthConsent takes back the access the user allowed an app and signs the app out.
+	RevokeOAuthConsent(ctx context.Context, userID, clientID string, client ClientInfo) error
+}
+
+// CreateOAuthClientParams defines the parameters for registering a third-party app. Confidential
+// clients, which can keep a secret on a server, get a client secret; public clients do not.
+type CreateOAuthClientParams struct {
+	UserID       string
+	Name         string
+	RedirectURIs []string
+	Scopes       []string
+	Confidential bool
+}
+
+// OAuthConsentDetails holds a consent along with the app it was given to.
+type OAuthConsentDetails struct {
+	Consent *model.OAuthConsent
+	Client  *model.OAuthClient
+}
+
+var (
+	ErrOAuthClientNotFound  = errors.New("oauth client not found")
+	ErrOAuthConsentNotFound = errors.New("oauth consent not found")
+)
+
+type oauthClientUsecase struct {
+	oauthClientRepo  repository.OAuthClientRepository
+	oauthConsentRepo repository.OAuthConsentRepository
+	sessionRepo      repository.SessionRepository
+	authEvents       *AuthEventRecorder
+	authServiceCfg   *config.AuthServiceConfig
+}
+
+// NewOAuthClientUsecase creates a new instance of OAuthClientUsecase.
+func NewOAuthClientUsecase(
+	oauthClientRepo repository.OAuthClientRepository,
+	oauthConsentRepo repository.OAuthConsentRepository,
+	sessionRepo repository.SessionRepository,
+	authEvents *AuthEventRecorder,
+	authServiceCfg *config.AuthServiceConfig,
+) OAuthClientUsecase {
+	return &oauthClientUsecase{
+		oauthClientRepo:  oauthClientRepo,
+		oauthConsentRepo: oauthConsentRepo,
+		sessionRepo:      sessionRepo,
+		authEvents:       authEvents,
+		authServiceCfg:   authServiceCfg,
+	}
+}
+
+func (u *oauthClientUsecase) CreateOAuthClient(
+	ctx context.Context,
+	params CreateOAuthClientParams,
+) (*model.OAuthClient, string, error) {
+	if len(params.RedirectURIs) == 0 {
+		return nil, "", ErrInvalidRedirectURI
+	}
+	for _, redirectURI := range params.RedirectURIs {
+		if !validRedirectURI(redirectURI) {
+			return nil, "", ErrInvalidRedirectURI
+		}
+	}
+
+	if len(params.Scopes) == 0 {
+		return nil, "", ErrInvalidScope
+	}
+	for _, scope := range params.Scopes {
+		if !slices.Contains(u.authServiceCfg.AuthorizationServer.Scopes, scope) {
+			return nil, "", ErrInvalidScope
+		}
+	}
+
+	client := &model.OAuthClient{
+		UserID:       params.UserID,
+		Name:         params.Name,
+		RedirectURIs: slices.Compact(slices.Sorted(slices.Values(params.RedirectURIs))),
+		Scopes:       slices.Compact(slices.Sorted(slices.Values(params.Scopes))),
+	}
+
+	var secret string
+	if params.Confidential {
+		var err error
+		secret, err = generateJTI()
+		if err != nil {
+			return nil, "", err
+		}
+		client.SecretHash = hashToken(secret)
+	}
+
+	if err := u.oauthClientRepo.CreateOAuthClient(ctx, client); err != nil {
+		return nil, "", err
+	}
+
+	return client, secret, nil
+}
+
+func (u *oauthClientUsecase) ListOAuthClients(ctx context.Context, userID string) ([]*model.OAuthClient, error) {
+	return u.oauthClientRepo.ListOAuthClientsByUserID(ctx, userID)
+}
+
+func (u *oauthClientUsecase) DeleteOAuthClient(ctx context.Context, userID, clientID string) error {
+	if err := u.oauthClientRepo.DeleteOAuthClient(ctx, userID, clientID); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrOAuthClientNotFound
+		}
+
+		return err
+	}
+
+	// Tokens cannot be refreshed without the client, but the current ones would otherwise stay
+	// usable until they expire
+	if err := u.sessionRepo.RevokeSessionsByClientID(ctx, clientID, ""); err != nil {
+		return err
+	}
+
+	return u.oauthConsentRepo.DeleteOAuthConsentsByClientID(ctx, clientID)
+}
+
+func (u *oauthClientUsecase) ListOAuthConsents(ctx context.Context, userID string) ([]*OAuthConsentDetails, error) {
+	consents, err := u.oauthConsentRepo.ListOAuthConsentsByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	details := make([]*OAuthConsentDetails, 0, len(consents))
+	for _, consent := range consents {
+		client, err := u.oauthClientRepo.GetOAuthClient(ctx, consent.ClientID)
+		if err != nil {
+			// The consents of a deleted client are removed right after it
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		details = append(details, &OAuthConsentDetails{
+			Consent: consent,
+			Client:  client,
+		})
+	}
+
+	return details, nil
+}
+
+func (u *oauthClientUsecase) RevokeOAuthConsent(
+	ctx context.Context,
+	userID, clientID string,
+	client ClientInfo,
+) (err error) {
+	defer func() {
+		u.authEvents.Record(ctx, &model.AuthEvent{
+			Type:   model.AuthEventOAuthConsentRevoke,
+			UserID: userID,
+		}, client, err)
+	}()
+
+	if err := u.oauthConsentRepo.DeleteOAuthConsent(ctx, userID, clientID); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrOAuthConsentNotFound
+		}
+
+		return err
+	}
+
+	return u.sessionRepo.RevokeSessionsByClientID(ctx, clientID, userID)
+}
+
+// validRedirectURI reports whether a redirect URI can be registered. Codes must only travel over
+// HTTPS, except to the loopback address of native apps and local development.
+func validRedirectURI(redirectURI string) bool {
+	parsed, err := url.Parse(redirectURI)
+	if err != nil || parsed.Host == "" || parsed.Fragment != "" {
+		return false
+	}
+
+	switch parsed.Scheme {
+	case "https":
+		return true
+	case "http":
+		hostname := parsed.Hostname()
+		return hostname == "localhost" || hostname == "127.0.0.1" || hostname == "::1"
+	default:
+		return false
+	}
+}
@@ -0,0 +1,504 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/config"
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/model"
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/repository"
+	authtypes "github.com/vasapolrittideah/money-tracker-api/services/auth-service/pkg/types"
+	"github.com/vasapolrittideah/money-tracker-api/shared/auth"
+)
+
+// OAuthAuthorizationRequest is the request a third-party app sends the user to the authorization
+// endpoint with, following the authorization code grant with PKCE.
+type OAuthAuthorizationRequest struct {
+	ResponseType        string
+	ClientID            string
+	RedirectURI         string
+	Scope               string
+	State               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+}
+
+// OAuthAuthorizationPrompt describes an authorization request to the user. Consented is set when the
+// user has allowed the app every requested scope before, so they do not have to be asked again.
+type OAuthAuthorizationPrompt struct {
+	Client    *model.OAuthClient
+	Scopes    []string
+	Consented bool
+}
+
+// AuthorizeOAuthClientParams defines the parameters for answering an authorization request.
+type AuthorizeOAuthClientParams struct {
+	UserID   string
+	Request  OAuthAuthorizationRequest
+	Approved bool
+	Client   ClientInfo
+}
+
+// Grant types the token endpoint supports.
+const (
+	OAuthGrantTypeAuthorizationCode = "authorization_code"
+	OAuthGrantTypeRefreshToken      = "refresh_token"
+)
+
+// ExchangeOAuthTokenParams defines the parameters of a token request. Code, RedirectURI and
+// CodeVerifier are used by the authorization code grant, RefreshToken by the refresh token grant.
+// Public clients have no ClientSecret.
+type ExchangeOAuthTokenParams struct {
+	GrantType    string
+	ClientID     string
+	ClientSecret string
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+	RefreshToken string
+}
+
+// OAuthTokens holds the tokens issued to a third-party app, how long the access token is valid for
+// and the scopes it is limited to.
+type OAuthTokens struct {
+	Tokens    *authtypes.Tokens
+	ExpiresIn time.Duration
+	Scopes    []string
+}
+
+// RevokeOAuthTokenParams defines the parameters for a third-party app revoking one of its tokens.
+type RevokeOAuthTokenParams struct {
+	ClientID     string
+	ClientSecret string
+	Token        string
+}
+
+const (
+	oauthResponseTypeCode        = "code"
+	oauthCodeChallengeMethodS256 = "S256"
+	// The S256 challenge is the unpadded base64url encoding of a SHA-256 hash
+	oauthCodeChallengeLength = 43
+	oauthCodeVerifierMinLen  = 43
+	oauthCodeVerifierMaxLen  = 128
+)
+
+var (
+	ErrInvalidOAuthClient      = errors.New("invalid oauth client")
+	ErrInvalidRedirectURI      = errors.New("invalid redirect uri")
+	ErrUnsupportedResponseType = errors.New("unsupported response type")
+	ErrInvalidCodeChallenge    = errors.New("code challenge using the S256 method is required")
+	ErrUnsupportedGrantType    = errors.New("unsupported grant type")
+	ErrInvalidOAuthGrant       = errors.New("invalid or expired authorization grant")
+)
+
+// OAuthServerUsecase defines the business logic of the authorization server, through which
+// third-party apps get access to the accounts of users who allow it.
+type OAuthServerUsecase interface {
+	// GetOAuthAuthorizationRequest checks a third-party app's request for access to the account of a
+	// logged in user and describes it, so the user can be asked for consent.
+	GetOAuthAuthorizationRequest(
+		ctx context.Context,
+		userID string,
+		request OAuthAuthorizationRequest,
+	) (*OAuthAuthorizationPrompt, error)
+
+	// AuthorizeOAuthClient records the user's answer to an authorization request and returns the URL
+	// to send the user back to the app with, carrying an authorization code if access was allowed.
+	AuthorizeOAuthClient(ctx context.Context, params AuthorizeOAuthClientParams) (string, error)
+
+	// ExchangeOAuthToken issues tokens to a third-party app for an authorization code or a refresh token.
+	ExchangeOAuthToken(ctx context.Context, params ExchangeOAuthTokenParams) (*OAuthTokens, error)
+
+	// RevokeOAuthToken signs a third-party app out of the session an access or refresh token belongs
+	// to. Unknown tokens are ignored, as the app's goal of the token being unusable is met.
+	RevokeOAuthToken(ctx context.Context, params RevokeOAuthTokenParams) error
+}
+
+type oauthServerUsecase struct {
+	userRepo         repository.UserRepository
+	sessionRepo      repository.SessionRepository
+	oauthClientRepo  repository.OAuthClientRepository
+	oauthConsentRepo repository.OAuthConsentRepository
+	oauthCodeRepo    repository.OAuthAuthorizationCodeRepository
+	sessionIssuer    *SessionIssuer
+	authEvents       *AuthEventRecorder
+	jwtAuth          auth.JWTAuthenticator
+	accessTokenKeys  *auth.KeySet
+	authServiceCfg   *config.AuthServiceConfig
+}
+
+// NewOAuthServerUsecase creates a new instance of OAuthServerUsecase.
+func NewOAuthServerUsecase(
+	userRepo repository.UserRepository,
+	sessionRepo repository.SessionRepository,
+	oauthClientRepo repository.OAuthClientRepository,
+	oauthConsentRepo repository.OAuthConsentRepository,
+	oauthCodeRepo repository.OAuthAuthorizationCodeRepository,
+	sessionIssuer *SessionIssuer,
+	authEvents *AuthEventRecorder,
+	jwtAuth auth.JWTAuthenticator,
+	accessTokenKeys *auth.KeySet,
+	authServiceCfg *config.AuthServiceConfig,
+) OAuthServerUsecase {
+	return &oauthServerUsecase{
+		userRepo:         userRepo,
+		sessionRepo:      sessionRepo,
+		oauthClientRepo:  oauthClientRepo,
+		oauthConsentRepo: oauthConsentRepo,
+		oauthCodeRepo:    oauthCodeRepo,
+		sessionIssuer:    sessionIssuer,
+		authEvents:       authEvents,
+		jwtAuth:          jwtAuth,
+		accessTokenKeys:  accessTokenKeys,
+		authServiceCfg:   authServiceCfg,
+	}
+}
+
+func (u *oauthServerUsecase) GetOAuthAuthorizationRequest(
+	ctx context.Context,
+	userID string,
+	request OAuthAuthorizationRequest,
+) (*OAuthAuthorizationPrompt, error) {
+	client, scopes, err := u.validateAuthorizationRequest(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	consent, err := u.oauthConsentRepo.GetOAuthConsent(ctx, userID, client.ID.Hex())
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	return &OAuthAuthorizationPrompt{
+		Client:    client,
+		Scopes:    scopes,
+		Consented: consent != nil && containsScopes(consent.Scopes, scopes),
+	}, nil
+}
+
+func (u *oauthServerUsecase) AuthorizeOAuthClient(
+	ctx context.Context,
+	params AuthorizeOAuthClientParams,
+) (_ string, err error) {
+	// Requests that fail validation are not sent back to the app: the redirect URI cannot be trusted
+	// before the client has been checked, and the user is better served by an error page than by an
+	// app that asked for something it was not registered for
+	client, scopes, err := u.validateAuthorizationRequest(ctx, params.Request)
+	if err != nil {
+		return "", err
+	}
+
+	redirectURL, err := url.Parse(params.Request.RedirectURI)
+	if err != nil {
+		return "", ErrInvalidRedirectURI
+	}
+	query := redirectURL.Query()
+	if params.Request.State != "" {
+		query.Set("state", params.Request.State)
+	}
+
+	if !params.Approved {
+		query.Set("error", "access_denied")
+		redirectURL.RawQuery = query.Encode()
+
+		return redirectURL.String(), nil
+	}
+
+	defer func() {
+		u.authEvents.Record(ctx, &model.AuthEvent{
+			Type:   model.AuthEventOAuthAuthorization,
+			UserID: params.UserID,
+		}, params.Client, err)
+	}()
+
+	if err := u.oauthConsentRepo.GrantOAuthConsent(ctx, params.UserID, client.ID.Hex(), scopes); err != nil {
+		return "", err
+	}
+
+	code, err := generateJTI()
+	if err != nil {
+		return "", err
+	}
+
+	if err := u.oauthCodeRepo.CreateAuthorizationCode(ctx, &model.OAuthAuthorizationCode{
+		CodeHash:      hashToken(code),
+		ClientID:      client.ID.Hex(),
+		UserID:        params.UserID,
+		RedirectURI:   params.Request.RedirectURI,
+		Scopes:        scopes,
+		CodeChallenge: params.Request.CodeChallenge,
+		ExpiresAt:     time.Now().Add(u.authServiceCfg.AuthorizationServer.AuthorizationCodeExpiresIn),
+	}); err != nil {
+		return "", err
+	}
+
+	query.Set("code", code)
+	redirectURL.RawQuery = query.Encode()
+
+	return redirectURL.String(), nil
+}
+
+func (u *oauthServerUsecase) ExchangeOAuthToken(
+	ctx context.Context,
+	params ExchangeOAuthTokenParams,
+) (*OAuthTokens, error) {
+	if params.GrantType != OAuthGrantTypeAuthorizationCode && params.GrantType != OAuthGrantTypeRefreshToken {
+		return nil, ErrUnsupportedGrantType
+	}
+
+	client, err := u.authenticateOAuthClient(ctx, params.ClientID, params.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	if params.GrantType == OAuthGrantTypeRefreshToken {
+		return u.refreshOAuthTokens(ctx, client, params.RefreshToken)
+	}
+
+	return u.exchangeAuthorizationCode(ctx, client, params)
+}
+
+func (u *oauthServerUsecase) RevokeOAuthToken(ctx context.Context, params RevokeOAuthTokenParams) error {
+	client, err := u.authenticateOAuthClient(ctx, params.ClientID, params.ClientSecret)
+	if err != nil {
+		return err
+	}
+
+	// Either token of the pair signs the app out of the whole session
+	claims := &authtypes.JWTClaims{}
+	if _, err := u.jwtAuth.ValidateTokenWithClaims(
+		params.Token,
+		u.authServiceCfg.Token.RefreshTokenSecret,
+		claims,
+	); err != nil {
+		claims = &authtypes.JWTClaims{}
+		if _, err := u.jwtAuth.ValidateTokenWithKeys(params.Token, u.accessTokenKeys, claims); err != nil {
+			return nil
+		}
+	}
+
+	// Apps can only revoke their own tokens; anything else is treated as an unknown token
+	if claims.ClientID != client.ID.Hex() {
+		return nil
+	}
+
+	session, err := u.sessionRepo.GetSession(ctx, claims.SessionID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil
+		}
+
+		return err
+	}
+
+	if session.ClientID != client.ID.Hex() {
+		return nil
+	}
+
+	return u.sessionRepo.RevokeSession(ctx, session.ID.Hex())
+}
+
+// validateAuthorizationRequest checks an authorization request against the registered client and
+// returns the client and the scopes the app asks for. Apps that do not ask for any scope get the
+// scopes they are registered for.
+func (u *oauthServerUsecase) validateAuthorizationRequest(
+	ctx context.Context,
+	request OAuthAuthorizationRequest,
+) (*model.OAuthClient, []string, error) {
+	client, err := u.oauthClientRepo.GetOAuthClient(ctx, request.ClientID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil, ErrInvalidOAuthClient
+		}
+
+		return nil, nil, err
+	}
+
+	// Redirect URIs are compared exactly, so codes can only ever be sent where the app said
+	if !slices.Contains(client.RedirectURIs, request.RedirectURI) {
+		return nil, nil, ErrInvalidRedirectURI
+	}
+
+	if request.ResponseType != oauthResponseTypeCode {
+		return nil, nil, ErrUnsupportedResponseType
+	}
+
+	// PKCE is required of confidential clients as well, as it also stops stolen codes from being
+	// injected into the app's own session
+	if request.CodeChallengeMethod != oauthCodeChallengeMethodS256 ||
+		len(request.CodeChallenge) != oauthCodeChallengeLength {
+		return nil, nil, ErrInvalidCodeChallenge
+	}
+	if _, err := base64.RawURLEncoding.DecodeString(request.CodeChallenge); err != nil {
+		return nil, nil, ErrInvalidCodeChallenge
+	}
+
+	scopes := strings.Fields(request.Scope)
+	if len(scopes) == 0 {
+		scopes = client.Scopes
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(client.Scopes, scope) ||
+			!slices.Contains(u.authServiceCfg.AuthorizationServer.Scopes, scope) {
+			return nil, nil, ErrInvalidScope
+		}
+	}
+
+	return client, slices.Compact(slices.Sorted(slices.Values(scopes))), nil
+}
+
+// authenticateOAuthClient returns the client with the given ID after checking its secret. Public
+// clients have no secret to check.
+func (u *oauthServerUsecase) authenticateOAuthClient(
+	ctx context.Context,
+	clientID, clientSecret string,
+) (*model.OAuthClient, error) {
+	client, err := u.oauthClientRepo.GetOAuthClient(ctx, clientID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrInvalidOAuthClient
+		}
+
+		return nil, err
+	}
+
+	if client.Confidential() &&
+		subtle.ConstantTimeCompare([]byte(hashToken(clientSecret)), []byte(client.SecretHash)) != 1 {
+		return nil, ErrInvalidOAuthClient
+	}
+
+	return client, nil
+}
+
+func (u *oauthServerUsecase) exchangeAuthorizationCode(
+	ctx context.Context,
+	client *model.OAuthClient,
+	params ExchangeOAuthTokenParams,
+) (*OAuthTokens, error) {
+	code, err := u.oauthCodeRepo.ConsumeAuthorizationCode(ctx, hashToken(params.Code))
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrInvalidOAuthGrant
+		}
+
+		return nil, err
+	}
+
+	if code.ClientID != client.ID.Hex() || code.RedirectURI != params.RedirectURI {
+		return nil, ErrInvalidOAuthGrant
+	}
+
+	if !verifyCodeVerifier(params.CodeVerifier, code.CodeChallenge) {
+		return nil, ErrInvalidOAuthGrant
+	}
+
+	user, err := u.userRepo.GetUser(ctx, code.UserID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrInvalidOAuthGrant
+		}
+
+		return nil, err
+	}
+
+	// Only the user logging in keeps an account that is scheduled for deletion
+	if user.DeletedAt != nil {
+		return nil, ErrInvalidOAuthGrant
+	}
+
+	// The user may have revoked the app's access since the code was issued
+	consent, err := u.oauthConsentRepo.GetOAuthConsent(ctx, user.ID.Hex(), client.ID.Hex())
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrInvalidOAuthGrant
+		}
+
+		return nil, err
+	}
+	if !containsScopes(consent.Scopes, code.Scopes) {
+		return nil, ErrInvalidOAuthGrant
+	}
+
+	session, err := u.sessionRepo.CreateSession(ctx, &model.Session{
+		UserID:   user.ID.Hex(),
+		ClientID: client.ID.Hex(),
+		Scope:    strings.Join(code.Scopes, " "),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, tokenParams, err := u.sessionIssuer.generateTokens(user, session)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := u.sessionRepo.UpdateTokens(ctx, session.ID.Hex(), tokenParams); err != nil {
+		return nil, err
+	}
+
+	return &OAuthTokens{
+		Tokens:    tokens,
+		ExpiresIn: u.authServiceCfg.Token.AccessTokenExpiresIn,
+		Scopes:    code.Scopes,
+	}, nil
+}
+
+func (u *oauthServerUsecase) refreshOAuthTokens(
+	ctx context.Context,
+	client *model.OAuthClient,
+	refreshToken string,
+) (*OAuthTokens, error) {
+	user, session, err := u.sessionIssuer.sessionFromRefreshToken(ctx, refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if session.ClientID != client.ID.Hex() {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	tokens, err := u.sessionIssuer.rotateTokens(ctx, user, session, refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OAuthTokens{
+		Tokens:    tokens,
+		ExpiresIn: u.authServiceCfg.Token.AccessTokenExpiresIn,
+		Scopes:    strings.Fields(session.Scope),
+	}, nil
+}
+
+// verifyCodeVerifier reports whether the PKCE code verifier an app sent with its token request
+// hashes to the challenge of the authorization request.
+func verifyCodeVerifier(verifier, challenge string) bool {
+	if len(verifier) < oauthCodeVerifierMinLen || len(verifier) > oauthCodeVerifierMaxLen {
+		return false
+	}
+
+	hash := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(hash[:])
+
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
+
+// containsScopes reports whether granted includes every scope of requested.
+func containsScopes(granted, requested []string) bool {
+	for _, scope := range requested {
+		if !slices.Contains(granted, scope) {
+			return false
+		}
+	}
+
+	return true
+}
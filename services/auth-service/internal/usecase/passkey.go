@@ -0,0 +1,372 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/config"
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/model"
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/repository"
+	authtypes "github.com/vasapolrittideah/money-tracker-api/services/auth-service/pkg/types"
+	"github.com/vasapolrittideah/money-tracker-api/shared/webauthn"
+)
+
+// PasskeyUsecase defines the business logic for registering passkeys.
+type PasskeyUsecase interface {
+	// BeginPasskeyRegistration re-authenticates the user and returns the options to create
+	// a new passkey with.
+	BeginPasskeyRegistration(
+		ctx context.Context,
+		params BeginPasskeyRegistrationParams,
+	) (*PasskeyRegistrationOptions, error)
+
+	// FinishPasskeyRegistration verifies the authenticator's response to BeginPasskeyRegistration
+	// and stores the new passkey.
+	FinishPasskeyRegistration(
+		ctx context.Context,
+		params FinishPasskeyRegistrationParams,
+	) (*model.WebAuthnCredential, error)
+}
+
+// BeginPasskeyRegistrationParams defines the parameters for starting a passkey registration.
+type BeginPasskeyRegistrationParams struct {
+	UserID    string
+	SessionID string
+	Password  string
+}
+
+// FinishPasskeyRegistrationParams defines the parameters of an authenticator's registration response.
+type FinishPasskeyRegistrationParams struct {
+	UserID            string
+	ClientDataJSON    []byte
+	AttestationObject []byte
+	Transports        []string
+	Name              string
+}
+
+// FinishPasskeyLoginParams defines the parameters of an authenticator's login response.
+type FinishPasskeyLoginParams struct {
+	CredentialID      []byte
+	ClientDataJSON    []byte
+	AuthenticatorData []byte
+	Signature         []byte
+	UserHandle        []byte
+	Client            ClientInfo
+}
+
+// PasskeyRegistrationOptions holds what the client needs to create a passkey. UserHandle is the
+// opaque user ID stored on the authenticator, and ExcludeCredentialIDs are the base64url encoded
+// IDs of the user's existing passkeys, so the same authenticator is not registered twice.
+type PasskeyRegistrationOptions struct {
+	Challenge            string
+	RPID                 string
+	RPName               string
+	UserHandle           []byte
+	UserName             string
+	ExcludeCredentialIDs []string
+	Timeout              time.Duration
+}
+
+// PasskeyLoginOptions holds what the client needs to log in with a passkey.
+type PasskeyLoginOptions struct {
+	Challenge string
+	RPID      string
+	Timeout   time.Duration
+}
+
+var (
+	ErrInvalidPasskeyChallenge  = errors.New("invalid or expired passkey challenge")
+	ErrInvalidPasskey           = errors.New("invalid passkey")
+	ErrPasskeyAlreadyRegistered = errors.New("passkey is already registered")
+)
+
+// defaultPasskeyName is used for passkeys the user did not name.
+const defaultPasskeyName = "Passkey"
+
+type passkeyUsecase struct {
+	userRepo               repository.UserRepository
+	webAuthnCredentialRepo repository.WebAuthnCredentialRepository
+	webAuthnSessionRepo    repository.WebAuthnSessionRepository
+	relyingParty           *webauthn.RelyingParty
+	reauthenticator        *Reauthenticator
+	authServiceCfg         *config.AuthServiceConfig
+}
+
+// NewPasskeyUsecase creates a new instance of PasskeyUsecase.
+func NewPasskeyUsecase(
+	userRepo repository.UserRepository,
+	webAuthnCredentialRepo repository.WebAuthnCredentialRepository,
+	webAuthnSessionRepo repository.WebAuthnSessionRepository,
+	relyingParty *webauthn.RelyingParty,
+	reauthenticator *Reauthenticator,
+	authServiceCfg *config.AuthServiceConfig,
+) PasskeyUsecase {
+	return &passkeyUsecase{
+		userRepo:               userRepo,
+		webAuthnCredentialRepo: webAuthnCredentialRepo,
+		webAuthnSessionRepo:    webAuthnSessionRepo,
+		relyingParty:           relyingParty,
+		reauthenticator:        reauthenticator,
+		authServiceCfg:         authServiceCfg,
+	}
+}
+
+func (u *passkeyUsecase) BeginPasskeyRegistration(
+	ctx context.Context,
+	params BeginPasskeyRegistrationParams,
+) (*PasskeyRegistrationOptions, error) {
+	user, err := u.userRepo.GetUser(ctx, params.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.reauthenticator.reauthenticate(ctx, user, params.SessionID, params.Password); err != nil {
+		return nil, err
+	}
+
+	credentials, err := u.webAuthnCredentialRepo.GetCredentialsByUserID(ctx, params.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	excludeCredentialIDs := make([]string, 0, len(credentials))
+	for _, credential := range credentials {
+		excludeCredentialIDs = append(excludeCredentialIDs, credential.CredentialID)
+	}
+
+	challenge, err := createWebAuthnSession(
+		ctx,
+		u.webAuthnSessionRepo,
+		model.WebAuthnCeremonyRegistration,
+		params.UserID,
+		u.authServiceCfg.WebAuthn.Timeout,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PasskeyRegistrationOptions{
+		Challenge:            challenge,
+		RPID:                 u.relyingParty.ID,
+		RPName:               u.relyingParty.Name,
+		UserHandle:           user.ID[:],
+		UserName:             user.Email,
+		ExcludeCredentialIDs: excludeCredentialIDs,
+		Timeout:              u.authServiceCfg.WebAuthn.Timeout,
+	}, nil
+}
+
+func (u *passkeyUsecase) FinishPasskeyRegistration(
+	ctx context.Context,
+	params FinishPasskeyRegistrationParams,
+) (*model.WebAuthnCredential, error) {
+	session, err := consumeWebAuthnSession(
+		ctx,
+		u.webAuthnSessionRepo,
+		params.ClientDataJSON,
+		model.WebAuthnCeremonyRegistration,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// The challenge was issued to the user who re-authenticated, not to whoever presents it
+	if session.UserID != params.UserID {
+		return nil, ErrInvalidPasskeyChallenge
+	}
+
+	credential, err := u.relyingParty.VerifyRegistration(
+		session.Challenge,
+		params.ClientDataJSON,
+		params.AttestationObject,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPasskey, err)
+	}
+
+	name := params.Name
+	if name == "" {
+		name = defaultPasskeyName
+	}
+
+	created, err := u.webAuthnCredentialRepo.CreateCredential(ctx, &model.WebAuthnCredential{
+		UserID:         params.UserID,
+		CredentialID:   base64.RawURLEncoding.EncodeToString(credential.ID),
+		PublicKey:      credential.PublicKey,
+		SignCount:      credential.SignCount,
+		Transports:     params.Transports,
+		AAGUID:         credential.AAGUID,
+		Name:           name,
+		BackupEligible: credential.BackupEligible,
+		BackedUp:       credential.BackedUp,
+	})
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrPasskeyAlreadyRegistered
+		}
+
+		return nil, err
+	}
+
+	return created, nil
+}
+
+func (u *authUsecase) BeginPasskeyLogin(ctx context.Context) (*PasskeyLoginOptions, error) {
+	// The user is not known yet: the authenticator offers its discoverable passkeys for the
+	// relying party and the user handle in the response identifies the account.
+	challenge, err := createWebAuthnSession(
+		ctx,
+		u.webAuthnSessionRepo,
+		model.WebAuthnCeremonyLogin,
+		"",
+		u.authServiceCfg.WebAuthn.Timeout,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PasskeyLoginOptions{
+		Challenge: challenge,
+		RPID:      u.relyingParty.ID,
+		Timeout:   u.authServiceCfg.WebAuthn.Timeout,
+	}, nil
+}
+
+func (u *authUsecase) FinishPasskeyLogin(
+	ctx context.Context,
+	params FinishPasskeyLoginParams,
+) (tokens *authtypes.Tokens, err error) {
+	var user *model.User
+	defer func() {
+		u.sessionIssuer.recordLogin(ctx, model.AuthMethodPasskey, "", user, nil, params.Client, err)
+	}()
+
+	session, err := consumeWebAuthnSession(
+		ctx,
+		u.webAuthnSessionRepo,
+		params.ClientDataJSON,
+		model.WebAuthnCeremonyLogin,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	credential, err := u.webAuthnCredentialRepo.GetCredentialByCredentialID(
+		ctx,
+		base64.RawURLEncoding.EncodeToString(params.CredentialID),
+	)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrInvalidPasskey
+		}
+
+		return nil, err
+	}
+
+	user, err = u.userRepo.GetUser(ctx, credential.UserID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrInvalidPasskey
+		}
+
+		return nil, err
+	}
+
+	if len(params.UserHandle) != 0 && !bytes.Equal(params.UserHandle, user.ID[:]) {
+		return nil, ErrInvalidPasskey
+	}
+
+	assertion, err := u.relyingParty.VerifyAssertion(
+		session.Challenge,
+		credential.PublicKey,
+		credential.SignCount,
+		params.ClientDataJSON,
+		params.AuthenticatorData,
+		params.Signature,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPasskey, err)
+	}
+
+	if err := u.webAuthnCredentialRepo.UpdateSignCount(
+		ctx,
+		credential.ID.Hex(),
+		credential.SignCount,
+		assertion.SignCount,
+		assertion.BackedUp,
+	); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			// Another login with the same counter value won the race
+			return nil, ErrInvalidPasskey
+		}
+
+		return nil, err
+	}
+
+	if !user.Verified && u.authServiceCfg.EmailVerification.LoginPolicy == config.UnverifiedLoginDeny {
+		return nil, ErrEmailNotVerified
+	}
+
+	// Passkeys require user verification, so they are a second factor of their own and
+	// users with TOTP enabled are not asked for a code.
+	if err := u.identityRepo.UpdateLastLogin(ctx, user.ID.Hex()); err != nil {
+		return nil, err
+	}
+
+	return u.sessionIssuer.createAuthSession(ctx, user, params.Client)
+}
+
+// createWebAuthnSession stores a new challenge for a passkey ceremony and returns it.
+func createWebAuthnSession(
+	ctx context.Context,
+	webAuthnSessionRepo repository.WebAuthnSessionRepository,
+	ceremony string,
+	userID string,
+	timeout time.Duration,
+) (string, error) {
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := webAuthnSessionRepo.CreateWebAuthnSession(ctx, &model.WebAuthnSession{
+		Challenge: challenge,
+		Ceremony:  ceremony,
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(timeout),
+	}); err != nil {
+		return "", err
+	}
+
+	return challenge, nil
+}
+
+// consumeWebAuthnSession finds the ceremony a response belongs to by the challenge in its client data.
+func consumeWebAuthnSession(
+	ctx context.Context,
+	webAuthnSessionRepo repository.WebAuthnSessionRepository,
+	clientDataJSON []byte,
+	ceremony string,
+) (*model.WebAuthnSession, error) {
+	clientData, err := webauthn.ParseClientData(clientDataJSON)
+	if err != nil {
+		return nil, ErrInvalidPasskeyChallenge
+	}
+
+	session, err := webAuthnSessionRepo.ConsumeWebAuthnSession(ctx, clientData.Challenge, ceremony)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrInvalidPasskeyChallenge
+		}
+
+		return nil, err
+	}
+
+	return session, nil
+}
@@ -0,0 +1,117 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"time"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/model"
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/repository"
+)
+
+var ErrPasswordNotSet = errors.New("account has no password")
+
+// ChangePasswordParams defines the parameters for changing the password of a logged in user.
+type ChangePasswordParams struct {
+	UserID          string
+	SessionID       string
+	CurrentPassword string
+	NewPassword     string
+	Client          ClientInfo
+}
+
+func (u *authUsecase) ChangePassword(ctx context.Context, params ChangePasswordParams) (err error) {
+	defer func() {
+		u.authEvents.Record(ctx, &model.AuthEvent{
+			Type:   model.AuthEventPasswordChange,
+			UserID: params.UserID,
+		}, params.Client, err)
+	}()
+
+	user, err := u.userRepo.GetUser(ctx, params.UserID)
+	if err != nil {
+		return err
+	}
+
+	// Users who signed up with an external provider set their first password with a reset
+	if user.PasswordHash == "" {
+		return ErrPasswordNotSet
+	}
+
+	// A stolen access token must not become a way around the login throttle to guess the password
+	accountKey, ipKey := loginAttemptKeys(user.Email, params.Client)
+	if err := u.loginThrottle.reserve(ctx, user, accountKey, ipKey); err != nil {
+		return err
+	}
+
+	ok, _, err := u.passwordHasher.Verify(params.CurrentPassword, user.PasswordHash)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidCredentials
+	}
+
+	if err := u.loginThrottle.release(ctx, accountKey, ipKey); err != nil {
+		return err
+	}
+
+	if err := validateNewPassword(u.passwordPolicy, params.NewPassword, user.Email); err != nil {
+		return err
+	}
+
+	passwordHash, err := u.passwordHasher.Hash(params.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	// A new password also lifts the block of a reported sign-in
+	resetRequired := false
+	if _, err := u.userRepo.UpdateUser(ctx, user.ID.Hex(), repository.UpdateUserParams{
+		PasswordHash:          &passwordHash,
+		PasswordResetRequired: &resetRequired,
+	}); err != nil {
+		return err
+	}
+
+	// Anyone else who knew the old password is signed out; the device that changed it stays signed in
+	if err := u.sessionRepo.RevokeOtherSessionsByUserID(ctx, user.ID.Hex(), params.SessionID); err != nil {
+		return err
+	}
+
+	// Tokens created by whoever knew the old password would otherwise keep working
+	if err := u.personalAccessTokenRepo.DeleteTokensByUserID(ctx, user.ID.Hex()); err != nil {
+		return err
+	}
+
+	// The password has been changed already, so a failed email must not fail the request
+	if err := u.sendPasswordChangedNotification(user, params.Client); err != nil {
+		u.logger.Error().Err(err).Str("userID", user.ID.Hex()).Msg("failed to send password changed notification")
+	}
+
+	return nil
+}
+
+func (u *authUsecase) sendPasswordChangedNotification(user *model.User, client ClientInfo) error {
+	device := "an unknown device"
+	if client.IPAddress != "" {
+		device = "IP address " + html.EscapeString(client.IPAddress)
+	}
+
+	htmlBody := fmt.Sprintf(`
+		<p>Hi,</p>
+		<p>The password for your account was changed on %s from %s.
+		Every other device signed in to your account has been signed out.</p>
+
+		<p>If this was you, no further action is needed.</p>
+		<p>If this was not you, reset your password right away using "Forgot password" on the
+		login page, and review the devices signed in to your account.</p>
+
+		<p>Thank you,</p>
+		<p>Money Tracker Team</p>
+	`, time.Now().UTC().Format(time.RFC1123), device)
+
+	return u.mailer.SendHTML([]string{user.Email}, "Your Password Was Changed", htmlBody)
+}
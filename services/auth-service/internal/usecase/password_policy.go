@@ -0,0 +1,25 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vasapolrittideah/money-tracker-api/shared/security"
+)
+
+// ErrPasswordPolicyViolation wraps the reason a new password was rejected. The message of the
+// wrapping error is meant to be shown to the user.
+var ErrPasswordPolicyViolation = errors.New("password does not meet the requirements")
+
+// validateNewPassword checks a password a user is about to set against the password policy.
+func validateNewPassword(passwordPolicy *security.PasswordPolicy, password, email string) error {
+	if err := passwordPolicy.Validate(password, email); err != nil {
+		if security.IsPasswordPolicyViolation(err) {
+			return fmt.Errorf("%w: %w", ErrPasswordPolicyViolation, err)
+		}
+
+		return err
+	}
+
+	return nil
+}
@@ -3,6 +3,7 @@ package usecase
 import (
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -23,21 +24,27 @@ import (
 // PasswordResetUsecase defines the business logic for password reset token operations.
 type PasswordResetUsecase interface {
 	// RequestPasswordReset initiates the password reset process for a given email.
-	RequestPasswordReset(ctx context.Context, email string) error
+	RequestPasswordReset(ctx context.Context, email string, client ClientInfo) error
 
-	// ResetPassword resets the user's password using the provided jti and new password.
-	ResetPassword(ctx context.Context, jti, newPassword string) error
+	// ResetPassword resets the user's password using the provided jti and new password, and deletes
+	// the user's personal access tokens.
+	ResetPassword(ctx context.Context, jti, newPassword string, client ClientInfo) error
 
 	// ValidatePasswordResetToken checks if the provided jti is not used.
 	ValidatePasswordResetToken(ctx context.Context, jti string) error
 }
 
 type passwordResetUsecase struct {
-	userRepo       repository.UserRepository
-	tokenRepo      repository.PasswordResetTokenRepository
-	jwtAuth        auth.JWTAuthenticator
-	mailer         *mailer.Mailer
-	authServiceCfg *config.AuthServiceConfig
+	userRepo                repository.UserRepository
+	tokenRepo               repository.PasswordResetTokenRepository
+	personalAccessTokenRepo repository.PersonalAccessTokenRepository
+	loginAttemptRepo        repository.LoginAttemptRepository
+	passwordPolicy          *security.PasswordPolicy
+	passwordHasher          *security.PasswordHasher
+	jwtAuth                 auth.JWTAuthenticator
+	authEvents              *AuthEventRecorder
+	mailer                  *mailer.Mailer
+	authServiceCfg          *config.AuthServiceConfig
 }
 
 var (
@@ -51,22 +58,47 @@ var (
 func NewPasswordResetUsecase(
 	userRepo repository.UserRepository,
 	tokenRepo repository.PasswordResetTokenRepository,
+	personalAccessTokenRepo repository.PersonalAccessTokenRepository,
+	loginAttemptRepo repository.LoginAttemptRepository,
+	passwordPolicy *security.PasswordPolicy,
+	passwordHasher *security.PasswordHasher,
 	jwtAuth auth.JWTAuthenticator,
+	authEvents *AuthEventRecorder,
 	mailer *mailer.Mailer,
 	authServiceCfg *config.AuthServiceConfig,
 ) PasswordResetUsecase {
 	return &passwordResetUsecase{
-		userRepo:       userRepo,
-		tokenRepo:      tokenRepo,
-		jwtAuth:        jwtAuth,
-		mailer:         mailer,
-		authServiceCfg: authServiceCfg,
+		userRepo:                userRepo,
+		tokenRepo:               tokenRepo,
+		personalAccessTokenRepo: personalAccessTokenRepo,
+		loginAttemptRepo:        loginAttemptRepo,
+		passwordPolicy:          passwordPolicy,
+		passwordHasher:          passwordHasher,
+		jwtAuth:                 jwtAuth,
+		authEvents:              authEvents,
+		mailer:                  mailer,
+		authServiceCfg:          authServiceCfg,
 	}
 }
 
-func (u *passwordResetUsecase) RequestPasswordReset(ctx context.Context, email string) error {
+func (u *passwordResetUsecase) RequestPasswordReset(
+	ctx context.Context,
+	email string,
+	client ClientInfo,
+) (err error) {
+	var user *model.User
+	defer func() {
+		event := &model.AuthEvent{Type: model.AuthEventPasswordResetRequest}
+		if user != nil {
+			event.UserID = user.ID.Hex()
+		} else {
+			event.Email = email
+		}
+		u.authEvents.Record(ctx, event, client, err)
+	}()
+
 	// Get user by email
-	user, err := u.userRepo.GetUserByEmail(ctx, email)
+	user, err = u.userRepo.GetUserByEmail(ctx, email)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
 			// To prevent email enumeration, do not reveal that the email does not exist.
@@ -122,9 +154,23 @@ func (u *passwordResetUsecase) RequestPasswordReset(ctx context.Context, email s
 	return nil
 }
 
-func (u *passwordResetUsecase) ResetPassword(ctx context.Context, jti, newPassword string) error {
+func (u *passwordResetUsecase) ResetPassword(
+	ctx context.Context,
+	jti string,
+	newPassword string,
+	client ClientInfo,
+) (err error) {
+	var resetToken *model.PasswordResetToken
+	defer func() {
+		event := &model.AuthEvent{Type: model.AuthEventPasswordReset}
+		if resetToken != nil {
+			event.UserID = resetToken.UserID.Hex()
+		}
+		u.authEvents.Record(ctx, event, client, err)
+	}()
+
 	// Check token in database
-	resetToken, err := u.tokenRepo.GetTokenByJTI(ctx, jti)
+	resetToken, err = u.tokenRepo.GetTokenByJTI(ctx, jti)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
 			return ErrTokenNotFound
@@ -141,16 +187,28 @@ func (u *passwordResetUsecase) ResetPassword(ctx context.Context, jti, newPasswo
 		return ErrTokenExpired
 	}
 
+	user, err := u.userRepo.GetUser(ctx, resetToken.UserID.Hex())
+	if err != nil {
+		return err
+	}
+
+	if err := validateNewPassword(u.passwordPolicy, newPassword, user.Email); err != nil {
+		return err
+	}
+
 	// Hash new password
-	passwordHash, err := security.HashPassword(newPassword)
+	passwordHash, err := u.passwordHasher.Hash(newPassword)
 	if err != nil {
 		return err
 	}
 
-	// Update user's password
-	if _, err := u.userRepo.UpdateUser(ctx, resetToken.UserID.Hex(), repository.UpdateUserParams{
-		PasswordHash: &passwordHash,
-	}); err != nil {
+	// Update user's password, which also lifts the block of a reported sign-in
+	resetRequired := false
+	user, err = u.userRepo.UpdateUser(ctx, resetToken.UserID.Hex(), repository.UpdateUserParams{
+		PasswordHash:          &passwordHash,
+		PasswordResetRequired: &resetRequired,
+	})
+	if err != nil {
 		return err
 	}
 
@@ -159,6 +217,17 @@ func (u *passwordResetUsecase) ResetPassword(ctx context.Context, jti, newPasswo
 		return err
 	}
 
+	// Tokens created by whoever knew the old password would otherwise keep working
+	if err := u.personalAccessTokenRepo.DeleteTokensByUserID(ctx, user.ID.Hex()); err != nil {
+		return err
+	}
+
+	// Whoever was guessing the old password has nothing left to guess, so lift any lockout
+	accountKey, _ := loginAttemptKeys(user.Email, ClientInfo{})
+	if err := u.loginAttemptRepo.ResetLoginAttempts(ctx, accountKey); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -222,3 +291,10 @@ func generateJTI() (string, error) {
 	}
 	return hex.EncodeToString(bytes), nil
 }
+
+// hashToken hashes a token generated with generateJTI for storage, so the token cannot be used by
+// anyone who can read the database.
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
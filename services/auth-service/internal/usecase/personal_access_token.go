@@ -0,0 +1,255 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/config"
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/model"
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/repository"
+	"github.com/vasapolrittideah/money-tracker-api/shared/interceptor"
+)
+
+// PersonalAccessTokenUsecase defines the business logic for the tokens users create for scripts and
+// integrations.
+type PersonalAccessTokenUsecase interface {
+	// CreatePersonalAccessToken re-authenticates the user, with a second factor if they have one, and
+	// creates a token. The token itself is only returned here; afterwards only its hash is known.
+	CreatePersonalAccessToken(
+		ctx context.Context,
+		params CreatePersonalAccessTokenParams,
+	) (*model.PersonalAccessToken, string, error)
+
+	// ListPersonalAccessTokens returns the tokens of a user, most recently created first.
+	ListPersonalAccessTokens(ctx context.Context, userID string) ([]*model.PersonalAccessToken, error)
+
+	// RevokePersonalAccessToken deletes a token of the user, after which it no longer authenticates.
+	RevokePersonalAccessToken(ctx context.Context, userID, tokenID string, client ClientInfo) error
+
+	// IntrospectPersonalAccessToken reports whether a personal access token is valid and who it belongs
+	// to, and records that it was used.
+	IntrospectPersonalAccessToken(ctx context.Context, token string) (*TokenIntrospection, error)
+
+	// ValidatePersonalAccessToken introspects a personal access token and returns the claims it stands
+	// for, so the service can pass the usecase to interceptor.NewJWTInterceptor.
+	ValidatePersonalAccessToken(ctx context.Context, token string) (jwt.MapClaims, error)
+}
+
+// CreatePersonalAccessTokenParams defines the parameters for creating a personal access token.
+// ExpiresAt is nil for a token that does not expire.
+type CreatePersonalAccessTokenParams struct {
+	UserID    string
+	SessionID string
+	Password  string
+	Code      string
+	Name      string
+	Scopes    []string
+	ExpiresAt *time.Time
+	Client    ClientInfo
+}
+
+var (
+	ErrInvalidScope                = errors.New("invalid scope")
+	ErrInvalidExpiry               = errors.New("expiry must be in the future")
+	ErrTooManyPersonalAccessTokens = errors.New("too many personal access tokens")
+	ErrPersonalAccessTokenNotFound = errors.New("personal access token not found")
+	ErrInactivePersonalAccessToken = errors.New("personal access token is not active")
+)
+
+type personalAccessTokenUsecase struct {
+	userRepo                repository.UserRepository
+	personalAccessTokenRepo repository.PersonalAccessTokenRepository
+	reauthenticator         *Reauthenticator
+	authEvents              *AuthEventRecorder
+	authServiceCfg          *config.AuthServiceConfig
+}
+
+// NewPersonalAccessTokenUsecase creates a new instance of PersonalAccessTokenUsecase.
+func NewPersonalAccessTokenUsecase(
+	userRepo repository.UserRepository,
+	personalAccessTokenRepo repository.PersonalAccessTokenRepository,
+	reauthenticator *Reauthenticator,
+	authEvents *AuthEventRecorder,
+	authServiceCfg *config.AuthServiceConfig,
+) PersonalAccessTokenUsecase {
+	return &personalAccessTokenUsecase{
+		userRepo:                userRepo,
+		personalAccessTokenRepo: personalAccessTokenRepo,
+		reauthenticator:         reauthenticator,
+		authEvents:              authEvents,
+		authServiceCfg:          authServiceCfg,
+	}
+}
+
+func (u *personalAccessTokenUsecase) CreatePersonalAccessToken(
+	ctx context.Context,
+	params CreatePersonalAccessTokenParams,
+) (_ *model.PersonalAccessToken, _ string, err error) {
+	defer func() {
+		u.authEvents.Record(ctx, &model.AuthEvent{
+			Type:   model.AuthEventPersonalAccessTokenCreate,
+			UserID: params.UserID,
+		}, params.Client, err)
+	}()
+
+	if len(params.Scopes) == 0 {
+		return nil, "", ErrInvalidScope
+	}
+	for _, scope := range params.Scopes {
+		if !slices.Contains(u.authServiceCfg.PersonalAccessToken.Scopes, scope) {
+			return nil, "", ErrInvalidScope
+		}
+	}
+
+	if params.ExpiresAt != nil && !params.ExpiresAt.After(time.Now()) {
+		return nil, "", ErrInvalidExpiry
+	}
+
+	user, err := u.userRepo.GetUser(ctx, params.UserID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// A token outlives every session, so whoever creates one has to prove they own the account
+	if err := u.reauthenticator.reauthenticateWithSecondFactor(
+		ctx,
+		user,
+		params.SessionID,
+		params.Password,
+		params.Code,
+	); err != nil {
+		return nil, "", err
+	}
+
+	count, err := u.personalAccessTokenRepo.CountPersonalAccessTokensByUserID(ctx, params.UserID)
+	if err != nil {
+		return nil, "", err
+	}
+	if count >= int64(u.authServiceCfg.PersonalAccessToken.MaxPerUser) {
+		return nil, "", ErrTooManyPersonalAccessTokens
+	}
+
+	secret, err := generateJTI()
+	if err != nil {
+		return nil, "", err
+	}
+	tokenStr := interceptor.PersonalAccessTokenPrefix + secret
+
+	token := &model.PersonalAccessToken{
+		UserID:    params.UserID,
+		Name:      params.Name,
+		TokenHash: hashToken(tokenStr),
+		Scopes:    slices.Compact(slices.Sorted(slices.Values(params.Scopes))),
+		ExpiresAt: params.ExpiresAt,
+	}
+	if err := u.personalAccessTokenRepo.CreatePersonalAccessToken(ctx, token); err != nil {
+		return nil, "", err
+	}
+
+	return token, tokenStr, nil
+}
+
+func (u *personalAccessTokenUsecase) ListPersonalAccessTokens(
+	ctx context.Context,
+	userID string,
+) ([]*model.PersonalAccessToken, error) {
+	return u.personalAccessTokenRepo.ListPersonalAccessTokensByUserID(ctx, userID)
+}
+
+func (u *personalAccessTokenUsecase) RevokePersonalAccessToken(
+	ctx context.Context,
+	userID, tokenID string,
+	client ClientInfo,
+) (err error) {
+	defer func() {
+		u.authEvents.Record(ctx, &model.AuthEvent{
+			Type:   model.AuthEventPersonalAccessTokenRevoke,
+			UserID: userID,
+		}, client, err)
+	}()
+
+	if err := u.personalAccessTokenRepo.DeletePersonalAccessToken(ctx, userID, tokenID); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrPersonalAccessTokenNotFound
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (u *personalAccessTokenUsecase) IntrospectPersonalAccessToken(
+	ctx context.Context,
+	tokenStr string,
+) (*TokenIntrospection, error) {
+	token, err := u.personalAccessTokenRepo.GetPersonalAccessTokenByHash(ctx, hashToken(tokenStr))
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return &TokenIntrospection{Active: false}, nil
+		}
+
+		return nil, err
+	}
+
+	// Tokens are kept through the deletion grace period so they work again if the account is restored
+	user, err := u.userRepo.GetUser(ctx, token.UserID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return &TokenIntrospection{Active: false}, nil
+		}
+
+		return nil, err
+	}
+	if user.DeletedAt != nil {
+		return &TokenIntrospection{Active: false}, nil
+	}
+
+	if err := u.personalAccessTokenRepo.UpdateLastUsed(ctx, token.ID.Hex(), time.Now()); err != nil {
+		return nil, err
+	}
+
+	introspection := &TokenIntrospection{
+		Active:    true,
+		TokenType: TokenTypePersonalAccessToken,
+		UserID:    token.UserID,
+		Scopes:    token.Scopes,
+	}
+	if token.ExpiresAt != nil {
+		introspection.ExpiresAt = *token.ExpiresAt
+	}
+
+	return introspection, nil
+}
+
+func (u *personalAccessTokenUsecase) ValidatePersonalAccessToken(
+	ctx context.Context,
+	tokenStr string,
+) (jwt.MapClaims, error) {
+	introspection, err := u.IntrospectPersonalAccessToken(ctx, tokenStr)
+	if err != nil {
+		return nil, err
+	}
+
+	if !introspection.Active {
+		return nil, ErrInactivePersonalAccessToken
+	}
+
+	// The same claims authclient.ValidatePersonalAccessToken gives the other services
+	claims := jwt.MapClaims{
+		"user_id":    introspection.UserID,
+		"scope":      strings.Join(introspection.Scopes, " "),
+		"token_type": introspection.TokenType,
+	}
+	if !introspection.ExpiresAt.IsZero() {
+		claims["exp"] = introspection.ExpiresAt.Unix()
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,124 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/config"
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/model"
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/repository"
+	"github.com/vasapolrittideah/money-tracker-api/shared/security"
+)
+
+var ErrReauthenticationRequired = errors.New("re-authentication is required")
+
+// Reauthenticator confirms that the user making a sensitive request is the account owner and not
+// someone who got hold of their access token.
+type Reauthenticator struct {
+	userRepo       repository.UserRepository
+	sessionRepo    repository.SessionRepository
+	passwordHasher *security.PasswordHasher
+	encryptor      *security.Encryptor
+	loginThrottle  *LoginThrottle
+	authServiceCfg *config.AuthServiceConfig
+}
+
+// NewReauthenticator creates a new Reauthenticator.
+func NewReauthenticator(
+	userRepo repository.UserRepository,
+	sessionRepo repository.SessionRepository,
+	passwordHasher *security.PasswordHasher,
+	encryptor *security.Encryptor,
+	loginThrottle *LoginThrottle,
+	authServiceCfg *config.AuthServiceConfig,
+) *Reauthenticator {
+	return &Reauthenticator{
+		userRepo:       userRepo,
+		sessionRepo:    sessionRepo,
+		passwordHasher: passwordHasher,
+		encryptor:      encryptor,
+		loginThrottle:  loginThrottle,
+		authServiceCfg: authServiceCfg,
+	}
+}
+
+// reauthenticate checks that user made the request. Users with a password must enter it; users who
+// only sign in with external providers must have logged in within the reauthentication max age.
+// Password checks count against the account's login throttle, so a stolen access token is no way
+// around it to guess the password.
+func (r *Reauthenticator) reauthenticate(ctx context.Context, user *model.User, sessionID, password string) error {
+	if user.PasswordHash != "" {
+		if password == "" {
+			return ErrReauthenticationRequired
+		}
+
+		accountKey, _ := loginAttemptKeys(user.Email, ClientInfo{})
+		if err := r.loginThrottle.reserve(ctx, user, accountKey, ""); err != nil {
+			return err
+		}
+
+		// Hashes are only upgraded on login, which every user goes through regularly
+		ok, _, err := r.passwordHasher.Verify(password, user.PasswordHash)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrInvalidCredentials
+		}
+
+		return r.loginThrottle.release(ctx, accountKey, "")
+	}
+
+	session, err := r.sessionRepo.GetSession(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrReauthenticationRequired
+		}
+
+		return err
+	}
+
+	if session.UserID != user.ID.Hex() || time.Since(session.CreatedAt) > r.authServiceCfg.ReauthenticationMaxAge {
+		return ErrReauthenticationRequired
+	}
+
+	return nil
+}
+
+// reauthenticateWithSecondFactor is reauthenticate for requests that give lasting access to the
+// account. Users with TOTP enabled also have to enter a TOTP or recovery code, as a password or a
+// fresh session alone may be all whoever stole the password has.
+func (r *Reauthenticator) reauthenticateWithSecondFactor(
+	ctx context.Context,
+	user *model.User,
+	sessionID, password, code string,
+) error {
+	if err := r.reauthenticate(ctx, user, sessionID, password); err != nil {
+		return err
+	}
+
+	if !user.TOTPEnabled {
+		return nil
+	}
+	if code == "" {
+		return ErrReauthenticationRequired
+	}
+
+	return r.verifySecondFactor(ctx, user, code)
+}
+
+// verifySecondFactor checks a TOTP or recovery code of a signed in user against their attempt limit.
+func (r *Reauthenticator) verifySecondFactor(ctx context.Context, user *model.User, code string) error {
+	if err := r.loginThrottle.reserveSecondFactor(ctx, user.ID.Hex()); err != nil {
+		return err
+	}
+
+	if err := verifySecondFactor(ctx, r.userRepo, r.encryptor, user, code); err != nil {
+		return err
+	}
+
+	return r.loginThrottle.releaseSecondFactor(ctx, user.ID.Hex())
+}
@@ -0,0 +1,174 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/model"
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/repository"
+)
+
+// The fakes below keep their records in memory and follow the documented behaviour of the MongoDB
+// repositories. Each embeds its repository interface, so calling a method a test does not expect
+// panics instead of silently doing nothing.
+
+type fakeUserRepository struct {
+	repository.UserRepository
+
+	mu    sync.Mutex
+	users []*model.User
+}
+
+func (r *fakeUserRepository) GetUser(_ context.Context, id string) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, user := range r.users {
+		if user.ID.Hex() == id {
+			userCopy := *user
+			return &userCopy, nil
+		}
+	}
+
+	return nil, mongo.ErrNoDocuments
+}
+
+func (r *fakeUserRepository) GetUserByEmail(_ context.Context, email string) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, user := range r.users {
+		if strings.EqualFold(user.Email, email) {
+			userCopy := *user
+			return &userCopy, nil
+		}
+	}
+
+	return nil, mongo.ErrNoDocuments
+}
+
+type fakeLoginAttemptRepository struct {
+	mu       sync.Mutex
+	attempts map[string]*model.LoginAttempt
+}
+
+func newFakeLoginAttemptRepository() *fakeLoginAttemptRepository {
+	return &fakeLoginAttemptRepository{attempts: make(map[string]*model.LoginAttempt)}
+}
+
+func (r *fakeLoginAttemptRepository) ReserveAttempt(
+	_ context.Context,
+	key string,
+	expiresAt time.Time,
+) (*model.LoginAttempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	attempt, ok := r.attempts[key]
+	if !ok || !attempt.ExpiresAt.After(now) {
+		r.attempts[key] = &model.LoginAttempt{
+			Key:           key,
+			Failures:      1,
+			LastFailureAt: now,
+			ExpiresAt:     expiresAt,
+			CreatedAt:     now,
+		}
+		return nil, nil
+	}
+
+	before := *attempt
+	attempt.Failures++
+	attempt.LastFailureAt = now
+	if expiresAt.After(attempt.ExpiresAt) {
+		attempt.ExpiresAt = expiresAt
+	}
+
+	return &before, nil
+}
+
+func (r *fakeLoginAttemptRepository) ReleaseAttempt(_ context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if attempt, ok := r.attempts[key]; ok && attempt.Failures > 0 {
+		attempt.Failures--
+	}
+
+	return nil
+}
+
+func (r *fakeLoginAttemptRepository) LockUntil(_ context.Context, key string, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if attempt, ok := r.attempts[key]; ok {
+		attempt.LockedUntil = &until
+		if until.After(attempt.ExpiresAt) {
+			attempt.ExpiresAt = until
+		}
+	}
+
+	return nil
+}
+
+func (r *fakeLoginAttemptRepository) ClearExpiredLock(_ context.Context, key string, lockedUntil time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	attempt, ok := r.attempts[key]
+	if ok && attempt.LockedUntil != nil && attempt.LockedUntil.Equal(lockedUntil) && !lockedUntil.After(time.Now()) {
+		delete(r.attempts, key)
+	}
+
+	return nil
+}
+
+func (r *fakeLoginAttemptRepository) ResetLoginAttempts(_ context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.attempts, key)
+	return nil
+}
+
+type fakeMFAChallengeRepository struct {
+	repository.MFAChallengeRepository
+
+	mu         sync.Mutex
+	challenges []*model.MFAChallenge
+}
+
+func (r *fakeMFAChallengeRepository) CreateChallenge(
+	_ context.Context,
+	challenge *model.MFAChallenge,
+) (*model.MFAChallenge, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	challenge.ID = bson.NewObjectID()
+	challenge.CreatedAt = time.Now()
+	r.challenges = append(r.challenges, challenge)
+
+	return challenge, nil
+}
+
+type fakeAuthEventRepository struct {
+	repository.AuthEventRepository
+
+	mu     sync.Mutex
+	events []*model.AuthEvent
+}
+
+func (r *fakeAuthEventRepository) CreateAuthEvent(_ context.Context, event *model.AuthEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = append(r.events, event)
+	return nil
+}
@@ -0,0 +1,152 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/rs/zerolog"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/model"
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/repository"
+	authtypes "github.com/vasapolrittideah/money-tracker-api/services/auth-service/pkg/types"
+)
+
+// RoleUsecase defines the business logic for the roles and permissions of users. Roles and
+// permissions are carried in access tokens, which services verify without asking the auth service,
+// so revoked ones stay valid in the access tokens already issued until those expire.
+type RoleUsecase interface {
+	// SetUserRoles replaces the roles and directly granted permissions of a user. They take effect
+	// when the user's access tokens are next refreshed.
+	SetUserRoles(ctx context.Context, params SetUserRolesParams) (*model.User, error)
+
+	// BootstrapAdmins gives the admin role to the users with the given email addresses. Addresses
+	// without a user, or whose user has not verified them, are skipped, so nobody can become admin by
+	// registering an address they do not own.
+	BootstrapAdmins(ctx context.Context, emails []string) error
+}
+
+// SetUserRolesParams defines the parameters for changing the roles of a user. ActorID is the user
+// making the change.
+type SetUserRolesParams struct {
+	ActorID     string
+	UserID      string
+	Roles       []string
+	Permissions []string
+	Client      ClientInfo
+}
+
+var (
+	ErrUserNotFound      = errors.New("user not found")
+	ErrUnknownRole       = errors.New("unknown role")
+	ErrUnknownPermission = errors.New("unknown permission")
+)
+
+type roleUsecase struct {
+	logger     *zerolog.Logger
+	userRepo   repository.UserRepository
+	authEvents *AuthEventRecorder
+}
+
+// NewRoleUsecase creates a new instance of RoleUsecase.
+func NewRoleUsecase(
+	logger *zerolog.Logger,
+	userRepo repository.UserRepository,
+	authEvents *AuthEventRecorder,
+) RoleUsecase {
+	return &roleUsecase{
+		logger:     logger,
+		userRepo:   userRepo,
+		authEvents: authEvents,
+	}
+}
+
+func (u *roleUsecase) SetUserRoles(ctx context.Context, params SetUserRolesParams) (_ *model.User, err error) {
+	defer func() {
+		u.authEvents.Record(ctx, &model.AuthEvent{
+			Type:    model.AuthEventRolesUpdate,
+			UserID:  params.UserID,
+			ActorID: params.ActorID,
+		}, params.Client, err)
+	}()
+
+	for _, role := range params.Roles {
+		if _, ok := model.RolePermissions[role]; !ok {
+			return nil, ErrUnknownRole
+		}
+	}
+	for _, permission := range params.Permissions {
+		if !slices.Contains(authtypes.Permissions, permission) {
+			return nil, ErrUnknownPermission
+		}
+	}
+
+	if _, err := bson.ObjectIDFromHex(params.UserID); err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	roles := slices.Compact(slices.Sorted(slices.Values(params.Roles)))
+	permissions := slices.Compact(slices.Sorted(slices.Values(params.Permissions)))
+	user, err := u.userRepo.UpdateUser(ctx, params.UserID, repository.UpdateUserParams{
+		Roles:       &roles,
+		Permissions: &permissions,
+	})
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrUserNotFound
+		}
+
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (u *roleUsecase) BootstrapAdmins(ctx context.Context, emails []string) error {
+	for _, email := range emails {
+		user, err := u.userRepo.GetUserByEmail(ctx, email)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				u.logger.Warn().Str("email", email).Msg("admin email has no user, skipping")
+				continue
+			}
+
+			return err
+		}
+
+		if !user.Verified {
+			u.logger.Warn().Str("email", email).Msg("admin email has not been verified, skipping")
+			continue
+		}
+		if slices.Contains(user.Roles, model.RoleAdmin) {
+			continue
+		}
+
+		roles := slices.Sorted(slices.Values(append(slices.Clone(user.Roles), model.RoleAdmin)))
+		_, err = u.userRepo.UpdateUser(ctx, user.ID.Hex(), repository.UpdateUserParams{
+			Roles: &roles,
+		})
+		u.authEvents.Record(ctx, &model.AuthEvent{
+			Type:   model.AuthEventRolesUpdate,
+			UserID: user.ID.Hex(),
+		}, ClientInfo{}, err)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// userPermissions returns the permissions of a user, both the ones of their roles and the ones
+// granted to them directly.
+func userPermissions(user *model.User) []string {
+	permissions := slices.Clone(user.Permissions)
+	for _, role := range user.Roles {
+		permissions = append(permissions, model.RolePermissions[role]...)
+	}
+
+	return slices.Compact(slices.Sorted(slices.Values(permissions)))
+}
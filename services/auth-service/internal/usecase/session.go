@@ -0,0 +1,94 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/model"
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/repository"
+)
+
+// SessionUsecase defines the business logic for managing authentication sessions.
+type SessionUsecase interface {
+	// ValidateSession returns an error if the session does not exist or has been revoked.
+	ValidateSession(ctx context.Context, sessionID string) error
+
+	// ListSessions returns the active sessions of the user, most recently used first.
+	ListSessions(ctx context.Context, userID string) ([]*model.Session, error)
+
+	// RevokeSession revokes one of the user's sessions, signing out the device that holds it.
+	RevokeSession(ctx context.Context, userID, sessionID string) error
+
+	// Logout revokes the given session of the user.
+	Logout(ctx context.Context, userID, sessionID string) error
+
+	// LogoutAll revokes every session of the user.
+	LogoutAll(ctx context.Context, userID string) error
+}
+
+var (
+	ErrSessionNotFound = errors.New("session not found")
+	ErrSessionRevoked  = errors.New("session has been revoked")
+)
+
+type sessionUsecase struct {
+	sessionRepo repository.SessionRepository
+}
+
+// NewSessionUsecase creates a new instance of SessionUsecase.
+func NewSessionUsecase(sessionRepo repository.SessionRepository) SessionUsecase {
+	return &sessionUsecase{
+		sessionRepo: sessionRepo,
+	}
+}
+
+func (u *sessionUsecase) ValidateSession(ctx context.Context, sessionID string) error {
+	session, err := u.sessionRepo.GetSession(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrSessionNotFound
+		}
+		return err
+	}
+
+	if session.RevokedAt != nil {
+		return ErrSessionRevoked
+	}
+
+	return nil
+}
+
+func (u *sessionUsecase) ListSessions(ctx context.Context, userID string) ([]*model.Session, error) {
+	return u.sessionRepo.ListActiveSessionsByUserID(ctx, userID)
+}
+
+func (u *sessionUsecase) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	if _, err := bson.ObjectIDFromHex(sessionID); err != nil {
+		return ErrSessionNotFound
+	}
+
+	session, err := u.sessionRepo.GetSession(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrSessionNotFound
+		}
+		return err
+	}
+
+	if session.UserID != userID {
+		return ErrSessionNotFound
+	}
+
+	return u.sessionRepo.RevokeSession(ctx, sessionID)
+}
+
+func (u *sessionUsecase) Logout(ctx context.Context, userID, sessionID string) error {
+	return u.RevokeSession(ctx, userID, sessionID)
+}
+
+func (u *sessionUsecase) LogoutAll(ctx context.Context, userID string) error {
+	return u.sessionRepo.RevokeSessionsByUserID(ctx, userID)
+}
@@ -0,0 +1,341 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rs/zerolog"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/config"
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/model"
+	"github.com/vasapolrittideah/money-tracker-api/services/auth-service/internal/repository"
+	authtypes "github.com/vasapolrittideah/money-tracker-api/services/auth-service/pkg/types"
+	"github.com/vasapolrittideah/money-tracker-api/shared/auth"
+	"github.com/vasapolrittideah/money-tracker-api/shared/mailer"
+)
+
+// SessionIssuer creates the sessions and tokens of logins once the user has been authenticated. It
+// is shared by every usecase that logs users in or issues tokens, so they all ask for the second
+// factor, restore accounts scheduled for deletion and send login alerts the same way.
+type SessionIssuer struct {
+	logger           *zerolog.Logger
+	identityRepo     repository.IdentityRepository
+	sessionRepo      repository.SessionRepository
+	userRepo         repository.UserRepository
+	mfaChallengeRepo repository.MFAChallengeRepository
+	loginAlertRepo   repository.LoginAlertRepository
+	authEvents       *AuthEventRecorder
+	mailer           *mailer.Mailer
+	jwtAuth          auth.JWTAuthenticator
+	accessTokenKeys  *auth.KeySet
+	authServiceCfg   *config.AuthServiceConfig
+}
+
+// NewSessionIssuer creates a new SessionIssuer.
+func NewSessionIssuer(
+	logger *zerolog.Logger,
+	identityRepo repository.IdentityRepository,
+	sessionRepo repository.SessionRepository,
+	userRepo repository.UserRepository,
+	mfaChallengeRepo repository.MFAChallengeRepository,
+	loginAlertRepo repository.LoginAlertRepository,
+	authEvents *AuthEventRecorder,
+	mailer *mailer.Mailer,
+	jwtAuth auth.JWTAuthenticator,
+	accessTokenKeys *auth.KeySet,
+	authServiceCfg *config.AuthServiceConfig,
+) *SessionIssuer {
+	return &SessionIssuer{
+		logger:           logger,
+		identityRepo:     identityRepo,
+		sessionRepo:      sessionRepo,
+		userRepo:         userRepo,
+		mfaChallengeRepo: mfaChallengeRepo,
+		loginAlertRepo:   loginAlertRepo,
+		authEvents:       authEvents,
+		mailer:           mailer,
+		jwtAuth:          jwtAuth,
+		accessTokenKeys:  accessTokenKeys,
+		authServiceCfg:   authServiceCfg,
+	}
+}
+
+// sessionFromRefreshToken returns the session a refresh token was issued for and the user it belongs to.
+func (s *SessionIssuer) sessionFromRefreshToken(
+	ctx context.Context,
+	refreshToken string,
+) (*model.User, *model.Session, error) {
+	claims := &authtypes.JWTClaims{}
+	if _, err := s.jwtAuth.ValidateTokenWithClaims(
+		refreshToken,
+		s.authServiceCfg.Token.RefreshTokenSecret,
+		claims,
+	); err != nil {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	session, err := s.sessionRepo.GetSession(ctx, claims.SessionID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil, ErrInvalidRefreshToken
+		}
+
+		return nil, nil, err
+	}
+
+	if session.UserID != claims.UserID {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	user, err := s.userRepo.GetUser(ctx, session.UserID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil, ErrInvalidRefreshToken
+		}
+
+		return nil, nil, err
+	}
+
+	return user, session, nil
+}
+
+// rotateTokens exchanges the current refresh token of a session for a new token pair.
+func (s *SessionIssuer) rotateTokens(
+	ctx context.Context,
+	user *model.User,
+	session *model.Session,
+	refreshToken string,
+) (*authtypes.Tokens, error) {
+	if session.RevokedAt != nil {
+		return nil, ErrSessionRevoked
+	}
+
+	// Refresh tokens are rotated in place, so a session and every refresh token ever issued for it
+	// form one family. A correctly signed token that is no longer the current one has been presented
+	// before, which means it leaked; revoke the family so neither the attacker nor the victim can
+	// continue with it.
+	if session.RefreshToken != refreshToken {
+		if err := s.sessionRepo.RevokeSession(ctx, session.ID.Hex()); err != nil {
+			return nil, err
+		}
+
+		s.recordRefreshTokenReuse(ctx, session)
+		return nil, ErrRefreshTokenReused
+	}
+
+	tokens, params, err := s.generateTokens(user, session)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.sessionRepo.RotateTokens(ctx, session.ID.Hex(), refreshToken, params); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			// The same refresh token was rotated by a concurrent request.
+			if err := s.sessionRepo.RevokeSession(ctx, session.ID.Hex()); err != nil {
+				return nil, err
+			}
+
+			s.recordRefreshTokenReuse(ctx, session)
+			return nil, ErrRefreshTokenReused
+		}
+
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// completeLogin finishes a login whose first factor has been verified. It asks for a second factor
+// if the user has one set up, and otherwise creates the session.
+func (s *SessionIssuer) completeLogin(ctx context.Context, user *model.User, client ClientInfo) (*LoginResult, error) {
+	if user.TOTPEnabled {
+		mfaToken, err := s.createMFAChallenge(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+
+		return &LoginResult{MFAToken: mfaToken}, nil
+	}
+
+	if err := s.identityRepo.UpdateLastLogin(ctx, user.ID.Hex()); err != nil {
+		return nil, err
+	}
+
+	tokens, err := s.createAuthSession(ctx, user, client)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoginResult{Tokens: tokens}, nil
+}
+
+func (s *SessionIssuer) createAuthSession(
+	ctx context.Context,
+	user *model.User,
+	client ClientInfo,
+) (*authtypes.Tokens, error) {
+	// Logging in during the grace period of a deletion keeps the account
+	if user.DeletedAt != nil {
+		if _, err := s.userRepo.RestoreUser(ctx, user.ID.Hex()); err != nil {
+			return nil, err
+		}
+
+		s.authEvents.Record(ctx, &model.AuthEvent{
+			Type:   model.AuthEventAccountRestore,
+			UserID: user.ID.Hex(),
+		}, client, nil)
+	}
+
+	alert, err := s.shouldAlertOnLogin(ctx, user, client)
+	if err != nil {
+		return nil, err
+	}
+
+	session := &model.Session{UserID: user.ID.Hex()}
+	if client.IPAddress != "" {
+		session.IPAddress = &client.IPAddress
+	}
+	if client.UserAgent != "" {
+		session.UserAgent = &client.UserAgent
+	}
+	if client.DeviceID != "" {
+		session.DeviceIDHash = hashToken(client.DeviceID)
+	}
+
+	session, err = s.sessionRepo.CreateSession(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, params, err := s.generateTokens(user, session)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.sessionRepo.UpdateTokens(ctx, session.ID.Hex(), params); err != nil {
+		return nil, err
+	}
+
+	if alert {
+		s.alertOnLogin(ctx, user, session.ID.Hex(), client)
+	}
+
+	return tokens, nil
+}
+
+// generateTokens creates a new access and refresh token pair for the given session.
+func (s *SessionIssuer) generateTokens(
+	user *model.User,
+	session *model.Session,
+) (*authtypes.Tokens, repository.UpdateTokensParams, error) {
+	accessClaims, err := s.newTokenClaims(user, session, s.authServiceCfg.Token.AccessTokenExpiresIn)
+	if err != nil {
+		return nil, repository.UpdateTokensParams{}, err
+	}
+
+	// Access tokens are verified by other services, so they are signed with a private key whose
+	// public key is published. Refresh tokens are only ever verified by the auth service.
+	accessToken, err := s.jwtAuth.GenerateTokenWithKeySet(accessClaims, s.accessTokenKeys)
+	if err != nil {
+		return nil, repository.UpdateTokensParams{}, err
+	}
+
+	refreshClaims, err := s.newTokenClaims(user, session, s.authServiceCfg.Token.RefreshTokenExpiresIn)
+	if err != nil {
+		return nil, repository.UpdateTokensParams{}, err
+	}
+
+	refreshToken, err := s.jwtAuth.GenerateToken(refreshClaims, s.authServiceCfg.Token.RefreshTokenSecret)
+	if err != nil {
+		return nil, repository.UpdateTokensParams{}, err
+	}
+
+	now := time.Now()
+	params := repository.UpdateTokensParams{
+		AccessToken:           accessToken,
+		RefreshToken:          refreshToken,
+		AccessTokenExpiresAt:  now.Add(s.authServiceCfg.Token.AccessTokenExpiresIn),
+		RefreshTokenExpiresAt: now.Add(s.authServiceCfg.Token.RefreshTokenExpiresIn),
+	}
+
+	return &authtypes.Tokens{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	}, params, nil
+}
+
+func (s *SessionIssuer) newTokenClaims(
+	user *model.User,
+	session *model.Session,
+	expiresIn time.Duration,
+) (authtypes.JWTClaims, error) {
+	// A unique JTI keeps tokens issued within the same second distinguishable,
+	// which refresh token reuse detection relies on.
+	jti, err := generateJTI()
+	if err != nil {
+		return authtypes.JWTClaims{}, err
+	}
+
+	now := time.Now()
+	claims := authtypes.JWTClaims{
+		UserID:        user.ID.Hex(),
+		SessionID:     session.ID.Hex(),
+		EmailVerified: user.Verified,
+		Scope:         session.Scope,
+		ClientID:      session.ClientID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(expiresIn)),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    s.authServiceCfg.Token.Issuer,
+			Audience:  jwt.ClaimStrings{s.authServiceCfg.Token.Issuer},
+		},
+	}
+
+	// Third-party apps only get the scopes the user consented to, never the user's staff permissions
+	if session.ClientID == "" {
+		claims.Roles = user.Roles
+		claims.Permissions = userPermissions(user)
+	}
+
+	return claims, nil
+}
+
+// recordLogin adds a login attempt to the audit log. user is nil when the attempt could not be
+// attributed to an account, in which case the email address that was tried is recorded.
+func (s *SessionIssuer) recordLogin(
+	ctx context.Context,
+	method string,
+	email string,
+	user *model.User,
+	result *LoginResult,
+	client ClientInfo,
+	err error,
+) {
+	event := &model.AuthEvent{Type: model.AuthEventLogin, Method: method}
+	if user != nil {
+		event.UserID = user.ID.Hex()
+	} else {
+		event.Email = email
+	}
+
+	// The first factor was accepted, but the login is only complete once VerifyMFA succeeds
+	if err == nil && result != nil && result.Tokens == nil {
+		event.Outcome = model.AuthEventOutcomeMFARequired
+	}
+
+	s.authEvents.Record(ctx, event, client, err)
+}
+
+// recordRefreshTokenReuse adds the revocation of a session whose refresh token was presented twice
+// to the audit log. Either the user or an attacker presented a stolen token, so neither client is known.
+func (s *SessionIssuer) recordRefreshTokenReuse(ctx context.Context, session *model.Session) {
+	s.authEvents.Record(ctx, &model.AuthEvent{
+		Type:   model.AuthEventRefreshTokenReuse,
+		UserID: session.UserID,
+	}, ClientInfo{}, ErrRefreshTokenReused)
+}
@@ -3,17 +3,30 @@ package authclient
 import (
 	"google.golang.org/grpc"
 
+	"github.com/vasapolrittideah/money-tracker-api/shared/auth"
 	"github.com/vasapolrittideah/money-tracker-api/shared/discovery"
+	"github.com/vasapolrittideah/money-tracker-api/shared/interceptor"
 	authpbv1 "github.com/vasapolrittideah/money-tracker-api/shared/protos/auth/v1"
 )
 
 type AuthServiceClient struct {
 	Client authpbv1.AuthServiceClient
 	conn   *grpc.ClientConn
+
+	introspectionCache *introspectionCache
 }
 
-func NewAuthServiceClient(serviceName string, consulRegistry *discovery.ConsulRegistry) (*AuthServiceClient, error) {
-	conn, err := consulRegistry.Connect(serviceName)
+// NewAuthServiceClient connects to the auth service. Calls are authenticated with tokens from
+// serviceTokens, which identify the calling service to the auth service.
+func NewAuthServiceClient(
+	serviceName string,
+	consulRegistry *discovery.ConsulRegistry,
+	serviceTokens *auth.ServiceTokenIssuer,
+) (*AuthServiceClient, error) {
+	conn, err := consulRegistry.Connect(
+		serviceName,
+		grpc.WithPerRPCCredentials(interceptor.NewServiceCredentials(serviceTokens, serviceName)),
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -21,8 +34,9 @@ func NewAuthServiceClient(serviceName string, consulRegistry *discovery.ConsulRe
 	client := authpbv1.NewAuthServiceClient(conn)
 
 	return &AuthServiceClient{
-		Client: client,
-		conn:   conn,
+		Client:             client,
+		conn:               conn,
+		introspectionCache: newIntrospectionCache(),
 	}, nil
 }
 
@@ -0,0 +1,132 @@
+package authclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	authpbv1 "github.com/vasapolrittideah/money-tracker-api/shared/protos/auth/v1"
+)
+
+const (
+	// introspectionCacheTTL is how long an introspection result is reused. A revoked token can
+	// stay active for callers for up to this long.
+	introspectionCacheTTL = 30 * time.Second
+	// introspectionCacheSize is the most results kept per client.
+	introspectionCacheSize = 10_000
+)
+
+type introspectionCacheEntry struct {
+	response  *authpbv1.IntrospectResponse
+	expiresAt time.Time
+}
+
+// introspectionCache caches introspection results by the SHA-256 hash of the token, so the tokens
+// themselves are not kept in memory.
+type introspectionCache struct {
+	mu      sync.Mutex
+	entries map[[sha256.Size]byte]introspectionCacheEntry
+}
+
+func newIntrospectionCache() *introspectionCache {
+	return &introspectionCache{
+		entries: make(map[[sha256.Size]byte]introspectionCacheEntry),
+	}
+}
+
+func (c *introspectionCache) get(key [sha256.Size]byte, now time.Time) (*authpbv1.IntrospectResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || !now.Before(entry.expiresAt) {
+		return nil, false
+	}
+
+	return entry.response, true
+}
+
+func (c *introspectionCache) set(key [sha256.Size]byte, response *authpbv1.IntrospectResponse, now time.Time) {
+	expiresAt := now.Add(introspectionCacheTTL)
+	// An active token must not outlive its expiry in the cache
+	if response.GetActive() && response.GetExpiresAt() != nil && response.GetExpiresAt().AsTime().Before(expiresAt) {
+		expiresAt = response.GetExpiresAt().AsTime()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.entries) >= introspectionCacheSize {
+		c.evict(now)
+	}
+
+	c.entries[key] = introspectionCacheEntry{
+		response:  response,
+		expiresAt: expiresAt,
+	}
+}
+
+// evict removes the expired entries, and arbitrary live ones if that does not free any space.
+func (c *introspectionCache) evict(now time.Time) {
+	for key, entry := range c.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(c.entries, key)
+		}
+	}
+
+	for key := range c.entries {
+		if len(c.entries) < introspectionCacheSize {
+			return
+		}
+		delete(c.entries, key)
+	}
+}
+
+// Introspect reports whether an access token is still valid and who it belongs to. Results are
+// cached for a short time, so most requests do not reach the auth service; a revoked token may be
+// reported as active for up to introspectionCacheTTL after the revocation.
+func (c *AuthServiceClient) Introspect(ctx context.Context, token string) (*authpbv1.IntrospectResponse, error) {
+	key := sha256.Sum256([]byte(token))
+	if response, ok := c.introspectionCache.get(key, time.Now()); ok {
+		return response, nil
+	}
+
+	response, err := c.Client.Introspect(ctx, &authpbv1.IntrospectRequest{Token: token})
+	if err != nil {
+		return nil, err
+	}
+
+	c.introspectionCache.set(key, response, time.Now())
+
+	return response, nil
+}
+
+// ValidatePersonalAccessToken introspects a personal access token and returns the claims it stands
+// for, in the shape of access token claims but without a session_id. It lets services pass the
+// client to interceptor.NewJWTInterceptor to accept personal access tokens.
+func (c *AuthServiceClient) ValidatePersonalAccessToken(ctx context.Context, token string) (jwt.MapClaims, error) {
+	response, err := c.Introspect(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if !response.GetActive() {
+		return nil, errors.New("personal access token is not active")
+	}
+
+	claims := jwt.MapClaims{
+		"user_id":    response.GetUserId(),
+		"scope":      strings.Join(response.GetScopes(), " "),
+		"token_type": response.GetTokenType(),
+	}
+	if response.GetExpiresAt() != nil {
+		claims["exp"] = response.GetExpiresAt().AsTime().Unix()
+	}
+
+	return claims, nil
+}
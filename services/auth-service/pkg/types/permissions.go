@@ -0,0 +1,19 @@
+package authtypes
+
+// Permissions users can be granted, either directly or through their roles. Services require them
+// with interceptor.RequirePermission and the gateway with middleware.RequirePermission.
+const (
+	// PermissionUsersRead allows looking into the accounts of other users, such as their security events.
+	PermissionUsersRead = "users:read"
+	// PermissionUsersWrite allows changing the accounts of other users, such as their roles.
+	PermissionUsersWrite = "users:write"
+)
+
+// Permissions are all the permissions users can be granted.
+var Permissions = []string{
+	PermissionUsersRead,
+	PermissionUsersWrite,
+}
+
+// ScopeRead is the scope personal access tokens need to read the account of their user.
+const ScopeRead = "read"
@@ -10,6 +10,38 @@ type Tokens struct {
 type JWTClaims struct {
 	jwt.RegisteredClaims
 
-	UserID    string `json:"user_id"`
-	SessionID string `json:"session_id"`
+	UserID        string `json:"user_id"`
+	SessionID     string `json:"session_id"`
+	EmailVerified bool   `json:"email_verified"`
+	// Scope is the space separated list of scopes the token is limited to. Tokens the user got by
+	// logging in have no scope and are not limited.
+	Scope string `json:"scope,omitempty"`
+	// ClientID is the third-party app the token was issued to, if any.
+	ClientID string `json:"client_id,omitempty"`
+	// Roles are the roles of the user, and Permissions everything they grant along with the permissions
+	// given to the user directly. Tokens of third-party apps carry neither.
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+}
+
+type PasswordResetClaims struct {
+	jwt.RegisteredClaims
+
+	UserID string `json:"user_id"`
+	Email  string `json:"email"`
+	JTI    string `json:"jti"`
+}
+
+type MagicLinkClaims struct {
+	jwt.RegisteredClaims
+
+	UserID string `json:"user_id"`
+	Email  string `json:"email"`
+	JTI    string `json:"jti"`
+}
+
+type MFAChallengeClaims struct {
+	jwt.RegisteredClaims
+
+	UserID string `json:"user_id"`
 }
@@ -54,17 +54,35 @@ func (a *JWTAuthenticator) ValidateToken(token, secret string) (*jwt.Token, erro
 // ValidateTokenWithClaims validates a JWT token and parses it into the provided claims type.
 // The claims parameter should be a pointer to a struct that implements jwt.Claims.
 func (a *JWTAuthenticator) ValidateTokenWithClaims(tokenString, secret string, claims jwt.Claims) (*jwt.Token, error) {
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (any, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
-		}
+	return a.ValidateTokenWithKeys(tokenString, Secret(secret), claims)
+}
 
-		return []byte(secret), nil
-	},
+// GenerateTokenWithKeySet generates a JWT token with the given claims, signed by the signing key
+// of keySet. The key ID is set as the kid header.
+func (a *JWTAuthenticator) GenerateTokenWithKeySet(claims jwt.Claims, keySet *KeySet) (string, error) {
+	signingKey := keySet.SigningKey()
+	if signingKey == nil {
+		return "", errors.New("key set has no signing key")
+	}
+
+	token := jwt.NewWithClaims(signingKey.Method, claims)
+	token.Header["kid"] = signingKey.ID
+
+	return token.SignedString(signingKey.PrivateKey)
+}
+
+// ValidateTokenWithKeys validates a JWT token signed with one of keys and parses it into the
+// provided claims type.
+func (a *JWTAuthenticator) ValidateTokenWithKeys(
+	tokenString string,
+	keys VerificationKeys,
+	claims jwt.Claims,
+) (*jwt.Token, error) {
+	token, err := jwt.ParseWithClaims(tokenString, claims, keys.VerificationKey,
 		jwt.WithExpirationRequired(),
 		jwt.WithAudience(a.audience),
 		jwt.WithIssuer(a.issuer),
-		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Name}),
+		jwt.WithValidMethods(keys.Algorithms()),
 	)
 	if err != nil {
 		return nil, err
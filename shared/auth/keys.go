@@ -0,0 +1,442 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrUnknownKeyID       = errors.New("unknown key id")
+	ErrUnsupportedKeyType = errors.New("unsupported key type")
+)
+
+// minRSAKeyBits is the smallest RSA modulus accepted for signing or verifying tokens.
+const minRSAKeyBits = 2048
+
+// VerificationKeys provides the keys tokens are verified with.
+type VerificationKeys interface {
+	// VerificationKey returns the key token has to be verified with, based on its header.
+	VerificationKey(token *jwt.Token) (any, error)
+
+	// Algorithms returns the signing algorithms tokens may be signed with.
+	Algorithms() []string
+}
+
+// Secret is a shared secret tokens are signed and verified with using HS256.
+type Secret string
+
+// VerificationKey returns the secret for every token.
+func (s Secret) VerificationKey(token *jwt.Token) (any, error) {
+	return []byte(s), nil
+}
+
+// Algorithms returns HS256.
+func (s Secret) Algorithms() []string {
+	return []string{jwt.SigningMethodHS256.Alg()}
+}
+
+// SigningKey is a private key tokens are signed with. ID is sent as the kid header, so verifiers
+// can pick the matching public key.
+type SigningKey struct {
+	ID         string
+	Method     jwt.SigningMethod
+	PrivateKey crypto.Signer
+}
+
+// VerificationKey is a public key tokens are verified with.
+type VerificationKey struct {
+	ID        string
+	Method    jwt.SigningMethod
+	PublicKey crypto.PublicKey
+}
+
+// KeySet holds the key new tokens are signed with and every public key tokens are accepted from.
+// During a rotation it holds the keys before and after the rotation, so tokens signed with either
+// are valid until they expire.
+type KeySet struct {
+	signingKey       *SigningKey
+	verificationKeys map[string]VerificationKey
+}
+
+// NewKeySet creates a key set. The public part of signingKey is always accepted; signingKey may be
+// nil for a set that only verifies tokens.
+func NewKeySet(signingKey *SigningKey, verificationKeys ...VerificationKey) *KeySet {
+	keySet := &KeySet{
+		signingKey:       signingKey,
+		verificationKeys: make(map[string]VerificationKey, len(verificationKeys)+1),
+	}
+
+	for _, key := range verificationKeys {
+		keySet.verificationKeys[key.ID] = key
+	}
+
+	if signingKey != nil {
+		keySet.verificationKeys[signingKey.ID] = VerificationKey{
+			ID:        signingKey.ID,
+			Method:    signingKey.Method,
+			PublicKey: signingKey.PrivateKey.Public(),
+		}
+	}
+
+	return keySet
+}
+
+// SigningKey returns the key new tokens are signed with, or nil for a set that only verifies tokens.
+func (s *KeySet) SigningKey() *SigningKey {
+	return s.signingKey
+}
+
+// VerificationKey returns the public key named by the kid header of token. Tokens without a kid
+// header are verified with the only key of a set that holds a single one, as some identity
+// providers publish a single key without an ID.
+func (s *KeySet) VerificationKey(token *jwt.Token) (any, error) {
+	keyID, _ := token.Header["kid"].(string)
+	key, ok := s.verificationKeys[keyID]
+	if !ok && keyID == "" && len(s.verificationKeys) == 1 {
+		for _, onlyKey := range s.verificationKeys {
+			key, ok = onlyKey, true
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKeyID, keyID)
+	}
+
+	// A key is only ever used with its own algorithm
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Method.Alg())
+	}
+
+	return key.PublicKey, nil
+}
+
+// Algorithms returns the algorithms of the asymmetric keys a KeySet supports. Every key is only
+// used with its own algorithm, so a token cannot pick another one.
+func (s *KeySet) Algorithms() []string {
+	return supportedAlgorithms
+}
+
+// supportedAlgorithms are the algorithms of the asymmetric keys a KeySet supports.
+var supportedAlgorithms = []string{
+	jwt.SigningMethodRS256.Alg(),
+	jwt.SigningMethodRS384.Alg(),
+	jwt.SigningMethodRS512.Alg(),
+	jwt.SigningMethodES256.Alg(),
+	jwt.SigningMethodES384.Alg(),
+	jwt.SigningMethodES512.Alg(),
+	jwt.SigningMethodEdDSA.Alg(),
+}
+
+// JSONWebKeySet returns the public keys of the set, ordered by key ID.
+func (s *KeySet) JSONWebKeySet() JSONWebKeySet {
+	keys := make([]JSONWebKey, 0, len(s.verificationKeys))
+	for _, key := range s.verificationKeys {
+		// Every key in the set was validated when it was parsed
+		jwk, _ := NewJSONWebKey(key)
+		keys = append(keys, jwk)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].KeyID < keys[j].KeyID
+	})
+
+	return JSONWebKeySet{Keys: keys}
+}
+
+// ParseSigningKeyPEM parses a PEM encoded PKCS #8 RSA, ECDSA or Ed25519 private key, or a PKCS #1
+// RSA private key. RSA keys sign with RS256, ECDSA keys with the ES algorithm of their curve and
+// Ed25519 keys with EdDSA. The key ID is the RFC 7638
+// thumbprint of the public key, so it changes whenever the key does.
+func ParseSigningKeyPEM(data []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found in signing key")
+	}
+
+	var privateKey any
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		privateKey, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		privateKey, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unexpected PEM block type %q in signing key", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := privateKey.(crypto.Signer)
+	if !ok {
+		return nil, ErrUnsupportedKeyType
+	}
+
+	verificationKey, err := newVerificationKey(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	return &SigningKey{
+		ID:         verificationKey.ID,
+		Method:     verificationKey.Method,
+		PrivateKey: signer,
+	}, nil
+}
+
+// ParseVerificationKeyPEM parses a PEM encoded PKIX RSA, ECDSA or Ed25519 public key.
+func ParseVerificationKeyPEM(data []byte) (VerificationKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return VerificationKey{}, errors.New("no PEM encoded public key found")
+	}
+
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return VerificationKey{}, err
+	}
+
+	return newVerificationKey(publicKey)
+}
+
+func newVerificationKey(publicKey crypto.PublicKey) (VerificationKey, error) {
+	var method jwt.SigningMethod
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		if key.N.BitLen() < minRSAKeyBits {
+			return VerificationKey{}, fmt.Errorf("RSA keys must be at least %d bits long", minRSAKeyBits)
+		}
+		method = jwt.SigningMethodRS256
+	case *ecdsa.PublicKey:
+		switch key.Curve {
+		case elliptic.P256():
+			method = jwt.SigningMethodES256
+		case elliptic.P384():
+			method = jwt.SigningMethodES384
+		case elliptic.P521():
+			method = jwt.SigningMethodES512
+		default:
+			return VerificationKey{}, ErrUnsupportedKeyType
+		}
+	case ed25519.PublicKey:
+		method = jwt.SigningMethodEdDSA
+	default:
+		return VerificationKey{}, ErrUnsupportedKeyType
+	}
+
+	verificationKey := VerificationKey{
+		Method:    method,
+		PublicKey: publicKey,
+	}
+
+	jwk, err := NewJSONWebKey(verificationKey)
+	if err != nil {
+		return VerificationKey{}, err
+	}
+	verificationKey.ID = jwk.Thumbprint()
+
+	return verificationKey, nil
+}
+
+// JSONWebKeySet is a JSON Web Key Set as defined in RFC 7517.
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// JSONWebKey is the JSON Web Key representation of an RSA, ECDSA or Ed25519 public key.
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid,omitempty"`
+	Use       string `json:"use,omitempty"`
+	Algorithm string `json:"alg,omitempty"`
+	// N and E are the modulus and exponent of an RSA key.
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Curve and X are the curve and public key of an OKP key, and with Y the curve and point of an EC key.
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+	Y     string `json:"y,omitempty"`
+}
+
+// NewJSONWebKey returns the JSON Web Key of a verification key.
+func NewJSONWebKey(key VerificationKey) (JSONWebKey, error) {
+	jwk := JSONWebKey{
+		KeyID:     key.ID,
+		Use:       "sig",
+		Algorithm: key.Method.Alg(),
+	}
+
+	switch publicKey := key.PublicKey.(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+	case *ecdsa.PublicKey:
+		byteLen := (publicKey.Curve.Params().BitSize + 7) / 8
+		jwk.KeyType = "EC"
+		jwk.Curve = publicKey.Curve.Params().Name
+		jwk.X = base64.RawURLEncoding.EncodeToString(publicKey.X.FillBytes(make([]byte, byteLen)))
+		jwk.Y = base64.RawURLEncoding.EncodeToString(publicKey.Y.FillBytes(make([]byte, byteLen)))
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(publicKey)
+	default:
+		return JSONWebKey{}, ErrUnsupportedKeyType
+	}
+
+	return jwk, nil
+}
+
+// Thumbprint returns the RFC 7638 SHA-256 thumbprint of the key.
+func (k JSONWebKey) Thumbprint() string {
+	// The required members in lexicographic order, without whitespace
+	var members string
+	switch k.KeyType {
+	case "RSA":
+		members = fmt.Sprintf(`{"e":%q,"kty":"RSA","n":%q}`, k.E, k.N)
+	case "EC":
+		members = fmt.Sprintf(`{"crv":%q,"kty":"EC","x":%q,"y":%q}`, k.Curve, k.X, k.Y)
+	case "OKP":
+		members = fmt.Sprintf(`{"crv":%q,"kty":"OKP","x":%q}`, k.Curve, k.X)
+	}
+
+	sum := sha256.Sum256([]byte(members))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// VerificationKey parses the key into a verification key. RSA keys are used with the RS algorithm
+// the key names, RS256 if it names none.
+func (k JSONWebKey) VerificationKey() (VerificationKey, error) {
+	var publicKey crypto.PublicKey
+	switch {
+	case k.KeyType == "RSA" && (k.Algorithm == "" || strings.HasPrefix(k.Algorithm, "RS")):
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return VerificationKey{}, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return VerificationKey{}, err
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+			return VerificationKey{}, errors.New("invalid RSA exponent")
+		}
+
+		publicKey = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}
+	case k.KeyType == "EC":
+		ecKey, err := k.ecdsaPublicKey()
+		if err != nil {
+			return VerificationKey{}, err
+		}
+
+		publicKey = ecKey
+	case k.KeyType == "OKP" && k.Curve == "Ed25519" &&
+		(k.Algorithm == "" || k.Algorithm == jwt.SigningMethodEdDSA.Alg()):
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return VerificationKey{}, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return VerificationKey{}, errors.New("invalid Ed25519 public key")
+		}
+
+		publicKey = ed25519.PublicKey(x)
+	default:
+		return VerificationKey{}, ErrUnsupportedKeyType
+	}
+
+	verificationKey, err := newVerificationKey(publicKey)
+	if err != nil {
+		return VerificationKey{}, err
+	}
+
+	if k.Algorithm != "" {
+		method := jwt.GetSigningMethod(k.Algorithm)
+		if method == nil || !slices.Contains(supportedAlgorithms, k.Algorithm) ||
+			k.KeyType != "RSA" && method != verificationKey.Method {
+			return VerificationKey{}, fmt.Errorf("unsupported algorithm %q for %s key", k.Algorithm, k.KeyType)
+		}
+		verificationKey.Method = method
+	}
+
+	// Keep the ID the publisher chose, which tokens refer to
+	if k.KeyID != "" {
+		verificationKey.ID = k.KeyID
+	}
+
+	return verificationKey, nil
+}
+
+func (k JSONWebKey) ecdsaPublicKey() (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	var ecdhCurve ecdh.Curve
+	switch k.Curve {
+	case "P-256":
+		curve, ecdhCurve = elliptic.P256(), ecdh.P256()
+	case "P-384":
+		curve, ecdhCurve = elliptic.P384(), ecdh.P384()
+	case "P-521":
+		curve, ecdhCurve = elliptic.P521(), ecdh.P521()
+	default:
+		return nil, ErrUnsupportedKeyType
+	}
+
+	x, err := base64.RawURLEncoding.DecodeString(k.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := base64.RawURLEncoding.DecodeString(k.Y)
+	if err != nil {
+		return nil, err
+	}
+
+	byteLen := (curve.Params().BitSize + 7) / 8
+	if len(x) != byteLen || len(y) != byteLen {
+		return nil, errors.New("invalid EC point")
+	}
+
+	// crypto/ecdh rejects points that are not on the curve
+	point := append([]byte{4}, append(x, y...)...)
+	if _, err := ecdhCurve.NewPublicKey(point); err != nil {
+		return nil, err
+	}
+
+	return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+}
+
+// NewKeySetFromJSONWebKeySet creates a key set that verifies tokens with the signature keys of a
+// JSON Web Key Set. Keys of other types or uses are skipped.
+func NewKeySetFromJSONWebKeySet(jwks JSONWebKeySet) *KeySet {
+	verificationKeys := make([]VerificationKey, 0, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.VerificationKey()
+		if err != nil {
+			continue
+		}
+
+		verificationKeys = append(verificationKeys, key)
+	}
+
+	return NewKeySet(nil, verificationKeys...)
+}
@@ -0,0 +1,179 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// remoteKeySetMaxAge is how long fetched keys are used before they are fetched again.
+	remoteKeySetMaxAge = time.Hour
+	// defaultRemoteKeySetMinRefreshInterval limits how often the keys are fetched, so tokens with
+	// made up key IDs cannot be used to flood the publisher with requests.
+	defaultRemoteKeySetMinRefreshInterval = 30 * time.Second
+	// remoteKeySetTimeout bounds a fetch made with the default HTTP client.
+	remoteKeySetTimeout = 10 * time.Second
+)
+
+// RemoteKeySet verifies tokens with the public keys published at a JWKS URL, such as the
+// /.well-known/jwks.json route of the API gateway or the jwks_uri of an OpenID Connect provider.
+// Keys are cached and fetched again when a token names an unknown key, so rotated keys are picked
+// up without holding any secret.
+type RemoteKeySet struct {
+	url                string
+	client             *http.Client
+	minRefreshInterval time.Duration
+
+	mu          sync.Mutex
+	keySet      *KeySet
+	fetchedAt   time.Time
+	attemptedAt time.Time
+	// fetching is closed when the fetch in progress completes, nil when there is none.
+	fetching chan struct{}
+	fetchErr error
+}
+
+// NewRemoteKeySet creates a RemoteKeySet for the JWKS at url. Keys are fetched on first use. A nil
+// httpClient uses a client that gives up after ten seconds, and a minRefreshInterval of zero
+// allows a fetch every thirty seconds.
+func NewRemoteKeySet(url string, httpClient *http.Client, minRefreshInterval time.Duration) *RemoteKeySet {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: remoteKeySetTimeout}
+	}
+	if minRefreshInterval <= 0 {
+		minRefreshInterval = defaultRemoteKeySetMinRefreshInterval
+	}
+
+	return &RemoteKeySet{
+		url:                url,
+		client:             httpClient,
+		minRefreshInterval: minRefreshInterval,
+	}
+}
+
+// VerificationKey returns the public key named by the kid header of token.
+func (s *RemoteKeySet) VerificationKey(token *jwt.Token) (any, error) {
+	return s.VerificationKeyContext(context.Background(), token)
+}
+
+// VerificationKeyContext is VerificationKey with a context that bounds fetching the keys.
+func (s *RemoteKeySet) VerificationKeyContext(ctx context.Context, token *jwt.Token) (any, error) {
+	s.mu.Lock()
+	stale := s.keySet == nil || time.Since(s.fetchedAt) > remoteKeySetMaxAge
+	s.mu.Unlock()
+
+	// Outdated keys are better than none while the JWKS cannot be reached, so a failed refresh
+	// only matters when no keys have been fetched yet
+	var refreshErr error
+	if stale {
+		refreshErr = s.refresh(ctx)
+	}
+
+	s.mu.Lock()
+	keySet := s.keySet
+	s.mu.Unlock()
+	if keySet == nil {
+		if refreshErr != nil {
+			return nil, refreshErr
+		}
+
+		return nil, errors.New("JSON web key set is not available")
+	}
+
+	key, err := keySet.VerificationKey(token)
+	if !errors.Is(err, ErrUnknownKeyID) || stale {
+		return key, err
+	}
+
+	if err := s.refresh(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	keySet = s.keySet
+	s.mu.Unlock()
+
+	return keySet.VerificationKey(token)
+}
+
+// Algorithms returns the algorithms of the asymmetric keys a KeySet supports.
+func (s *RemoteKeySet) Algorithms() []string {
+	return supportedAlgorithms
+}
+
+// refresh fetches the keys unless they were fetched within the minimum refresh interval. The fetch
+// runs without holding the lock, and callers arriving during a fetch wait for its result instead of
+// starting another one.
+func (s *RemoteKeySet) refresh(ctx context.Context) error {
+	s.mu.Lock()
+	fetching := s.fetching
+	if fetching == nil {
+		if time.Since(s.attemptedAt) < s.minRefreshInterval {
+			s.mu.Unlock()
+			return nil
+		}
+
+		fetching = make(chan struct{})
+		s.fetching = fetching
+		s.attemptedAt = time.Now()
+		s.mu.Unlock()
+
+		keySet, err := s.fetch(ctx)
+
+		s.mu.Lock()
+		if err == nil {
+			s.keySet = keySet
+			s.fetchedAt = time.Now()
+		}
+		s.fetchErr = err
+		s.fetching = nil
+		s.mu.Unlock()
+		close(fetching)
+
+		return err
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-fetching:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.fetchErr
+}
+
+func (s *RemoteKeySet) fetch(ctx context.Context) (*KeySet, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JSON web key set: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JSON web key set: unexpected status %d", resp.StatusCode)
+	}
+
+	var jwks JSONWebKeySet
+	if err := json.NewDecoder(resp.Body).Decode(&jwks); err != nil {
+		return nil, fmt.Errorf("failed to decode JSON web key set: %w", err)
+	}
+
+	return NewKeySetFromJSONWebKeySet(jwks), nil
+}
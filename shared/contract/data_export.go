@@ -0,0 +1,21 @@
+package contract
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// DataExportFormatVersion is the version of the DataExport format. It only changes when existing
+// fields change; new sections are added without a new version.
+const DataExportFormatVersion = 1
+
+// DataExport is the archive of the personal data stored about a user, as handed to the user.
+// Every service contributes its data as one or more sections, named "<service>.<section>" so the
+// names stay unique, such as "auth.profile". Sections are kept as raw JSON, so an archive can be
+// assembled from sections produced by other services without knowing their types.
+type DataExport struct {
+	FormatVersion int                        `json:"format_version"`
+	UserID        string                     `json:"user_id"`
+	GeneratedAt   time.Time                  `json:"generated_at"`
+	Sections      map[string]json.RawMessage `json:"sections"`
+}
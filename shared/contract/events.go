@@ -0,0 +1,14 @@
+package contract
+
+import "time"
+
+// EventTypeAccountDeleted is emitted by the auth service once an account has been purged. Services
+// that store data about users must erase the data of the user when they receive it.
+const EventTypeAccountDeleted = "auth.account.deleted"
+
+// AccountDeletedEvent is the payload of an EventTypeAccountDeleted event.
+type AccountDeletedEvent struct {
+	UserID    string    `json:"user_id"    bson:"user_id"`
+	DeletedAt time.Time `json:"deleted_at" bson:"deleted_at"`
+	PurgedAt  time.Time `json:"purged_at"  bson:"purged_at"`
+}
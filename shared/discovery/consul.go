@@ -96,16 +96,21 @@ func (r *ConsulRegistry) Deregister(instanceID, serviceName string) error {
 	return nil
 }
 
-// Connect establishes a gRPC connection to a service via Consul.
-func (r *ConsulRegistry) Connect(serviceName string) (*grpc.ClientConn, error) {
-	conn, err := grpc.NewClient(
-		fmt.Sprintf("consul://%s/%s?tag=grpc&healthy=true", r.config.Address, serviceName),
+// Connect establishes a gRPC connection to a service via Consul. opts are added to the default
+// options, e.g. to authenticate the calls with grpc.WithPerRPCCredentials.
+func (r *ConsulRegistry) Connect(serviceName string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	dialOpts := []grpc.DialOption{
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
 		grpc.WithDefaultServiceConfig(`
 			{
 				"loadBalancingPolicy": "round_robin"
 			}
 		`),
+	}
+
+	conn, err := grpc.NewClient(
+		fmt.Sprintf("consul://%s/%s?tag=grpc&healthy=true", r.config.Address, serviceName),
+		append(dialOpts, opts...)...,
 	)
 	if err != nil {
 		return nil, err
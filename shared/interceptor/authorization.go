@@ -0,0 +1,72 @@
+package interceptor
+
+import (
+	"context"
+	"slices"
+
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// AuthorizationRule decides whether a caller with the given permissions may make a call.
+type AuthorizationRule func(permissions []string) bool
+
+// RequirePermission returns a rule that only lets callers with permission through.
+func RequirePermission(permission string) AuthorizationRule {
+	return func(permissions []string) bool {
+		return slices.Contains(permissions, permission)
+	}
+}
+
+// MethodAuthorizationRules maps full gRPC method names to the rule callers have to satisfy.
+type MethodAuthorizationRules map[string]AuthorizationRule
+
+// NewAuthorizationInterceptor creates an interceptor that enforces the rule of every method in
+// rules and passes calls of other methods through. It reads the permissions claim of the token, so
+// it has to be chained after NewJWTInterceptor.
+func NewAuthorizationInterceptor(rules MethodAuthorizationRules) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		rule, ok := rules[info.FullMethod]
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		if !rule(PermissionsFromContext(ctx)) {
+			return nil, status.Error(codes.PermissionDenied, "missing permission")
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// PermissionsFromContext returns the permissions of the caller, as set in the permissions claim of
+// their access token. Personal access tokens and tokens of third-party apps have none.
+func PermissionsFromContext(ctx context.Context) []string {
+	claims, ok := ctx.Value(UserClaimsKey).(jwt.MapClaims)
+	if !ok {
+		return nil
+	}
+
+	values, _ := claims["permissions"].([]any)
+	permissions := make([]string, 0, len(values))
+	for _, value := range values {
+		if permission, ok := value.(string); ok {
+			permissions = append(permissions, permission)
+		}
+	}
+
+	return permissions
+}
+
+// HasPermission reports whether the caller has permission, for checks that depend on the request
+// rather than only on the method.
+func HasPermission(ctx context.Context, permission string) bool {
+	return slices.Contains(PermissionsFromContext(ctx), permission)
+}
@@ -3,7 +3,6 @@ package interceptor
 import (
 	"context"
 	"errors"
-	"fmt"
 	"strings"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -19,64 +18,136 @@ type contextKey struct{}
 
 var UserClaimsKey = contextKey{}
 
+// SessionValidator checks whether the session a token was issued for is still active.
+// It lets services reject tokens of revoked sessions before the tokens expire.
+type SessionValidator interface {
+	ValidateSession(ctx context.Context, sessionID string) error
+}
+
+// PersonalAccessTokenPrefix starts every personal access token, which tells them apart from JWTs.
+const PersonalAccessTokenPrefix = "pat_"
+
+// PersonalAccessTokenValidator checks a personal access token and returns the claims it stands for,
+// such as user_id and scope. Personal access tokens are opaque, so only the auth service can tell
+// whether one is valid.
+type PersonalAccessTokenValidator interface {
+	ValidatePersonalAccessToken(ctx context.Context, token string) (jwt.MapClaims, error)
+}
+
+// NewJWTInterceptor creates an interceptor that authenticates every method except the exempt ones.
+// Services that do not issue tokens verify them with an auth.RemoteKeySet, which needs no secret.
+// When sessionValidator is not nil, tokens carrying a session_id claim are also checked against it.
+// When patValidator is not nil, personal access tokens are accepted alongside JWTs; their claims
+// have no session_id. Chain NewPersonalAccessTokenInterceptor after it to limit what they may call.
 func NewJWTInterceptor(
 	jwtAuth auth.JWTAuthenticator,
-	secret string,
+	keys auth.VerificationKeys,
 	exemptMethods []string,
+	sessionValidator SessionValidator,
+	patValidator PersonalAccessTokenValidator,
 ) grpc.UnaryServerInterceptor {
 	exemptMap := make(map[string]bool)
 	for _, method := range exemptMethods {
 		exemptMap[method] = true
 	}
 
+	return newJWTInterceptor(jwtAuth, keys, func(method string) bool {
+		return !exemptMap[method]
+	}, sessionValidator, patValidator)
+}
+
+// NewMethodJWTInterceptor creates an interceptor that authenticates only the given methods and
+// passes every other call through. It is meant to be chained with NewJWTInterceptor for methods
+// whose tokens are signed with different keys, such as password reset tokens.
+func NewMethodJWTInterceptor(
+	jwtAuth auth.JWTAuthenticator,
+	keys auth.VerificationKeys,
+	methods []string,
+) grpc.UnaryServerInterceptor {
+	methodMap := make(map[string]bool)
+	for _, method := range methods {
+		methodMap[method] = true
+	}
+
+	return newJWTInterceptor(jwtAuth, keys, func(method string) bool {
+		return methodMap[method]
+	}, nil, nil)
+}
+
+func newJWTInterceptor(
+	jwtAuth auth.JWTAuthenticator,
+	keys auth.VerificationKeys,
+	requiresAuth func(method string) bool,
+	sessionValidator SessionValidator,
+	patValidator PersonalAccessTokenValidator,
+) grpc.UnaryServerInterceptor {
 	return func(
 		ctx context.Context,
 		req any,
 		info *grpc.UnaryServerInfo,
 		handler grpc.UnaryHandler,
 	) (any, error) {
-		// Skip authentication for exempt methods
-		if exemptMap[info.FullMethod] {
+		// Skip authentication for methods this interceptor is not responsible for
+		if !requiresAuth(info.FullMethod) {
 			return handler(ctx, req)
 		}
 
-		claims, err := extractAndValidateJWT(ctx, jwtAuth, secret)
+		tokenString, err := extractBearerToken(ctx)
 		if err != nil {
 			return nil, status.Error(codes.Unauthenticated, err.Error())
 		}
 
+		if strings.HasPrefix(tokenString, PersonalAccessTokenPrefix) {
+			if patValidator == nil {
+				return nil, status.Error(codes.Unauthenticated, "personal access tokens are not accepted")
+			}
+
+			claims, err := patValidator.ValidatePersonalAccessToken(ctx, tokenString)
+			if err != nil {
+				return nil, status.Error(codes.Unauthenticated, "invalid personal access token")
+			}
+
+			ctx = context.WithValue(ctx, UserClaimsKey, claims)
+			ctx = context.WithValue(ctx, personalAccessTokenContextKey{}, true)
+
+			return handler(ctx, req)
+		}
+
+		claims := jwt.MapClaims{}
+		if _, err := jwtAuth.ValidateTokenWithKeys(tokenString, keys, claims); err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+
+		if sessionValidator != nil {
+			if sessionID, ok := claims["session_id"].(string); ok && sessionID != "" {
+				if err := sessionValidator.ValidateSession(ctx, sessionID); err != nil {
+					return nil, status.Error(codes.Unauthenticated, "session is no longer active")
+				}
+			}
+		}
+
 		ctx = context.WithValue(ctx, UserClaimsKey, claims)
 
 		return handler(ctx, req)
 	}
 }
 
-func extractAndValidateJWT(ctx context.Context, jwtAuth auth.JWTAuthenticator, secret string) (jwt.MapClaims, error) {
+func extractBearerToken(ctx context.Context) (string, error) {
 	md, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
-		return nil, errors.New("missing metadata")
+		return "", errors.New("missing metadata")
 	}
 
-	fmt.Println(md)
-
 	authHeaders := md.Get("Authorization")
 	if len(authHeaders) == 0 {
-		return nil, errors.New("missing authorization header")
+		return "", errors.New("missing authorization header")
 	}
 
 	authHeader := authHeaders[0]
 	parts := strings.SplitN(authHeader, " ", 2)
 	if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-		return nil, errors.New("invalid authorization header format")
-	}
-
-	tokenString := parts[1]
-
-	claims := jwt.MapClaims{}
-	_, err := jwtAuth.ValidateTokenWithClaims(tokenString, secret, claims)
-	if err != nil {
-		return nil, err
+		return "", errors.New("invalid authorization header format")
 	}
 
-	return claims, nil
+	return parts[1], nil
 }
@@ -0,0 +1,54 @@
+package interceptor
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type personalAccessTokenContextKey struct{}
+
+// IsPersonalAccessToken reports whether the call was authenticated with a personal access token
+// rather than a JWT.
+func IsPersonalAccessToken(ctx context.Context) bool {
+	isPersonalAccessToken, _ := ctx.Value(personalAccessTokenContextKey{}).(bool)
+	return isPersonalAccessToken
+}
+
+// PersonalAccessTokenScopes maps the full gRPC method names personal access tokens may call to the
+// scope a token has to be granted to call them.
+type PersonalAccessTokenScopes map[string]string
+
+// NewPersonalAccessTokenInterceptor creates an interceptor that only lets calls made with a personal
+// access token through for the methods in scopes, and only when the token was granted the scope of
+// the method. Calls made with JWTs are passed through. It has to be chained after NewJWTInterceptor.
+func NewPersonalAccessTokenInterceptor(scopes PersonalAccessTokenScopes) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		if !IsPersonalAccessToken(ctx) {
+			return handler(ctx, req)
+		}
+
+		scope, ok := scopes[info.FullMethod]
+		if !ok {
+			return nil, status.Error(codes.PermissionDenied, "personal access tokens cannot call this method")
+		}
+
+		claims, _ := ctx.Value(UserClaimsKey).(jwt.MapClaims)
+		granted, _ := claims["scope"].(string)
+		if !slices.Contains(strings.Fields(granted), scope) {
+			return nil, status.Error(codes.PermissionDenied, "personal access token is missing the "+scope+" scope")
+		}
+
+		return handler(ctx, req)
+	}
+}
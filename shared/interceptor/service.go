@@ -0,0 +1,190 @@
+package interceptor
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/vasapolrittideah/money-tracker-api/shared/auth"
+)
+
+// ServiceTokenHeader carries the token of the calling service. It is kept apart from the
+// Authorization header, which carries the credentials of the user the call is made for.
+const ServiceTokenHeader = "x-service-token"
+
+// serviceTokenRefreshBefore is how long before a service token expires a new one is signed, so
+// tokens do not expire while a call is on its way.
+const serviceTokenRefreshBefore = time.Minute
+
+type serviceContextKey struct{}
+
+var CallerServiceKey = serviceContextKey{}
+
+// CallerServiceFromContext returns the name of the service that made the call, as verified by the
+// service authentication interceptors.
+func CallerServiceFromContext(ctx context.Context) (string, bool) {
+	serviceName, ok := ctx.Value(CallerServiceKey).(string)
+	return serviceName, ok
+}
+
+// ServiceAllowList maps gRPC methods to the services that may call them. Keys are full method names,
+// such as "/auth.v1.AuthService/Login", or a gRPC service name followed by "/*", such as
+// "/auth.v1.AuthService/*", for every method of that service. Methods that are not listed cannot be
+// called by any service.
+type ServiceAllowList map[string][]string
+
+// Allows reports whether the service named serviceName may call method.
+func (l ServiceAllowList) Allows(method, serviceName string) bool {
+	if slices.Contains(l[method], serviceName) {
+		return true
+	}
+
+	if i := strings.LastIndex(method, "/"); i >= 0 {
+		return slices.Contains(l[method[:i+1]+"*"], serviceName)
+	}
+
+	return false
+}
+
+// NewServiceAuthInterceptor creates an interceptor that only lets the services in allowList call the
+// methods listed for them, except for the exempt ones such as health checks. Callers authenticate
+// with a token from auth.ServiceTokenIssuer, sent by ServiceCredentials.
+func NewServiceAuthInterceptor(
+	verifier *auth.ServiceTokenVerifier,
+	allowList ServiceAllowList,
+	exemptMethods []string,
+) grpc.UnaryServerInterceptor {
+	authenticate := newServiceAuthenticator(verifier, allowList, exemptMethods)
+
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		ctx, err := authenticate(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// NewServiceAuthStreamInterceptor is the streaming counterpart of NewServiceAuthInterceptor.
+func NewServiceAuthStreamInterceptor(
+	verifier *auth.ServiceTokenVerifier,
+	allowList ServiceAllowList,
+	exemptMethods []string,
+) grpc.StreamServerInterceptor {
+	authenticate := newServiceAuthenticator(verifier, allowList, exemptMethods)
+
+	return func(
+		srv any,
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		ctx, err := authenticate(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func newServiceAuthenticator(
+	verifier *auth.ServiceTokenVerifier,
+	allowList ServiceAllowList,
+	exemptMethods []string,
+) func(ctx context.Context, method string) (context.Context, error) {
+	exemptMap := make(map[string]bool)
+	for _, method := range exemptMethods {
+		exemptMap[method] = true
+	}
+
+	return func(ctx context.Context, method string) (context.Context, error) {
+		if exemptMap[method] {
+			return ctx, nil
+		}
+
+		md, _ := metadata.FromIncomingContext(ctx)
+		tokens := md.Get(ServiceTokenHeader)
+		if len(tokens) == 0 {
+			return nil, status.Error(codes.Unauthenticated, "missing service token")
+		}
+
+		serviceName, err := verifier.ValidateToken(tokens[0])
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, "invalid service token")
+		}
+
+		if !allowList.Allows(method, serviceName) {
+			return nil, status.Errorf(codes.PermissionDenied, "service %q may not call %s", serviceName, method)
+		}
+
+		return context.WithValue(ctx, CallerServiceKey, serviceName), nil
+	}
+}
+
+// serverStream overrides the context of a grpc.ServerStream.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// ServiceCredentials sends the token of the calling service with every call on a connection. Tokens
+// are signed for the service the connection leads to and reused until shortly before they expire.
+type ServiceCredentials struct {
+	issuer   *auth.ServiceTokenIssuer
+	audience string
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+// NewServiceCredentials creates the credentials for calling the service named audience. Pass them to
+// grpc.WithPerRPCCredentials when connecting to it.
+func NewServiceCredentials(issuer *auth.ServiceTokenIssuer, audience string) *ServiceCredentials {
+	return &ServiceCredentials{
+		issuer:   issuer,
+		audience: audience,
+	}
+}
+
+// GetRequestMetadata returns the service token header.
+func (c *ServiceCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if time.Until(c.expiresAt) < serviceTokenRefreshBefore {
+		token, expiresAt, err := c.issuer.GenerateToken(c.audience)
+		if err != nil {
+			return nil, err
+		}
+
+		c.token = token
+		c.expiresAt = expiresAt
+	}
+
+	return map[string]string{ServiceTokenHeader: c.token}, nil
+}
+
+// RequireTransportSecurity returns false, since services connect to each other without TLS inside
+// the cluster. The short lifetime of the tokens limits what can be done with an intercepted one.
+func (c *ServiceCredentials) RequireTransportSecurity() bool {
+	return false
+}
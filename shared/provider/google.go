@@ -5,30 +5,60 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"net/url"
 
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/oauth2/v2"
 	"google.golang.org/api/option"
 )
 
 var (
 	ErrInvalidGoogleAudience = errors.New("invalid google audience")
+	ErrInvalidGoogleIDToken  = errors.New("invalid google id token")
 )
 
+const defaultGoogleEndpoint = "https://www.googleapis.com/"
+
 type GoogleOAuthProvider struct {
-	idToken  string
-	clientID string
+	clientID   string
+	endpoint   string
+	httpClient *http.Client
+}
+
+// NewGoogleOAuthProvider creates a provider that accepts tokens issued to clientID.
+// endpoint is the base URL of the Google OAuth2 API; an empty endpoint uses Google's,
+// any other value lets a local stub server stand in for it.
+func NewGoogleOAuthProvider(clientID, endpoint string) *GoogleOAuthProvider {
+	if endpoint == "" {
+		endpoint = defaultGoogleEndpoint
+	}
+
+	return &GoogleOAuthProvider{
+		clientID:   clientID,
+		endpoint:   endpoint,
+		httpClient: &http.Client{Timeout: httpTimeout},
+	}
 }
 
-func (p *GoogleOAuthProvider) ValidateIDToken(ctx context.Context) (*oauth2.Tokeninfo, error) {
-	oauth2Service, err := oauth2.NewService(ctx, option.WithHTTPClient(&http.Client{}))
+func (p *GoogleOAuthProvider) ValidateIDToken(ctx context.Context, idToken string) (*oauth2.Tokeninfo, error) {
+	oauth2Service, err := oauth2.NewService(
+		ctx,
+		option.WithHTTPClient(p.httpClient),
+		option.WithEndpoint(p.endpoint),
+	)
 	if err != nil {
 		return nil, err
 	}
 
 	tokenInfoCall := oauth2Service.Tokeninfo()
-	tokenInfoCall.IdToken(p.idToken)
-	tokenInfo, err := tokenInfoCall.Do()
+	tokenInfoCall.IdToken(idToken)
+	tokenInfo, err := tokenInfoCall.Context(ctx).Do()
 	if err != nil {
+		// The token info endpoint answers with a 4xx status for malformed, expired or forged tokens
+		var apiErr *googleapi.Error
+		if errors.As(err, &apiErr) && apiErr.Code >= 400 && apiErr.Code < 500 {
+			return nil, ErrInvalidGoogleIDToken
+		}
 		return nil, err
 	}
 
@@ -36,20 +66,28 @@ func (p *GoogleOAuthProvider) ValidateIDToken(ctx context.Context) (*oauth2.Toke
 		return nil, ErrInvalidGoogleAudience
 	}
 
+	// The token info endpoint rejects expired tokens itself; this guards against one that does not
+	if tokenInfo.UserId == "" || tokenInfo.ExpiresIn <= 0 {
+		return nil, ErrInvalidGoogleIDToken
+	}
+
 	return tokenInfo, nil
 }
 
-func (p *GoogleOAuthProvider) GetUserInfo() (*oauth2.Userinfo, error) {
-	client := &http.Client{}
+func (p *GoogleOAuthProvider) GetUserInfo(ctx context.Context, accessToken string) (*oauth2.Userinfo, error) {
+	userInfoURL, err := url.JoinPath(p.endpoint, "oauth2/v2/userinfo")
+	if err != nil {
+		return nil, err
+	}
 
-	req, err := http.NewRequest(http.MethodGet, "https://www.googleapis.com/oauth2/v1/userinfo", nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, userInfoURL, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Set("Authorization", "Bearer "+p.idToken)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
 
-	resp, err := client.Do(req)
+	resp, err := p.httpClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,97 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testGoogleClientID = "client-id.apps.googleusercontent.com"
+
+// newGoogleStub starts a stand-in for the Google token info endpoint that answers every request
+// with status and body.
+func newGoogleStub(t *testing.T, status int, body map[string]any) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/oauth2/v2/tokeninfo" {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(body)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestGoogleOAuthProviderValidateIDToken(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    map[string]any
+		wantErr error
+	}{
+		{
+			name:   "valid token",
+			status: http.StatusOK,
+			body: map[string]any{
+				"audience":       testGoogleClientID,
+				"user_id":        "1234567890",
+				"email":          "user@example.com",
+				"verified_email": true,
+				"expires_in":     3599,
+			},
+		},
+		{
+			name:   "token issued to another client",
+			status: http.StatusOK,
+			body: map[string]any{
+				"audience":   "other-client.apps.googleusercontent.com",
+				"user_id":    "1234567890",
+				"expires_in": 3599,
+			},
+			wantErr: ErrInvalidGoogleAudience,
+		},
+		{
+			name:   "expired token rejected by the endpoint",
+			status: http.StatusBadRequest,
+			body: map[string]any{
+				"error":             "invalid_token",
+				"error_description": "Token expired",
+			},
+			wantErr: ErrInvalidGoogleIDToken,
+		},
+		{
+			name:   "expired token accepted by the endpoint",
+			status: http.StatusOK,
+			body: map[string]any{
+				"audience":   testGoogleClientID,
+				"user_id":    "1234567890",
+				"expires_in": 0,
+			},
+			wantErr: ErrInvalidGoogleIDToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newGoogleStub(t, tt.status, tt.body)
+			provider := NewGoogleOAuthProvider(testGoogleClientID, server.URL+"/")
+
+			tokenInfo, err := provider.ValidateIDToken(context.Background(), "id-token")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ValidateIDToken() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && tokenInfo.Email != "user@example.com" {
+				t.Errorf("ValidateIDToken() email = %q, want %q", tokenInfo.Email, "user@example.com")
+			}
+		})
+	}
+}
@@ -0,0 +1,310 @@
+package provider
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/oauth2"
+
+	"github.com/vasapolrittideah/money-tracker-api/shared/auth"
+)
+
+// OIDCConfig contains the configuration of an OpenID Connect provider.
+type OIDCConfig struct {
+	// Name is the key the provider is registered under, e.g. "apple" or "line".
+	Name string
+
+	// Issuer is the issuer URL the discovery document is fetched from.
+	Issuer string
+
+	ClientID     string
+	ClientSecret string
+
+	// RedirectURL is the callback URL registered with the provider.
+	RedirectURL string
+
+	// Scopes are requested in addition to "openid".
+	Scopes []string
+
+	// HTTPClient is used for every request to the provider. It defaults to a client that gives up
+	// after httpTimeout.
+	HTTPClient *http.Client
+
+	// JWKSRefreshInterval limits how often a token signed with an unknown key makes the provider's
+	// keys be fetched again. It defaults to thirty seconds.
+	JWKSRefreshInterval time.Duration
+}
+
+// OIDCProvider is a Provider for any identity provider implementing OpenID Connect discovery.
+// The discovery document is fetched on first use, so an unreachable provider does not keep
+// the service from starting.
+type OIDCProvider struct {
+	cfg        OIDCConfig
+	httpClient *http.Client
+
+	mu        sync.Mutex
+	discovery *oidcDiscovery
+	keySet    *auth.RemoteKeySet
+}
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type idTokenClaims struct {
+	jwt.RegisteredClaims
+
+	Nonce         string       `json:"nonce"`
+	Email         string       `json:"email"`
+	EmailVerified flexibleBool `json:"email_verified"`
+	Name          string       `json:"name"`
+	Picture       string       `json:"picture"`
+}
+
+type userInfoResponse struct {
+	Subject       string       `json:"sub"`
+	Email         string       `json:"email"`
+	EmailVerified flexibleBool `json:"email_verified"`
+	Name          string       `json:"name"`
+	Picture       string       `json:"picture"`
+}
+
+// flexibleBool accepts both JSON booleans and the "true"/"false" strings some providers,
+// such as Apple, use for boolean claims.
+type flexibleBool bool
+
+func (b *flexibleBool) UnmarshalJSON(data []byte) error {
+	switch strings.Trim(string(data), `"`) {
+	case "true":
+		*b = true
+	case "false", "null", "":
+		*b = false
+	default:
+		return fmt.Errorf("invalid boolean value %s", data)
+	}
+
+	return nil
+}
+
+// NewOIDCProvider creates a new OpenID Connect provider.
+func NewOIDCProvider(cfg OIDCConfig) *OIDCProvider {
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: httpTimeout}
+	}
+
+	return &OIDCProvider{
+		cfg:        cfg,
+		httpClient: httpClient,
+	}
+}
+
+func (p *OIDCProvider) Name() string {
+	return p.cfg.Name
+}
+
+func (p *OIDCProvider) AuthCodeURL(ctx context.Context, state, nonce, codeVerifier string) (string, error) {
+	oauth2Cfg, err := p.oauth2Config(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return oauth2Cfg.AuthCodeURL(
+		state,
+		oauth2.S256ChallengeOption(codeVerifier),
+		oauth2.SetAuthURLParam("nonce", nonce),
+	), nil
+}
+
+func (p *OIDCProvider) Exchange(ctx context.Context, code, codeVerifier string) (*Token, error) {
+	oauth2Cfg, err := p.oauth2Config(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, p.httpClient)
+	token, err := oauth2Cfg.Exchange(ctx, code, oauth2.VerifierOption(codeVerifier))
+	if err != nil {
+		var retrieveErr *oauth2.RetrieveError
+		if errors.As(err, &retrieveErr) {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidAuthorizationCode, err)
+		}
+		return nil, err
+	}
+
+	idToken, _ := token.Extra("id_token").(string)
+
+	return &Token{
+		AccessToken: token.AccessToken,
+		IDToken:     idToken,
+	}, nil
+}
+
+func (p *OIDCProvider) VerifyIDToken(ctx context.Context, rawIDToken, nonce string) (*UserInfo, error) {
+	discovery, keySet, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &idTokenClaims{}
+	if _, err := jwt.ParseWithClaims(
+		rawIDToken,
+		claims,
+		func(token *jwt.Token) (any, error) {
+			return keySet.VerificationKeyContext(ctx, token)
+		},
+		jwt.WithValidMethods(keySet.Algorithms()),
+		jwt.WithIssuer(discovery.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+	); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidIDToken, err)
+	}
+
+	if claims.Subject == "" {
+		return nil, ErrInvalidIDToken
+	}
+
+	if subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1 {
+		return nil, ErrNonceMismatch
+	}
+
+	return &UserInfo{
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: bool(claims.EmailVerified),
+		Name:          claims.Name,
+		Picture:       claims.Picture,
+	}, nil
+}
+
+func (p *OIDCProvider) UserInfo(ctx context.Context, token *Token, nonce string) (*UserInfo, error) {
+	if token.IDToken == "" {
+		return nil, ErrInvalidIDToken
+	}
+
+	info, err := p.VerifyIDToken(ctx, token.IDToken, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	discovery, _, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Some providers only put the email into the user info response
+	if info.Email == "" && discovery.UserinfoEndpoint != "" && token.AccessToken != "" {
+		var resp userInfoResponse
+		if err := getJSON(ctx, p.httpClient, discovery.UserinfoEndpoint, token.AccessToken, &resp); err != nil {
+			return nil, fmt.Errorf("failed to fetch user info: %w", err)
+		}
+
+		// The user info response must describe the same user as the ID token
+		if resp.Subject != info.Subject {
+			return nil, ErrInvalidIDToken
+		}
+
+		info.Email = resp.Email
+		info.EmailVerified = bool(resp.EmailVerified)
+		if info.Name == "" {
+			info.Name = resp.Name
+		}
+		if info.Picture == "" {
+			info.Picture = resp.Picture
+		}
+	}
+
+	if info.Email == "" {
+		return nil, ErrEmailNotProvided
+	}
+
+	return info, nil
+}
+
+func (p *OIDCProvider) oauth2Config(ctx context.Context) (*oauth2.Config, error) {
+	discovery, _, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &oauth2.Config{
+		ClientID:     p.cfg.ClientID,
+		ClientSecret: p.cfg.ClientSecret,
+		RedirectURL:  p.cfg.RedirectURL,
+		Scopes:       append([]string{"openid"}, p.cfg.Scopes...),
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  discovery.AuthorizationEndpoint,
+			TokenURL: discovery.TokenEndpoint,
+		},
+	}, nil
+}
+
+// discover fetches and caches the provider's discovery document and key set.
+func (p *OIDCProvider) discover(ctx context.Context) (*oidcDiscovery, *auth.RemoteKeySet, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, p.keySet, nil
+	}
+
+	discoveryURL := strings.TrimSuffix(p.cfg.Issuer, "/") + "/.well-known/openid-configuration"
+
+	var discovery oidcDiscovery
+	if err := getJSON(ctx, p.httpClient, discoveryURL, "", &discovery); err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch OIDC discovery document: %w", err)
+	}
+
+	// The issuer in the document must match the configured one, see OpenID Connect Discovery 4.3
+	if strings.TrimSuffix(discovery.Issuer, "/") != strings.TrimSuffix(p.cfg.Issuer, "/") {
+		return nil, nil, fmt.Errorf("OIDC issuer mismatch: expected %q, got %q", p.cfg.Issuer, discovery.Issuer)
+	}
+
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, nil, errors.New("OIDC discovery document is missing required endpoints")
+	}
+
+	p.discovery = &discovery
+	p.keySet = auth.NewRemoteKeySet(discovery.JWKSURI, p.httpClient, p.cfg.JWKSRefreshInterval)
+
+	return p.discovery, p.keySet, nil
+}
+
+// getJSON fetches url and decodes the JSON response into out. A non-empty
+// bearerToken is sent in the Authorization header.
+func getJSON(ctx context.Context, httpClient *http.Client, url, bearerToken string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+bearerToken)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
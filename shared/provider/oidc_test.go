@@ -0,0 +1,330 @@
+package provider
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testOIDCClientID = "client-id"
+	testOIDCNonce    = "nonce"
+)
+
+type fakeSigningKey struct {
+	id     string
+	method jwt.SigningMethod
+	signer crypto.Signer
+}
+
+func newFakeRSAKey(t *testing.T, id string) fakeSigningKey {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return fakeSigningKey{id: id, method: jwt.SigningMethodRS256, signer: key}
+}
+
+func newFakeECKey(t *testing.T, id string) fakeSigningKey {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return fakeSigningKey{id: id, method: jwt.SigningMethodES256, signer: key}
+}
+
+func (k fakeSigningKey) jwk() map[string]string {
+	jwk := map[string]string{"kid": k.id, "use": "sig", "alg": k.method.Alg()}
+
+	switch publicKey := k.signer.Public().(type) {
+	case *rsa.PublicKey:
+		jwk["kty"] = "RSA"
+		jwk["n"] = base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+		jwk["e"] = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+	case *ecdsa.PublicKey:
+		jwk["kty"] = "EC"
+		jwk["crv"] = "P-256"
+		jwk["x"] = base64.RawURLEncoding.EncodeToString(publicKey.X.FillBytes(make([]byte, 32)))
+		jwk["y"] = base64.RawURLEncoding.EncodeToString(publicKey.Y.FillBytes(make([]byte, 32)))
+	}
+
+	return jwk
+}
+
+// fakeIssuer is a local OpenID Connect provider serving a discovery document and a key set
+// that tests can rotate.
+type fakeIssuer struct {
+	server *httptest.Server
+	// advertisedIssuer is the issuer in the discovery document, the server URL unless a test
+	// changes it.
+	advertisedIssuer string
+
+	mu           sync.Mutex
+	keys         []fakeSigningKey
+	jwksRequests int
+}
+
+func newFakeIssuer(t *testing.T, keys ...fakeSigningKey) *fakeIssuer {
+	t.Helper()
+
+	issuer := &fakeIssuer{keys: keys}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeTestJSON(w, map[string]string{
+			"issuer":                 issuer.advertisedIssuer,
+			"authorization_endpoint": issuer.server.URL + "/authorize",
+			"token_endpoint":         issuer.server.URL + "/token",
+			"jwks_uri":               issuer.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		issuer.mu.Lock()
+		defer issuer.mu.Unlock()
+
+		issuer.jwksRequests++
+		jwks := make([]map[string]string, 0, len(issuer.keys))
+		for _, key := range issuer.keys {
+			jwks = append(jwks, key.jwk())
+		}
+		writeTestJSON(w, map[string]any{"keys": jwks})
+	})
+
+	issuer.server = httptest.NewServer(mux)
+	issuer.advertisedIssuer = issuer.server.URL
+	t.Cleanup(issuer.server.Close)
+
+	return issuer
+}
+
+func (i *fakeIssuer) rotate(keys ...fakeSigningKey) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.keys = keys
+}
+
+func (i *fakeIssuer) provider(refreshInterval time.Duration) *OIDCProvider {
+	return NewOIDCProvider(OIDCConfig{
+		Name:                "fake",
+		Issuer:              i.server.URL,
+		ClientID:            testOIDCClientID,
+		RedirectURL:         "https://app.example.com/callback",
+		JWKSRefreshInterval: refreshInterval,
+	})
+}
+
+// idToken returns an ID token signed with key. modify may change the claims before signing.
+func (i *fakeIssuer) idToken(t *testing.T, key fakeSigningKey, modify func(claims *idTokenClaims)) string {
+	t.Helper()
+
+	now := time.Now()
+	claims := &idTokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    i.server.URL,
+			Subject:   "user-1",
+			Audience:  jwt.ClaimStrings{testOIDCClientID},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		},
+		Nonce: testOIDCNonce,
+		Email: "user@example.com",
+	}
+	if modify != nil {
+		modify(claims)
+	}
+
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.id
+
+	signed, err := token.SignedString(key.signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signed
+}
+
+func writeTestJSON(w http.ResponseWriter, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func TestOIDCProviderAuthCodeURL(t *testing.T) {
+	issuer := newFakeIssuer(t)
+
+	authURL, err := issuer.provider(0).AuthCodeURL(context.Background(), "state", testOIDCNonce, strings.Repeat("v", 43))
+	if err != nil {
+		t.Fatalf("AuthCodeURL() error = %v", err)
+	}
+
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := parsed.Scheme + "://" + parsed.Host + parsed.Path; got != issuer.server.URL+"/authorize" {
+		t.Errorf("AuthCodeURL() endpoint = %q, want the discovered authorization endpoint", got)
+	}
+
+	query := parsed.Query()
+	for param, want := range map[string]string{
+		"client_id":             testOIDCClientID,
+		"state":                 "state",
+		"nonce":                 testOIDCNonce,
+		"code_challenge_method": "S256",
+		"scope":                 "openid",
+	} {
+		if got := query.Get(param); got != want {
+			t.Errorf("AuthCodeURL() %s = %q, want %q", param, got, want)
+		}
+	}
+}
+
+func TestOIDCProviderRejectsIssuerMismatch(t *testing.T) {
+	issuer := newFakeIssuer(t)
+	issuer.advertisedIssuer = "https://attacker.example.com"
+
+	if _, err := issuer.provider(0).AuthCodeURL(context.Background(), "state", testOIDCNonce, "verifier"); err == nil {
+		t.Fatal("AuthCodeURL() succeeded for a discovery document of another issuer")
+	}
+}
+
+func TestOIDCProviderVerifyIDToken(t *testing.T) {
+	key := newFakeECKey(t, "key-1")
+	otherKey := newFakeECKey(t, "key-1")
+	issuer := newFakeIssuer(t, key)
+
+	tests := []struct {
+		name    string
+		key     fakeSigningKey
+		modify  func(claims *idTokenClaims)
+		nonce   string
+		wantErr error
+	}{
+		{
+			name:  "valid token",
+			key:   key,
+			nonce: testOIDCNonce,
+		},
+		{
+			name:    "nonce mismatch",
+			key:     key,
+			nonce:   "other-nonce",
+			wantErr: ErrNonceMismatch,
+		},
+		{
+			name:    "missing nonce",
+			key:     key,
+			modify:  func(claims *idTokenClaims) { claims.Nonce = "" },
+			nonce:   testOIDCNonce,
+			wantErr: ErrNonceMismatch,
+		},
+		{
+			name:    "other issuer",
+			key:     key,
+			modify:  func(claims *idTokenClaims) { claims.Issuer = "https://attacker.example.com" },
+			nonce:   testOIDCNonce,
+			wantErr: ErrInvalidIDToken,
+		},
+		{
+			name:    "other audience",
+			key:     key,
+			modify:  func(claims *idTokenClaims) { claims.Audience = jwt.ClaimStrings{"other-client"} },
+			nonce:   testOIDCNonce,
+			wantErr: ErrInvalidIDToken,
+		},
+		{
+			name: "expired",
+			key:  key,
+			modify: func(claims *idTokenClaims) {
+				claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+			},
+			nonce:   testOIDCNonce,
+			wantErr: ErrInvalidIDToken,
+		},
+		{
+			name:    "forged signature",
+			key:     otherKey,
+			nonce:   testOIDCNonce,
+			wantErr: ErrInvalidIDToken,
+		},
+	}
+
+	provider := issuer.provider(0)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := provider.VerifyIDToken(context.Background(), issuer.idToken(t, tt.key, tt.modify), tt.nonce)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("VerifyIDToken() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && (info.Subject != "user-1" || info.Email != "user@example.com") {
+				t.Errorf("VerifyIDToken() = %+v, want user-1 with user@example.com", info)
+			}
+		})
+	}
+}
+
+func TestOIDCProviderKeyRotation(t *testing.T) {
+	oldKey := newFakeRSAKey(t, "old")
+	newKey := newFakeECKey(t, "new")
+	issuer := newFakeIssuer(t, oldKey)
+	provider := issuer.provider(time.Nanosecond)
+	ctx := context.Background()
+
+	if _, err := provider.VerifyIDToken(ctx, issuer.idToken(t, oldKey, nil), testOIDCNonce); err != nil {
+		t.Fatalf("VerifyIDToken() with the old key error = %v", err)
+	}
+
+	issuer.rotate(newKey)
+
+	if _, err := provider.VerifyIDToken(ctx, issuer.idToken(t, newKey, nil), testOIDCNonce); err != nil {
+		t.Fatalf("VerifyIDToken() with the new key error = %v", err)
+	}
+	if _, err := provider.VerifyIDToken(ctx, issuer.idToken(t, oldKey, nil), testOIDCNonce); err == nil {
+		t.Fatal("VerifyIDToken() accepted a key that is no longer published")
+	}
+}
+
+func TestOIDCProviderLimitsKeyRefetches(t *testing.T) {
+	key := newFakeECKey(t, "key-1")
+	unknownKey := newFakeECKey(t, "unknown")
+	issuer := newFakeIssuer(t, key)
+	provider := issuer.provider(time.Hour)
+	ctx := context.Background()
+
+	if _, err := provider.VerifyIDToken(ctx, issuer.idToken(t, key, nil), testOIDCNonce); err != nil {
+		t.Fatalf("VerifyIDToken() error = %v", err)
+	}
+	for range 3 {
+		if _, err := provider.VerifyIDToken(ctx, issuer.idToken(t, unknownKey, nil), testOIDCNonce); err == nil {
+			t.Fatal("VerifyIDToken() accepted a token signed with an unknown key")
+		}
+	}
+
+	issuer.mu.Lock()
+	defer issuer.mu.Unlock()
+	if issuer.jwksRequests != 1 {
+		t.Errorf("key set fetched %d times, want 1", issuer.jwksRequests)
+	}
+}
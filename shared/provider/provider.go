@@ -0,0 +1,58 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// httpTimeout bounds every request to a provider, so a slow provider fails logins instead of
+// hanging them.
+const httpTimeout = 10 * time.Second
+
+var (
+	ErrInvalidIDToken           = errors.New("invalid id token")
+	ErrNonceMismatch            = errors.New("id token nonce does not match")
+	ErrInvalidAuthorizationCode = errors.New("invalid authorization code")
+	ErrEmailNotProvided         = errors.New("provider did not return an email address")
+	ErrProviderNotFound         = errors.New("provider not found")
+	ErrProviderDuplicate        = errors.New("provider is already registered")
+)
+
+// Provider is an external identity provider users can sign in with through the
+// authorization code flow.
+type Provider interface {
+	// Name returns the key the provider is registered under, which is also the
+	// provider stored on the identities of its users.
+	Name() string
+
+	// AuthCodeURL returns the URL to send the user to for signing in. The PKCE challenge is
+	// derived from codeVerifier, which must be passed to Exchange later.
+	AuthCodeURL(ctx context.Context, state, nonce, codeVerifier string) (string, error)
+
+	// Exchange trades an authorization code for the provider's tokens.
+	Exchange(ctx context.Context, code, codeVerifier string) (*Token, error)
+
+	// VerifyIDToken checks the signature and claims of an ID token, including that it
+	// was issued for nonce, and returns the user it identifies.
+	VerifyIDToken(ctx context.Context, rawIDToken, nonce string) (*UserInfo, error)
+
+	// UserInfo returns the user the token was issued to, falling back to the provider's
+	// user info endpoint for claims the ID token does not carry.
+	UserInfo(ctx context.Context, token *Token, nonce string) (*UserInfo, error)
+}
+
+// Token holds the tokens returned by a provider's token endpoint.
+type Token struct {
+	AccessToken string
+	IDToken     string
+}
+
+// UserInfo is the provider independent view of an external user.
+type UserInfo struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Picture       string
+}
@@ -0,0 +1,104 @@
+package security
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// BreachedPasswordList looks passwords up in a local copy of a breached password list in the
+// k-anonymity range format, as published by Have I Been Pwned: the uppercase hex SHA-1 hashes are
+// split into one file per five character prefix, e.g. 21BD1.txt, and every line of a file holds the
+// remaining 35 characters of a hash and how often it was seen, separated by a colon.
+type BreachedPasswordList struct {
+	dir string
+}
+
+// NewBreachedPasswordList creates a list backed by the prefix files in dir. It fails unless dir
+// holds at least one prefix file, as lookups in an empty or misconfigured directory would let every
+// password through.
+func NewBreachedPasswordList(dir string) (*BreachedPasswordList, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breached password list: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("breached password list %s is not a directory", dir)
+	}
+
+	hasPrefixFiles, err := containsPrefixFile(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read breached password list: %w", err)
+	}
+	if !hasPrefixFiles {
+		return nil, fmt.Errorf("breached password list %s has no prefix files such as 21BD1.txt", dir)
+	}
+
+	return &BreachedPasswordList{dir: dir}, nil
+}
+
+// containsPrefixFile reports whether dir holds a prefix file. A complete list has a million of
+// them, so the directory is read in batches until the first one is found.
+func containsPrefixFile(dir string) (bool, error) {
+	file, err := os.Open(dir)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	for {
+		entries, err := file.ReadDir(1024)
+		for _, entry := range entries {
+			if !entry.IsDir() && isPrefixFileName(entry.Name()) {
+				return true, nil
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+}
+
+func isPrefixFileName(name string) bool {
+	prefix, ok := strings.CutSuffix(name, ".txt")
+	return ok && len(prefix) == 5 && strings.Trim(prefix, "0123456789ABCDEF") == ""
+}
+
+// Contains reports whether the password appears in the list.
+func (l *BreachedPasswordList) Contains(password string) (bool, error) {
+	// The lists are published as SHA-1 hashes, which is only used to look the password up here
+	hash := sha1.Sum([]byte(password))
+	hexHash := strings.ToUpper(hex.EncodeToString(hash[:]))
+	prefix, suffix := hexHash[:5], hexHash[5:]
+
+	file, err := os.Open(filepath.Join(l.dir, prefix+".txt"))
+	if err != nil {
+		// Every prefix has a file in a complete list, but a partial one just has no entries for it
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lineSuffix, count, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		// Padded lists add entries with a count of zero so every file has a similar size
+		if strings.EqualFold(lineSuffix, suffix) && count != "0" {
+			return true, nil
+		}
+	}
+
+	return false, scanner.Err()
+}
@@ -1,17 +1,104 @@
 package security
 
-import "github.com/matthewhartstonge/argon2"
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"strings"
 
-func HashPassword(password string) (string, error) {
-	argon := argon2.DefaultConfig()
-	encoded, err := argon.HashEncoded([]byte(password))
+	"github.com/matthewhartstonge/argon2"
+)
+
+var ErrPepperRequired = errors.New("password hash was created with a pepper but none is configured")
+
+// pepperedPrefix marks hashes of peppered passwords, so hashes created before a pepper was
+// configured can still be verified.
+const pepperedPrefix = "$peppered"
+
+// PasswordHashingParams are the Argon2id parameters new password hashes are created with.
+type PasswordHashingParams struct {
+	// MemoryCost is the memory used in KiB.
+	MemoryCost  uint32
+	TimeCost    uint32
+	Parallelism uint8
+	SaltLength  uint32
+	HashLength  uint32
+}
+
+// PasswordHasher hashes and verifies passwords with Argon2id. If a pepper is set, passwords are
+// keyed with it using HMAC-SHA256 before hashing, so a leaked database alone is not enough to
+// crack them.
+type PasswordHasher struct {
+	config argon2.Config
+	pepper []byte
+}
+
+// NewPasswordHasher creates a PasswordHasher. pepper may be empty to hash passwords without one.
+func NewPasswordHasher(params PasswordHashingParams, pepper string) (*PasswordHasher, error) {
+	if params.MemoryCost == 0 || params.TimeCost == 0 || params.Parallelism == 0 {
+		return nil, errors.New("argon2 memory cost, time cost and parallelism must be positive")
+	}
+	if params.SaltLength < 8 || params.HashLength < 16 {
+		return nil, errors.New("argon2 salt must be at least 8 bytes and hash at least 16 bytes long")
+	}
+
+	return &PasswordHasher{
+		config: argon2.Config{
+			HashLength:  params.HashLength,
+			SaltLength:  params.SaltLength,
+			TimeCost:    params.TimeCost,
+			MemoryCost:  params.MemoryCost,
+			Parallelism: params.Parallelism,
+			Mode:        argon2.ModeArgon2id,
+			Version:     argon2.Version13,
+		},
+		pepper: []byte(pepper),
+	}, nil
+}
+
+// Hash returns the encoded hash of password.
+func (h *PasswordHasher) Hash(password string) (string, error) {
+	peppered := len(h.pepper) != 0
+	encoded, err := h.config.HashEncoded(h.keyPassword(password, peppered))
 	if err != nil {
 		return "", err
 	}
 
+	if peppered {
+		return pepperedPrefix + string(encoded), nil
+	}
+
 	return string(encoded), nil
 }
 
-func VerifyPassword(password, encodedHash string) (bool, error) {
-	return argon2.VerifyEncoded([]byte(password), []byte(encodedHash))
+// Verify reports whether password matches encodedHash. needsRehash is set for matching passwords
+// whose hash was created with other parameters or without the current pepper; callers should then
+// store a new hash of the password.
+func (h *PasswordHasher) Verify(password, encodedHash string) (ok bool, needsRehash bool, err error) {
+	encoded, peppered := strings.CutPrefix(encodedHash, pepperedPrefix)
+	if peppered && len(h.pepper) == 0 {
+		return false, false, ErrPepperRequired
+	}
+
+	raw, err := argon2.Decode([]byte(encoded))
+	if err != nil {
+		return false, false, err
+	}
+
+	ok, err = raw.Verify(h.keyPassword(password, peppered))
+	if err != nil || !ok {
+		return false, false, err
+	}
+
+	return true, raw.Config != h.config || peppered != (len(h.pepper) != 0), nil
+}
+
+func (h *PasswordHasher) keyPassword(password string, peppered bool) []byte {
+	if !peppered {
+		return []byte(password)
+	}
+
+	mac := hmac.New(sha256.New, h.pepper)
+	mac.Write([]byte(password))
+	return mac.Sum(nil)
 }
@@ -0,0 +1,110 @@
+package security
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	ErrPasswordTooShort       = errors.New("password is too short")
+	ErrPasswordTooLong        = errors.New("password is too long")
+	ErrPasswordCharacterClass = errors.New("password is missing a required character class")
+	ErrPasswordContainsEmail  = errors.New("password must not contain the email address")
+	ErrPasswordTooWeak        = errors.New("password is too easy to guess")
+	ErrPasswordBreached       = errors.New("password has appeared in a data breach")
+)
+
+// PasswordPolicy defines the rules new passwords have to follow.
+type PasswordPolicy struct {
+	MinLength     int
+	MaxLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	// MinScore is the lowest PasswordScore accepted.
+	MinScore int
+	// BreachedPasswords is checked last, if set.
+	BreachedPasswords *BreachedPasswordList
+}
+
+// Validate checks a new password for the account with the given email. Policy violations are
+// returned as one of the ErrPassword errors, with details in the message; any other error means
+// the password could not be checked.
+func (p *PasswordPolicy) Validate(password, email string) error {
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		return fmt.Errorf("%w: use at least %d characters", ErrPasswordTooShort, p.MinLength)
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		return fmt.Errorf("%w: use at most %d characters", ErrPasswordTooLong, p.MaxLength)
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		default:
+			hasSymbol = true
+		}
+	}
+
+	var missing []string
+	if p.RequireUpper && !hasUpper {
+		missing = append(missing, "an uppercase letter")
+	}
+	if p.RequireLower && !hasLower {
+		missing = append(missing, "a lowercase letter")
+	}
+	if p.RequireDigit && !hasDigit {
+		missing = append(missing, "a digit")
+	}
+	if p.RequireSymbol && !hasSymbol {
+		missing = append(missing, "a symbol")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: add %s", ErrPasswordCharacterClass, strings.Join(missing, ", "))
+	}
+
+	lowerPassword := strings.ToLower(password)
+	lowerEmail := strings.ToLower(strings.TrimSpace(email))
+	localPart, _, _ := strings.Cut(lowerEmail, "@")
+	if lowerEmail != "" && strings.Contains(lowerPassword, lowerEmail) ||
+		len(localPart) >= 3 && strings.Contains(lowerPassword, localPart) {
+		return ErrPasswordContainsEmail
+	}
+
+	if PasswordScore(password, lowerEmail, localPart) < p.MinScore {
+		return fmt.Errorf("%w: avoid common words, names, repeated characters and sequences", ErrPasswordTooWeak)
+	}
+
+	if p.BreachedPasswords != nil {
+		breached, err := p.BreachedPasswords.Contains(password)
+		if err != nil {
+			return err
+		}
+		if breached {
+			return ErrPasswordBreached
+		}
+	}
+
+	return nil
+}
+
+// IsPasswordPolicyViolation reports whether err is one of the errors Validate reports policy violations with.
+func IsPasswordPolicyViolation(err error) bool {
+	return errors.Is(err, ErrPasswordTooShort) ||
+		errors.Is(err, ErrPasswordTooLong) ||
+		errors.Is(err, ErrPasswordCharacterClass) ||
+		errors.Is(err, ErrPasswordContainsEmail) ||
+		errors.Is(err, ErrPasswordTooWeak) ||
+		errors.Is(err, ErrPasswordBreached)
+}
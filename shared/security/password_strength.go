@@ -0,0 +1,178 @@
+package security
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// commonPasswordWords are passwords and words that are among the first an attacker tries.
+// They are ordered roughly by popularity; a word's rank is its position in the list.
+var commonPasswordWords = []string{
+	"password", "123456", "qwerty", "letmein", "welcome", "admin", "login", "abc123", "iloveyou",
+	"monkey", "dragon", "football", "baseball", "master", "sunshine", "princess", "shadow", "superman",
+	"trustno1", "passw0rd", "starwars", "whatever", "freedom", "hello", "charlie", "donald", "secret",
+	"qwertyuiop", "asdfgh", "zxcvbn", "azerty", "michael", "jordan", "jennifer", "hunter", "ranger",
+	"batman", "soccer", "hockey", "killer", "george", "summer", "winter", "spring", "autumn", "flower",
+	"computer", "internet", "love", "money", "mustang", "access", "thomas", "pepper", "cookie", "cheese",
+	"ninja", "mother", "father", "family", "friend", "google", "apple", "orange", "banana", "chocolate",
+	"january", "february", "march", "april", "june", "july", "august", "september", "october",
+	"november", "december", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday",
+	"sunday", "default", "changeme", "test", "guest", "root", "user", "pass", "wallet", "finance",
+	"budget", "bank", "tracker",
+}
+
+// leetSubstitutions maps the common character substitutions back to the letters they replace.
+var leetSubstitutions = map[rune]rune{
+	'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '7': 't', '8': 'b', '@': 'a', '$': 's', '!': 'i',
+}
+
+// PasswordScore rates how hard a password is to guess, in the style of zxcvbn. The password is split
+// into the cheapest sequence of patterns an attacker would try: common words (also with l33t
+// substitutions and the given user inputs such as their email), repeated characters, runs like
+// "abcd" or "4321", and otherwise single characters guessed by brute force. The estimated number of
+// guesses maps to a score from 0 (under a thousand guesses) to 4 (ten billion guesses or more).
+func PasswordScore(password string, userInputs ...string) int {
+	guesses := estimateGuesses(password, userInputs)
+
+	switch {
+	case guesses < 1e3:
+		return 0
+	case guesses < 1e6:
+		return 1
+	case guesses < 1e8:
+		return 2
+	case guesses < 1e10:
+		return 3
+	default:
+		return 4
+	}
+}
+
+func estimateGuesses(password string, userInputs []string) float64 {
+	runes := []rune(password)
+	lower := make([]rune, len(runes))
+	normalized := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+		normalized[i] = lower[i]
+		if letter, ok := leetSubstitutions[r]; ok {
+			normalized[i] = letter
+		}
+	}
+
+	dictionary := make([]string, 0, len(userInputs)+len(commonPasswordWords))
+	for _, input := range userInputs {
+		if input = strings.ToLower(input); len(input) >= 3 {
+			dictionary = append(dictionary, input)
+		}
+	}
+	dictionary = append(dictionary, commonPasswordWords...)
+
+	// minGuesses[i] is the fewest guesses needed for the first i characters
+	minGuesses := make([]float64, len(runes)+1)
+	minGuesses[0] = 1
+	for i := 1; i <= len(runes); i++ {
+		minGuesses[i] = math.Inf(1)
+	}
+
+	for start := range runes {
+		if math.IsInf(minGuesses[start], 1) {
+			continue
+		}
+
+		relax := func(end int, guesses float64) {
+			if total := minGuesses[start] * guesses; total < minGuesses[end] {
+				minGuesses[end] = total
+			}
+		}
+
+		relax(start+1, float64(characterCardinality(runes[start])))
+
+		for rank, word := range dictionary {
+			wordRunes := []rune(word)
+			end := start + len(wordRunes)
+			if end > len(runes) {
+				continue
+			}
+
+			if string(lower[start:end]) == word {
+				relax(end, float64(rank+1)*caseVariations(runes[start:end]))
+			} else if string(normalized[start:end]) == word {
+				// Every substitution could have been made or not
+				relax(end, float64(rank+1)*caseVariations(runes[start:end])*2)
+			}
+		}
+
+		if end := repeatEnd(lower, start); end-start >= 3 {
+			relax(end, float64(characterCardinality(runes[start])*(end-start)))
+		}
+
+		if end := sequenceEnd(lower, start); end-start >= 3 {
+			relax(end, float64(4*(end-start)))
+		}
+	}
+
+	return minGuesses[len(runes)]
+}
+
+// characterCardinality is the number of characters a brute force attack tries for a character of this kind.
+func characterCardinality(r rune) int {
+	switch {
+	case unicode.IsDigit(r):
+		return 10
+	case unicode.IsLower(r), unicode.IsUpper(r):
+		return 26
+	case r < unicode.MaxASCII:
+		return 33
+	default:
+		return 100
+	}
+}
+
+// caseVariations is how many capitalizations an attacker tries before reaching the given one:
+// all lowercase, a capital first letter and all uppercase are tried first.
+func caseVariations(word []rune) float64 {
+	upper := 0
+	for _, r := range word {
+		if unicode.IsUpper(r) {
+			upper++
+		}
+	}
+
+	switch {
+	case upper == 0:
+		return 1
+	case upper == len(word), upper == 1 && unicode.IsUpper(word[0]):
+		return 2
+	default:
+		return math.Pow(2, float64(len(word))) / 2
+	}
+}
+
+// repeatEnd returns where the run of the character at start ends, as in "aaaa".
+func repeatEnd(runes []rune, start int) int {
+	end := start + 1
+	for end < len(runes) && runes[end] == runes[start] {
+		end++
+	}
+	return end
+}
+
+// sequenceEnd returns where the ascending or descending run starting at start ends, as in "abcd" or "9876".
+func sequenceEnd(runes []rune, start int) int {
+	if start+1 >= len(runes) {
+		return start + 1
+	}
+
+	step := runes[start+1] - runes[start]
+	if step != 1 && step != -1 {
+		return start + 1
+	}
+
+	end := start + 2
+	for end < len(runes) && runes[end]-runes[end-1] == step {
+		end++
+	}
+	return end
+}
@@ -2,12 +2,32 @@ package utilities
 
 import (
 	"context"
+	"net"
 	"net/http"
+	"strings"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/health"
 	"google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+const (
+	// ClientIPHeader carries the client IP address resolved by the API gateway.
+	ClientIPHeader = "X-Client-IP"
+
+	// ClientUserAgentHeader carries the client user agent. gRPC overwrites the user-agent
+	// header with its own value, so the original one is forwarded under this key instead.
+	ClientUserAgentHeader = "X-Client-User-Agent"
+
+	// ClientDeviceIDHeader carries the device ID of the client, taken from its DeviceIDCookie.
+	ClientDeviceIDHeader = "X-Client-Device-ID"
+
+	// DeviceIDCookie holds a random ID the API gateway gives every browser and app, so sign-ins from
+	// a device can be told apart from sign-ins elsewhere. It is HTTP only, so unlike the IP address
+	// or user agent, nobody else can find out or copy it.
+	DeviceIDCookie = "device_id"
 )
 
 var defaultHeadersToForward = []string{
@@ -49,5 +69,68 @@ func ForwardHTTPHeadersToGRPC(ctx context.Context, r *http.Request, headersToFor
 		}
 	}
 
+	if userAgent := r.UserAgent(); userAgent != "" {
+		md.Set(ClientUserAgentHeader, userAgent)
+	}
+
+	if cookie, err := r.Cookie(DeviceIDCookie); err == nil && cookie.Value != "" {
+		md.Set(ClientDeviceIDHeader, cookie.Value)
+	}
+
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		md.Set(ClientIPHeader, host)
+	} else if r.RemoteAddr != "" {
+		md.Set(ClientIPHeader, r.RemoteAddr)
+	}
+
 	return metadata.NewOutgoingContext(ctx, md)
 }
+
+// ClientInfoFromIncomingContext returns the IP address and user agent of the client that
+// originated the call, as forwarded by ForwardHTTPHeadersToGRPC. It falls back to the
+// proxy headers and finally to the address of the gRPC peer.
+func ClientInfoFromIncomingContext(ctx context.Context) (string, string) {
+	var ipAddress, userAgent string
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		userAgent = firstMetadataValue(md, ClientUserAgentHeader)
+
+		ipAddress = firstMetadataValue(md, ClientIPHeader)
+		if ipAddress == "" {
+			ipAddress = firstMetadataValue(md, "X-Real-IP")
+		}
+		if ipAddress == "" {
+			forwardedFor := firstMetadataValue(md, "X-Forwarded-For")
+			ipAddress = strings.TrimSpace(strings.Split(forwardedFor, ",")[0])
+		}
+	}
+
+	if ipAddress == "" {
+		if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+			if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+				ipAddress = host
+			}
+		}
+	}
+
+	return ipAddress, userAgent
+}
+
+// ClientDeviceIDFromIncomingContext returns the device ID of the client that originated the call,
+// as forwarded by ForwardHTTPHeadersToGRPC, or an empty string if it has none.
+func ClientDeviceIDFromIncomingContext(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	return firstMetadataValue(md, ClientDeviceIDHeader)
+}
+
+func firstMetadataValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+
+	return ""
+}
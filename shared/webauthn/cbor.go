@@ -0,0 +1,164 @@
+package webauthn
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+var errInvalidCBOR = errors.New("invalid CBOR")
+
+// maxCBORDepth bounds the nesting of decoded items, so a crafted attestation cannot exhaust the stack.
+const maxCBORDepth = 16
+
+// decodeCBOR decodes the first CBOR item in data and returns it together with the bytes that follow it.
+// It supports the subset of CBOR that authenticators produce: definite length items only.
+// Integers decode to int64, byte strings to []byte, text strings to string, arrays to []any
+// and maps to map[any]any.
+func decodeCBOR(data []byte) (any, []byte, error) {
+	return decodeCBORItem(data, 0)
+}
+
+func decodeCBORItem(data []byte, depth int) (any, []byte, error) {
+	if depth > maxCBORDepth {
+		return nil, nil, errInvalidCBOR
+	}
+
+	if len(data) == 0 {
+		return nil, nil, errInvalidCBOR
+	}
+
+	majorType := data[0] >> 5
+	info := data[0] & 0x1f
+
+	// Simple values and floats encode their value in the additional info instead of an argument
+	if majorType == 7 {
+		return decodeCBORSimple(data, info)
+	}
+
+	arg, rest, err := readCBORArgument(data, info)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch majorType {
+	case 0:
+		if arg > math.MaxInt64 {
+			return nil, nil, errInvalidCBOR
+		}
+		return int64(arg), rest, nil
+	case 1:
+		if arg > math.MaxInt64 {
+			return nil, nil, errInvalidCBOR
+		}
+		return -1 - int64(arg), rest, nil
+	case 2, 3:
+		if arg > uint64(len(rest)) {
+			return nil, nil, errInvalidCBOR
+		}
+		if majorType == 3 {
+			return string(rest[:arg]), rest[arg:], nil
+		}
+		return append([]byte(nil), rest[:arg]...), rest[arg:], nil
+	case 4:
+		// Every item takes at least one byte, which bounds the allocation for a forged length
+		if arg > uint64(len(rest)) {
+			return nil, nil, errInvalidCBOR
+		}
+		items := make([]any, 0, arg)
+		for range arg {
+			var item any
+			item, rest, err = decodeCBORItem(rest, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			items = append(items, item)
+		}
+		return items, rest, nil
+	case 5:
+		if arg > uint64(len(rest))/2 {
+			return nil, nil, errInvalidCBOR
+		}
+		items := make(map[any]any, arg)
+		for range arg {
+			var key, value any
+			key, rest, err = decodeCBORItem(rest, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, nil, errInvalidCBOR
+			}
+			value, rest, err = decodeCBORItem(rest, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			items[key] = value
+		}
+		return items, rest, nil
+	case 6:
+		// Tags carry no meaning for WebAuthn, so only the tagged item is kept
+		return decodeCBORItem(rest, depth+1)
+	default:
+		return nil, nil, errInvalidCBOR
+	}
+}
+
+func readCBORArgument(data []byte, info byte) (uint64, []byte, error) {
+	data = data[1:]
+
+	switch {
+	case info < 24:
+		return uint64(info), data, nil
+	case info == 24:
+		if len(data) < 1 {
+			return 0, nil, errInvalidCBOR
+		}
+		return uint64(data[0]), data[1:], nil
+	case info == 25:
+		if len(data) < 2 {
+			return 0, nil, errInvalidCBOR
+		}
+		return uint64(binary.BigEndian.Uint16(data)), data[2:], nil
+	case info == 26:
+		if len(data) < 4 {
+			return 0, nil, errInvalidCBOR
+		}
+		return uint64(binary.BigEndian.Uint32(data)), data[4:], nil
+	case info == 27:
+		if len(data) < 8 {
+			return 0, nil, errInvalidCBOR
+		}
+		return binary.BigEndian.Uint64(data), data[8:], nil
+	default:
+		// Indefinite lengths are not used by authenticators
+		return 0, nil, errInvalidCBOR
+	}
+}
+
+func decodeCBORSimple(data []byte, info byte) (any, []byte, error) {
+	rest := data[1:]
+
+	switch info {
+	case 20:
+		return false, rest, nil
+	case 21:
+		return true, rest, nil
+	case 22, 23:
+		return nil, rest, nil
+	case 26:
+		if len(rest) < 4 {
+			return nil, nil, errInvalidCBOR
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(rest))), rest[4:], nil
+	case 27:
+		if len(rest) < 8 {
+			return nil, nil, errInvalidCBOR
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(rest)), rest[8:], nil
+	default:
+		return nil, nil, errInvalidCBOR
+	}
+}
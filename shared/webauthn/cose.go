@@ -0,0 +1,144 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// COSE algorithm identifiers of the signature algorithms credentials may use.
+const (
+	AlgorithmES256 int64 = -7
+	AlgorithmEdDSA int64 = -8
+	AlgorithmRS256 int64 = -257
+)
+
+// SupportedAlgorithms lists the accepted algorithms in order of preference, for the
+// pubKeyCredParams of a registration.
+var SupportedAlgorithms = []int64{AlgorithmES256, AlgorithmEdDSA, AlgorithmRS256}
+
+// COSE key parameters, see RFC 9053.
+const (
+	coseKeyType      int64 = 1
+	coseKeyAlgorithm int64 = 3
+	coseKeyCurve     int64 = -1
+	coseKeyX         int64 = -2
+	coseKeyY         int64 = -3
+	coseKeyRSAN      int64 = -1
+	coseKeyRSAE      int64 = -2
+
+	coseKeyTypeOKP int64 = 1
+	coseKeyTypeEC2 int64 = 2
+	coseKeyTypeRSA int64 = 3
+
+	coseCurveP256    int64 = 1
+	coseCurveEd25519 int64 = 6
+)
+
+// publicKey is a credential public key together with the algorithm it signs with.
+type publicKey struct {
+	algorithm int64
+	key       crypto.PublicKey
+}
+
+// parsePublicKey parses a COSE_Key encoded credential public key.
+func parsePublicKey(coseKey []byte) (*publicKey, error) {
+	decoded, rest, err := decodeCBOR(coseKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, ErrInvalidPublicKey
+	}
+
+	return publicKeyFromCOSE(decoded)
+}
+
+func publicKeyFromCOSE(decoded any) (*publicKey, error) {
+	params, ok := decoded.(map[any]any)
+	if !ok {
+		return nil, ErrInvalidPublicKey
+	}
+
+	keyType, _ := params[coseKeyType].(int64)
+	algorithm, _ := params[coseKeyAlgorithm].(int64)
+
+	switch {
+	case keyType == coseKeyTypeEC2 && algorithm == AlgorithmES256:
+		curve, _ := params[coseKeyCurve].(int64)
+		x, _ := params[coseKeyX].([]byte)
+		y, _ := params[coseKeyY].([]byte)
+		if curve != coseCurveP256 || len(x) != 32 || len(y) != 32 {
+			return nil, ErrInvalidPublicKey
+		}
+
+		// crypto/ecdh rejects points that are not on the curve
+		point := append(append([]byte{4}, x...), y...)
+		if _, err := ecdh.P256().NewPublicKey(point); err != nil {
+			return nil, ErrInvalidPublicKey
+		}
+
+		return &publicKey{
+			algorithm: algorithm,
+			key: &ecdsa.PublicKey{
+				Curve: elliptic.P256(),
+				X:     new(big.Int).SetBytes(x),
+				Y:     new(big.Int).SetBytes(y),
+			},
+		}, nil
+	case keyType == coseKeyTypeOKP && algorithm == AlgorithmEdDSA:
+		curve, _ := params[coseKeyCurve].(int64)
+		x, _ := params[coseKeyX].([]byte)
+		if curve != coseCurveEd25519 || len(x) != ed25519.PublicKeySize {
+			return nil, ErrInvalidPublicKey
+		}
+
+		return &publicKey{algorithm: algorithm, key: ed25519.PublicKey(x)}, nil
+	case keyType == coseKeyTypeRSA && algorithm == AlgorithmRS256:
+		n, _ := params[coseKeyRSAN].([]byte)
+		e, _ := params[coseKeyRSAE].([]byte)
+		if len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return nil, ErrInvalidPublicKey
+		}
+
+		return &publicKey{
+			algorithm: algorithm,
+			key: &rsa.PublicKey{
+				N: new(big.Int).SetBytes(n),
+				E: int(new(big.Int).SetBytes(e).Int64()),
+			},
+		}, nil
+	default:
+		return nil, fmt.Errorf("%w: key type %d with algorithm %d", ErrUnsupportedAlgorithm, keyType, algorithm)
+	}
+}
+
+// verify checks a signature made by the credential over data.
+func (k *publicKey) verify(data, signature []byte) error {
+	var valid bool
+	switch key := k.key.(type) {
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(data)
+		valid = ecdsa.VerifyASN1(key, digest[:], signature)
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(key, data, signature)
+	case *rsa.PublicKey:
+		digest := sha256.Sum256(data)
+		valid = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil
+	default:
+		return errors.New("unexpected public key type")
+	}
+
+	if !valid {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
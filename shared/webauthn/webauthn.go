@@ -0,0 +1,370 @@
+// Package webauthn implements the relying party side of WebAuthn registration and authentication
+// ceremonies for passkeys.
+package webauthn
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+var (
+	ErrInvalidClientData     = errors.New("invalid client data")
+	ErrChallengeMismatch     = errors.New("challenge mismatch")
+	ErrOriginNotAllowed      = errors.New("origin is not allowed")
+	ErrInvalidAuthData       = errors.New("invalid authenticator data")
+	ErrRPIDMismatch          = errors.New("relying party id mismatch")
+	ErrUserNotPresent        = errors.New("user presence was not confirmed")
+	ErrUserNotVerified       = errors.New("user verification was not performed")
+	ErrInvalidAttestation    = errors.New("invalid attestation")
+	ErrInvalidPublicKey      = errors.New("invalid credential public key")
+	ErrUnsupportedAlgorithm  = errors.New("unsupported credential algorithm")
+	ErrInvalidSignature      = errors.New("invalid signature")
+	ErrSignCountNotIncreased = errors.New("signature counter did not increase")
+)
+
+// Ceremony types reported in the client data.
+const (
+	ceremonyCreate = "webauthn.create"
+	ceremonyGet    = "webauthn.get"
+)
+
+// Authenticator data flags.
+const (
+	flagUserPresent            byte = 1 << 0
+	flagUserVerified           byte = 1 << 2
+	flagBackupEligible         byte = 1 << 3
+	flagBackedUp               byte = 1 << 4
+	flagAttestedCredentialData byte = 1 << 6
+	flagExtensionData          byte = 1 << 7
+)
+
+// RelyingParty verifies the responses of authenticators for one relying party.
+type RelyingParty struct {
+	ID      string
+	Name    string
+	Origins []string
+}
+
+// NewRelyingParty creates a relying party. id is the domain credentials are scoped to, and
+// origins are the exact origins, e.g. https://app.example.com, ceremonies may be run from.
+func NewRelyingParty(id, name string, origins []string) *RelyingParty {
+	return &RelyingParty{
+		ID:      id,
+		Name:    name,
+		Origins: origins,
+	}
+}
+
+// Credential is a newly registered credential.
+type Credential struct {
+	ID             []byte
+	PublicKey      []byte
+	SignCount      uint32
+	AAGUID         []byte
+	BackupEligible bool
+	BackedUp       bool
+}
+
+// Assertion is the result of a successful authentication ceremony.
+type Assertion struct {
+	SignCount uint32
+	BackedUp  bool
+}
+
+// ClientData is the client data a browser collects for a ceremony.
+type ClientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+// NewChallenge returns a random base64url encoded challenge.
+func NewChallenge() (string, error) {
+	challenge := make([]byte, 32)
+	if _, err := rand.Read(challenge); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(challenge), nil
+}
+
+// ParseClientData parses the clientDataJSON of a ceremony response. It lets a server find the
+// ceremony a response belongs to by its challenge before the response is verified.
+func ParseClientData(clientDataJSON []byte) (*ClientData, error) {
+	var clientData ClientData
+	if err := json.Unmarshal(clientDataJSON, &clientData); err != nil {
+		return nil, ErrInvalidClientData
+	}
+
+	if clientData.Challenge == "" {
+		return nil, ErrInvalidClientData
+	}
+
+	return &clientData, nil
+}
+
+// VerifyRegistration verifies the response to a registration ceremony started with challenge and
+// returns the new credential. User verification is required, so a passkey is never weaker than the
+// password it replaces.
+//
+// Only "none" and "packed" attestations are accepted, and a packed attestation certificate is not
+// chained to a trust anchor: registrations ask for no attestation, so the statement only has to be
+// consistent with the credential.
+func (rp *RelyingParty) VerifyRegistration(
+	challenge string,
+	clientDataJSON []byte,
+	attestationObject []byte,
+) (*Credential, error) {
+	if err := rp.verifyClientData(clientDataJSON, ceremonyCreate, challenge); err != nil {
+		return nil, err
+	}
+
+	decoded, rest, err := decodeCBOR(attestationObject)
+	if err != nil || len(rest) != 0 {
+		return nil, ErrInvalidAttestation
+	}
+	attestation, ok := decoded.(map[any]any)
+	if !ok {
+		return nil, ErrInvalidAttestation
+	}
+
+	format, _ := attestation["fmt"].(string)
+	statement, _ := attestation["attStmt"].(map[any]any)
+	rawAuthData, _ := attestation["authData"].([]byte)
+	if statement == nil {
+		return nil, ErrInvalidAttestation
+	}
+
+	authData, err := rp.parseAuthData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+
+	if authData.credential == nil {
+		return nil, ErrInvalidAuthData
+	}
+
+	credentialKey, err := parsePublicKey(authData.credential.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signedData := append(append([]byte{}, rawAuthData...), clientDataHash[:]...)
+
+	switch format {
+	case "none":
+		if len(statement) != 0 {
+			return nil, ErrInvalidAttestation
+		}
+	case "packed":
+		if err := verifyPackedAttestation(statement, credentialKey, signedData); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w: unsupported format %q", ErrInvalidAttestation, format)
+	}
+
+	return authData.credential, nil
+}
+
+// VerifyAssertion verifies the response to an authentication ceremony started with challenge,
+// signed by the credential with the given COSE public key and last known signature counter.
+func (rp *RelyingParty) VerifyAssertion(
+	challenge string,
+	credentialPublicKey []byte,
+	storedSignCount uint32,
+	clientDataJSON []byte,
+	authenticatorData []byte,
+	signature []byte,
+) (*Assertion, error) {
+	if err := rp.verifyClientData(clientDataJSON, ceremonyGet, challenge); err != nil {
+		return nil, err
+	}
+
+	authData, err := rp.parseAuthData(authenticatorData)
+	if err != nil {
+		return nil, err
+	}
+
+	credentialKey, err := parsePublicKey(credentialPublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signedData := append(append([]byte{}, authenticatorData...), clientDataHash[:]...)
+	if err := credentialKey.verify(signedData, signature); err != nil {
+		return nil, err
+	}
+
+	// Authenticators that do not count signatures always report zero. Any other value has to
+	// increase, otherwise the credential may have been cloned.
+	if (authData.signCount != 0 || storedSignCount != 0) && authData.signCount <= storedSignCount {
+		return nil, ErrSignCountNotIncreased
+	}
+
+	return &Assertion{
+		SignCount: authData.signCount,
+		BackedUp:  authData.flags&flagBackedUp != 0,
+	}, nil
+}
+
+func (rp *RelyingParty) verifyClientData(clientDataJSON []byte, ceremony, challenge string) error {
+	clientData, err := ParseClientData(clientDataJSON)
+	if err != nil {
+		return err
+	}
+
+	if clientData.Type != ceremony {
+		return ErrInvalidClientData
+	}
+
+	if subtle.ConstantTimeCompare([]byte(clientData.Challenge), []byte(challenge)) != 1 {
+		return ErrChallengeMismatch
+	}
+
+	if clientData.CrossOrigin || !slices.Contains(rp.Origins, clientData.Origin) {
+		return ErrOriginNotAllowed
+	}
+
+	return nil
+}
+
+type authData struct {
+	flags      byte
+	signCount  uint32
+	credential *Credential
+}
+
+// parseAuthData parses authenticator data and checks that it was produced for this relying party
+// by a present and verified user.
+func (rp *RelyingParty) parseAuthData(data []byte) (*authData, error) {
+	// rpIdHash (32) | flags (1) | signCount (4)
+	if len(data) < 37 {
+		return nil, ErrInvalidAuthData
+	}
+
+	rpIDHash := sha256.Sum256([]byte(rp.ID))
+	if subtle.ConstantTimeCompare(data[:32], rpIDHash[:]) != 1 {
+		return nil, ErrRPIDMismatch
+	}
+
+	parsed := &authData{
+		flags:     data[32],
+		signCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+
+	if parsed.flags&flagUserPresent == 0 {
+		return nil, ErrUserNotPresent
+	}
+	if parsed.flags&flagUserVerified == 0 {
+		return nil, ErrUserNotVerified
+	}
+	// A credential can only be backed up if it is eligible for backup
+	if parsed.flags&flagBackedUp != 0 && parsed.flags&flagBackupEligible == 0 {
+		return nil, ErrInvalidAuthData
+	}
+
+	rest := data[37:]
+	if parsed.flags&flagAttestedCredentialData != 0 {
+		// aaguid (16) | credentialIdLength (2) | credentialId | credentialPublicKey
+		if len(rest) < 18 {
+			return nil, ErrInvalidAuthData
+		}
+		aaguid := rest[:16]
+		idLength := int(binary.BigEndian.Uint16(rest[16:18]))
+		rest = rest[18:]
+		if idLength > 1023 || len(rest) < idLength {
+			return nil, ErrInvalidAuthData
+		}
+		credentialID := rest[:idLength]
+		rest = rest[idLength:]
+
+		// The public key is the only item without a length prefix, so its length is found by decoding it
+		_, after, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, ErrInvalidAuthData
+		}
+		parsed.credential = &Credential{
+			ID:             bytes.Clone(credentialID),
+			PublicKey:      bytes.Clone(rest[:len(rest)-len(after)]),
+			SignCount:      parsed.signCount,
+			AAGUID:         bytes.Clone(aaguid),
+			BackupEligible: parsed.flags&flagBackupEligible != 0,
+			BackedUp:       parsed.flags&flagBackedUp != 0,
+		}
+		rest = after
+	}
+
+	if parsed.flags&flagExtensionData != 0 {
+		_, after, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, ErrInvalidAuthData
+		}
+		rest = after
+	}
+
+	if len(rest) != 0 {
+		return nil, ErrInvalidAuthData
+	}
+
+	return parsed, nil
+}
+
+// verifyPackedAttestation verifies a packed attestation statement, which is either signed by the
+// credential itself or by the attestation certificate in x5c.
+func verifyPackedAttestation(statement map[any]any, credentialKey *publicKey, signedData []byte) error {
+	algorithm, _ := statement["alg"].(int64)
+	signature, _ := statement["sig"].([]byte)
+	if len(signature) == 0 {
+		return ErrInvalidAttestation
+	}
+
+	certificates, hasCertificates := statement["x5c"].([]any)
+	if !hasCertificates {
+		if algorithm != credentialKey.algorithm {
+			return ErrInvalidAttestation
+		}
+
+		if err := credentialKey.verify(signedData, signature); err != nil {
+			return ErrInvalidAttestation
+		}
+
+		return nil
+	}
+
+	if len(certificates) == 0 {
+		return ErrInvalidAttestation
+	}
+	rawCertificate, _ := certificates[0].([]byte)
+	certificate, err := x509.ParseCertificate(rawCertificate)
+	if err != nil {
+		return ErrInvalidAttestation
+	}
+
+	signatureAlgorithm, ok := map[int64]x509.SignatureAlgorithm{
+		AlgorithmES256: x509.ECDSAWithSHA256,
+		AlgorithmEdDSA: x509.PureEd25519,
+		AlgorithmRS256: x509.SHA256WithRSA,
+	}[algorithm]
+	if !ok {
+		return ErrInvalidAttestation
+	}
+
+	if err := certificate.CheckSignature(signatureAlgorithm, signedData, signature); err != nil {
+		return ErrInvalidAttestation
+	}
+
+	return nil
+}
@@ -0,0 +1,565 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+const (
+	testRPID      = "example.com"
+	testOrigin    = "https://app.example.com"
+	testChallenge = "Y2hhbGxlbmdlLWZvci10ZXN0cw"
+)
+
+// softwareAuthenticator plays the part of a passkey authenticator, producing the responses a browser
+// would pass on for it.
+type softwareAuthenticator struct {
+	name         string
+	algorithm    int64
+	signer       crypto.Signer
+	credentialID []byte
+}
+
+func newES256Authenticator(t *testing.T) *softwareAuthenticator {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate ES256 key: %v", err)
+	}
+
+	return &softwareAuthenticator{
+		name:         "ES256",
+		algorithm:    AlgorithmES256,
+		signer:       key,
+		credentialID: []byte("es256-credential"),
+	}
+}
+
+func newEd25519Authenticator(t *testing.T) *softwareAuthenticator {
+	t.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate Ed25519 key: %v", err)
+	}
+
+	return &softwareAuthenticator{
+		name:         "EdDSA",
+		algorithm:    AlgorithmEdDSA,
+		signer:       key,
+		credentialID: []byte("ed25519-credential"),
+	}
+}
+
+func testAuthenticators(t *testing.T) []*softwareAuthenticator {
+	return []*softwareAuthenticator{newES256Authenticator(t), newEd25519Authenticator(t)}
+}
+
+func (a *softwareAuthenticator) coseKey() []byte {
+	switch key := a.signer.Public().(type) {
+	case *ecdsa.PublicKey:
+		return cborMap(
+			cborInt(coseKeyType), cborInt(coseKeyTypeEC2),
+			cborInt(coseKeyAlgorithm), cborInt(AlgorithmES256),
+			cborInt(coseKeyCurve), cborInt(coseCurveP256),
+			cborInt(coseKeyX), cborBytes(key.X.FillBytes(make([]byte, 32))),
+			cborInt(coseKeyY), cborBytes(key.Y.FillBytes(make([]byte, 32))),
+		)
+	case ed25519.PublicKey:
+		return cborMap(
+			cborInt(coseKeyType), cborInt(coseKeyTypeOKP),
+			cborInt(coseKeyAlgorithm), cborInt(AlgorithmEdDSA),
+			cborInt(coseKeyCurve), cborInt(coseCurveEd25519),
+			cborInt(coseKeyX), cborBytes(key),
+		)
+	default:
+		panic("unexpected key type")
+	}
+}
+
+func (a *softwareAuthenticator) sign(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	var (
+		signature []byte
+		err       error
+	)
+	if a.algorithm == AlgorithmEdDSA {
+		signature, err = a.signer.Sign(rand.Reader, data, crypto.Hash(0))
+	} else {
+		digest := sha256.Sum256(data)
+		signature, err = a.signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	}
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+
+	return signature
+}
+
+// ceremony describes what the authenticator and the browser report for a ceremony. Tests start from
+// a valid one and change single fields.
+type ceremony struct {
+	clientDataType string
+	challenge      string
+	origin         string
+	rpID           string
+	flags          byte
+	signCount      uint32
+}
+
+func validCeremony(clientDataType string) ceremony {
+	return ceremony{
+		clientDataType: clientDataType,
+		challenge:      testChallenge,
+		origin:         testOrigin,
+		rpID:           testRPID,
+		flags:          flagUserPresent | flagUserVerified,
+		signCount:      1,
+	}
+}
+
+func (c ceremony) clientDataJSON(t *testing.T) []byte {
+	t.Helper()
+
+	clientDataJSON, err := json.Marshal(ClientData{Type: c.clientDataType, Challenge: c.challenge, Origin: c.origin})
+	if err != nil {
+		t.Fatalf("failed to encode client data: %v", err)
+	}
+
+	return clientDataJSON
+}
+
+// authData encodes the authenticator data, with the attested credential of a when it is not nil.
+func (c ceremony) authData(a *softwareAuthenticator) []byte {
+	rpIDHash := sha256.Sum256([]byte(c.rpID))
+	data := append([]byte{}, rpIDHash[:]...)
+
+	flags := c.flags
+	if a != nil {
+		flags |= flagAttestedCredentialData
+	}
+	data = append(data, flags)
+	data = binary.BigEndian.AppendUint32(data, c.signCount)
+
+	if a != nil {
+		data = append(data, make([]byte, 16)...)
+		data = binary.BigEndian.AppendUint16(data, uint16(len(a.credentialID)))
+		data = append(data, a.credentialID...)
+		data = append(data, a.coseKey()...)
+	}
+
+	return data
+}
+
+// attestationObject returns a registration response with a "none" attestation or, for "packed", a
+// self attestation signed by the credential.
+func (a *softwareAuthenticator) attestationObject(t *testing.T, format string, authData, clientDataJSON []byte) []byte {
+	t.Helper()
+
+	statement := cborMap()
+	if format == "packed" {
+		clientDataHash := sha256.Sum256(clientDataJSON)
+		signature := a.sign(t, append(append([]byte{}, authData...), clientDataHash[:]...))
+		statement = cborMap(
+			cborText("alg"), cborInt(a.algorithm),
+			cborText("sig"), cborBytes(signature),
+		)
+	}
+
+	return cborMap(
+		cborText("fmt"), cborText(format),
+		cborText("attStmt"), statement,
+		cborText("authData"), cborBytes(authData),
+	)
+}
+
+func newTestRelyingParty() *RelyingParty {
+	return NewRelyingParty(testRPID, "Test", []string{testOrigin})
+}
+
+func TestVerifyRegistration(t *testing.T) {
+	rp := newTestRelyingParty()
+
+	for _, authenticator := range testAuthenticators(t) {
+		for _, format := range []string{"none", "packed"} {
+			t.Run(authenticator.name+"/"+format, func(t *testing.T) {
+				c := validCeremony(ceremonyCreate)
+				clientDataJSON := c.clientDataJSON(t)
+				attestationObject := authenticator.attestationObject(
+					t,
+					format,
+					c.authData(authenticator),
+					clientDataJSON,
+				)
+
+				credential, err := rp.VerifyRegistration(testChallenge, clientDataJSON, attestationObject)
+				if err != nil {
+					t.Fatalf("VerifyRegistration() error = %v", err)
+				}
+
+				if !bytes.Equal(credential.ID, authenticator.credentialID) {
+					t.Errorf("credential ID = %q, want %q", credential.ID, authenticator.credentialID)
+				}
+				if !bytes.Equal(credential.PublicKey, authenticator.coseKey()) {
+					t.Error("credential public key does not match the authenticator's key")
+				}
+				if credential.SignCount != c.signCount {
+					t.Errorf("sign count = %d, want %d", credential.SignCount, c.signCount)
+				}
+			})
+		}
+	}
+}
+
+func TestVerifyRegistrationRejectsInvalidResponses(t *testing.T) {
+	rp := newTestRelyingParty()
+	authenticator := newES256Authenticator(t)
+
+	tests := []struct {
+		name    string
+		modify  func(c *ceremony)
+		format  string
+		corrupt func(attestationObject []byte) []byte
+		wantErr error
+	}{
+		{
+			name:    "other relying party",
+			modify:  func(c *ceremony) { c.rpID = "evil.example" },
+			wantErr: ErrRPIDMismatch,
+		},
+		{
+			name:    "other origin",
+			modify:  func(c *ceremony) { c.origin = "https://evil.example" },
+			wantErr: ErrOriginNotAllowed,
+		},
+		{
+			name:    "other challenge",
+			modify:  func(c *ceremony) { c.challenge = "b3RoZXItY2hhbGxlbmdl" },
+			wantErr: ErrChallengeMismatch,
+		},
+		{
+			name:    "authentication client data",
+			modify:  func(c *ceremony) { c.clientDataType = ceremonyGet },
+			wantErr: ErrInvalidClientData,
+		},
+		{
+			name:    "user not present",
+			modify:  func(c *ceremony) { c.flags &^= flagUserPresent },
+			wantErr: ErrUserNotPresent,
+		},
+		{
+			name:    "user not verified",
+			modify:  func(c *ceremony) { c.flags &^= flagUserVerified },
+			wantErr: ErrUserNotVerified,
+		},
+		{
+			name:    "backed up without being eligible",
+			modify:  func(c *ceremony) { c.flags |= flagBackedUp },
+			wantErr: ErrInvalidAuthData,
+		},
+		{
+			name:    "truncated attestation object",
+			corrupt: func(attestationObject []byte) []byte { return attestationObject[:len(attestationObject)-10] },
+			wantErr: ErrInvalidAttestation,
+		},
+		{
+			name:    "trailing bytes",
+			corrupt: func(attestationObject []byte) []byte { return append(attestationObject, 0x00) },
+			wantErr: ErrInvalidAttestation,
+		},
+		{
+			name: "oversized map",
+			corrupt: func(attestationObject []byte) []byte {
+				// A map header claiming four billion entries
+				return append([]byte{0xba, 0xff, 0xff, 0xff, 0xff}, attestationObject[1:]...)
+			},
+			wantErr: ErrInvalidAttestation,
+		},
+		{
+			name:   "forged packed signature",
+			format: "packed",
+			corrupt: func(attestationObject []byte) []byte {
+				forged := newES256Authenticator(t)
+				c := validCeremony(ceremonyCreate)
+				return forged.attestationObject(t, "packed", c.authData(authenticator), c.clientDataJSON(t))
+			},
+			wantErr: ErrInvalidAttestation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := validCeremony(ceremonyCreate)
+			if tt.modify != nil {
+				tt.modify(&c)
+			}
+			format := tt.format
+			if format == "" {
+				format = "none"
+			}
+
+			clientDataJSON := c.clientDataJSON(t)
+			attestationObject := authenticator.attestationObject(t, format, c.authData(authenticator), clientDataJSON)
+			if tt.corrupt != nil {
+				attestationObject = tt.corrupt(attestationObject)
+			}
+
+			_, err := rp.VerifyRegistration(testChallenge, clientDataJSON, attestationObject)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("VerifyRegistration() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyAssertion(t *testing.T) {
+	rp := newTestRelyingParty()
+
+	for _, authenticator := range testAuthenticators(t) {
+		t.Run(authenticator.name, func(t *testing.T) {
+			c := validCeremony(ceremonyGet)
+			c.signCount = 8
+			clientDataJSON := c.clientDataJSON(t)
+			authData := c.authData(nil)
+			signature := authenticator.signAssertion(t, authData, clientDataJSON)
+
+			assertion, err := rp.VerifyAssertion(
+				testChallenge,
+				authenticator.coseKey(),
+				7,
+				clientDataJSON,
+				authData,
+				signature,
+			)
+			if err != nil {
+				t.Fatalf("VerifyAssertion() error = %v", err)
+			}
+
+			if assertion.SignCount != 8 {
+				t.Errorf("sign count = %d, want 8", assertion.SignCount)
+			}
+		})
+	}
+}
+
+func TestVerifyAssertionAcceptsAuthenticatorsWithoutCounter(t *testing.T) {
+	rp := newTestRelyingParty()
+	authenticator := newEd25519Authenticator(t)
+
+	c := validCeremony(ceremonyGet)
+	c.signCount = 0
+	clientDataJSON := c.clientDataJSON(t)
+	authData := c.authData(nil)
+	signature := authenticator.signAssertion(t, authData, clientDataJSON)
+
+	if _, err := rp.VerifyAssertion(
+		testChallenge,
+		authenticator.coseKey(),
+		0,
+		clientDataJSON,
+		authData,
+		signature,
+	); err != nil {
+		t.Fatalf("VerifyAssertion() error = %v", err)
+	}
+}
+
+func TestVerifyAssertionRejectsInvalidResponses(t *testing.T) {
+	rp := newTestRelyingParty()
+	authenticator := newES256Authenticator(t)
+
+	const storedSignCount = 5
+
+	tests := []struct {
+		name    string
+		modify  func(c *ceremony)
+		corrupt func(authData []byte) []byte
+		signer  *softwareAuthenticator
+		wantErr error
+	}{
+		{
+			name:    "other relying party",
+			modify:  func(c *ceremony) { c.rpID = "evil.example" },
+			wantErr: ErrRPIDMismatch,
+		},
+		{
+			name:    "other origin",
+			modify:  func(c *ceremony) { c.origin = "https://evil.example" },
+			wantErr: ErrOriginNotAllowed,
+		},
+		{
+			name:    "other challenge",
+			modify:  func(c *ceremony) { c.challenge = "b3RoZXItY2hhbGxlbmdl" },
+			wantErr: ErrChallengeMismatch,
+		},
+		{
+			name:    "registration client data",
+			modify:  func(c *ceremony) { c.clientDataType = ceremonyCreate },
+			wantErr: ErrInvalidClientData,
+		},
+		{
+			name:    "user not present",
+			modify:  func(c *ceremony) { c.flags &^= flagUserPresent },
+			wantErr: ErrUserNotPresent,
+		},
+		{
+			name:    "user not verified",
+			modify:  func(c *ceremony) { c.flags &^= flagUserVerified },
+			wantErr: ErrUserNotVerified,
+		},
+		{
+			name:    "sign count not increased",
+			modify:  func(c *ceremony) { c.signCount = storedSignCount },
+			wantErr: ErrSignCountNotIncreased,
+		},
+		{
+			name:    "sign count reset",
+			modify:  func(c *ceremony) { c.signCount = 0 },
+			wantErr: ErrSignCountNotIncreased,
+		},
+		{
+			name:    "signed by another key",
+			signer:  newEd25519Authenticator(t),
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "truncated authenticator data",
+			corrupt: func(authData []byte) []byte { return authData[:36] },
+			wantErr: ErrInvalidAuthData,
+		},
+		{
+			name:    "trailing bytes",
+			corrupt: func(authData []byte) []byte { return append(authData, 0x00) },
+			wantErr: ErrInvalidAuthData,
+		},
+		{
+			name: "truncated extension data",
+			corrupt: func(authData []byte) []byte {
+				authData[32] |= flagExtensionData
+				// A map with one entry that is missing
+				return append(authData, 0xa1)
+			},
+			wantErr: ErrInvalidAuthData,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := validCeremony(ceremonyGet)
+			c.signCount = storedSignCount + 1
+			if tt.modify != nil {
+				tt.modify(&c)
+			}
+
+			clientDataJSON := c.clientDataJSON(t)
+			authData := c.authData(nil)
+			if tt.corrupt != nil {
+				authData = tt.corrupt(authData)
+			}
+
+			signer := authenticator
+			if tt.signer != nil {
+				signer = tt.signer
+			}
+			signature := signer.signAssertion(t, authData, clientDataJSON)
+
+			_, err := rp.VerifyAssertion(
+				testChallenge,
+				authenticator.coseKey(),
+				storedSignCount,
+				clientDataJSON,
+				authData,
+				signature,
+			)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("VerifyAssertion() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDecodeCBORRejectsMalformedInput(t *testing.T) {
+	deeplyNested := bytes.Repeat([]byte{0x81}, maxCBORDepth+2)
+	deeplyNested = append(deeplyNested, 0x00)
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "empty", data: nil},
+		{name: "truncated argument", data: []byte{0x19, 0x01}},
+		{name: "truncated byte string", data: []byte{0x45, 0x01, 0x02}},
+		{name: "oversized byte string", data: []byte{0x5b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		{name: "oversized array", data: []byte{0x9a, 0xff, 0xff, 0xff, 0xff, 0x00}},
+		{name: "indefinite length", data: []byte{0x9f, 0x00, 0xff}},
+		{name: "byte string map key", data: []byte{0xa1, 0x41, 0x00, 0x00}},
+		{name: "nested too deeply", data: deeplyNested},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := decodeCBOR(tt.data); !errors.Is(err, errInvalidCBOR) {
+				t.Fatalf("decodeCBOR() error = %v, want %v", err, errInvalidCBOR)
+			}
+		})
+	}
+}
+
+func (a *softwareAuthenticator) signAssertion(t *testing.T, authData, clientDataJSON []byte) []byte {
+	t.Helper()
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	return a.sign(t, append(append([]byte{}, authData...), clientDataHash[:]...))
+}
+
+// The CBOR encoding helpers produce the definite length items authenticators use.
+
+func cborHead(majorType byte, argument uint64) []byte {
+	switch {
+	case argument < 24:
+		return []byte{majorType<<5 | byte(argument)}
+	case argument <= 0xff:
+		return []byte{majorType<<5 | 24, byte(argument)}
+	case argument <= 0xffff:
+		return binary.BigEndian.AppendUint16([]byte{majorType<<5 | 25}, uint16(argument))
+	default:
+		return binary.BigEndian.AppendUint32([]byte{majorType<<5 | 26}, uint32(argument))
+	}
+}
+
+func cborInt(value int64) []byte {
+	if value < 0 {
+		return cborHead(1, uint64(-1-value))
+	}
+
+	return cborHead(0, uint64(value))
+}
+
+func cborBytes(value []byte) []byte {
+	return append(cborHead(2, uint64(len(value))), value...)
+}
+
+func cborText(value string) []byte {
+	return append(cborHead(3, uint64(len(value))), value...)
+}
+
+// cborMap encodes a map from alternating encoded keys and values.
+func cborMap(keysAndValues ...[]byte) []byte {
+	encoded := cborHead(5, uint64(len(keysAndValues)/2))
+	for _, item := range keysAndValues {
+		encoded = append(encoded, item...)
+	}
+
+	return encoded
+}